@@ -1,74 +1,210 @@
 package main
 
 import (
-	"log"
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
 	"net/http"
 	"os"
-	"strings"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"x86trade_backend/internal/antispam"
+	"x86trade_backend/internal/config"
 	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/handlers"
+	"x86trade_backend/internal/handlers/admin_handlers"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/mailer"
+	"x86trade_backend/internal/metrics"
 	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/ratelimit"
+	"x86trade_backend/internal/repository"
 	"x86trade_backend/internal/routes"
 	"x86trade_backend/internal/server"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/tracing"
+	"x86trade_backend/internal/utils"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	// загрузка .env
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found or error loading .env — relying on environment variables")
-	} else {
-		log.Println(".env loaded")
-	}
+	configFile := flag.String("config", "", "path to YAML/TOML config file (default: $CONFIG_FILE)")
+	printConfig := flag.Bool("print-config", false, "print effective configuration with secrets redacted and exit")
+	flag.Parse()
 
-	// Конфиг CORS из env
-	originsStr := os.Getenv("FRONTEND_ORIGIN")
-	origins := []string{}
-	if originsStr != "" {
-		for _, o := range strings.Split(originsStr, ",") {
-			if s := strings.TrimSpace(o); s != "" {
-				origins = append(origins, s)
-			}
+	// Конфигурация: defaults → файл → .env → окружение; все ошибки сразу
+	cfg, cfgErr := config.Load(config.Options{File: *configFile})
+	if *printConfig {
+		config.Print(os.Stdout, cfg)
+		if cfgErr != nil {
+			fmt.Fprintln(os.Stderr, cfgErr)
+			os.Exit(1)
 		}
+		return
 	}
-	if len(origins) == 0 {
-		origins = []string{"http://localhost:5500", "http://127.0.0.1:5500"}
+	if cfgErr != nil {
+		fmt.Fprintln(os.Stderr, cfgErr)
+		os.Exit(1)
 	}
 
-	allowCred := false
-	if os.Getenv("CORS_ALLOW_CREDENTIALS") == "1" || strings.ToLower(os.Getenv("CORS_ALLOW_CREDENTIALS")) == "true" {
-		allowCred = true
-	}
+	// Логгер: LOG_LEVEL (debug|info|warn|error), LOG_FORMAT (json|text)
+	logging.Setup(cfg.Log.Level, cfg.Log.Format)
+	slog.Info("configuration loaded", "env", cfg.App.Env, "config_file", cfg.File, "dotenv_loaded", cfg.DotEnvLoaded)
+
+	utils.ConfigureJWT(cfg.JWT.Secret, cfg.JWT.AccessMinutes, cfg.JWT.RefreshDays)
 
-	// DEBUG
-	debug := middleware.GetDebugFromEnv()
-	if debug {
-		log.Println("DEBUG mode enabled — each endpoint result will be logged")
+	// Трассировка (OTEL_TRACES_EXPORTER=otlp|stdout|none)
+	shutdownTracing, err := tracing.Setup(tracing.Config{
+		Exporter:     cfg.Tracing.Exporter,
+		ServiceName:  cfg.Tracing.ServiceName,
+		OTLPEndpoint: cfg.Tracing.OTLPTracesURL(),
+		OTLPHeaders:  cfg.Tracing.OTLPHeaders(),
+		SampleRatio:  cfg.Tracing.SampleRatio,
+	})
+	if err != nil {
+		slog.Error("tracing setup failed", "error", err)
+		os.Exit(1)
 	}
 
-	// DB connect
-	db.Connect()
+	// SIGTERM/SIGINT отменяет ctx: прерывает ожидание БД при старте и запускает graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	// DB connect (с повторными попытками)
+	if err := db.Connect(ctx, cfg.DB); err != nil {
+		slog.Error("db connect failed", "error", err)
+		os.Exit(1)
+	}
 	defer db.DB.Close()
 
+	// Применяем миграции схемы
+	if err := db.Migrate(ctx); err != nil {
+		slog.Error("migrate failed", "error", err)
+		os.Exit(1)
+	}
+
+	// Метрики пула соединений отдаются вместе с остальными на /metrics
+	metrics.RegisterDBStats(db.DB.Stats)
+
+	// Доверенные прокси (X-Forwarded-For) и лимиты частоты запросов — до регистрации роутов
+	if err := middleware.SetTrustedProxies(cfg.HTTP.TrustedProxies); err != nil {
+		slog.Error("invalid trusted proxies", "error", err)
+		os.Exit(1)
+	}
+	if cfg.RateLimit.Enabled {
+		var store ratelimit.Store = ratelimit.NewMemoryStore()
+		if cfg.RateLimit.Backend == "postgres" {
+			pg := ratelimit.NewPostgresStore(db.DB)
+			go pg.RunCleanup(ctx, 10*time.Minute, 24*time.Hour)
+			store = pg
+		}
+		middleware.ConfigureRateLimits(store, map[string]ratelimit.Policy{
+			middleware.LimitAuth:         cfg.RateLimit.Auth,
+			middleware.LimitContact:      cfg.RateLimit.Contact,
+			middleware.LimitCatalog:      cfg.RateLimit.Catalog,
+			middleware.LimitUser:         cfg.RateLimit.User,
+			middleware.LimitApplications: cfg.RateLimit.Applications,
+			middleware.LimitSuggest:      cfg.RateLimit.Suggest,
+		})
+		slog.Info("rate limiting enabled", "backend", cfg.RateLimit.Backend)
+	}
+
+	// Антиспам формы обратной связи: honeypot, время заполнения, ссылки, стоп-лист из БД
+	formTokens := antispam.NewTokens(cfg.AntiSpam.Secret)
+	spam := antispam.NewPipeline(cfg.AntiSpam.Threshold)
+	if cfg.AntiSpam.Enabled {
+		spam.Use(antispam.Honeypot{})
+		spam.Use(antispam.FormTime{Tokens: formTokens, MinFill: cfg.AntiSpam.MinFillTime, MaxAge: cfg.AntiSpam.TokenTTL})
+		spam.Use(antispam.Links{MaxLinks: cfg.AntiSpam.MaxLinks})
+		spam.Use(antispam.Blocklist{Source: repository.GetSpamBlocklist})
+	}
+	handlers.ConfigureContactSpam(spam, formTokens, cfg.AntiSpam.MinFillTime)
+
+	// Хранилище загруженных файлов (STORAGE_BACKEND=local|s3)
+	var files storage.Storage
+	if cfg.Storage.Backend == "s3" {
+		files, err = storage.NewS3(storage.S3Config{
+			Endpoint:  cfg.Storage.S3Endpoint,
+			Region:    cfg.Storage.S3Region,
+			Bucket:    cfg.Storage.S3Bucket,
+			AccessKey: cfg.Storage.S3AccessKey,
+			SecretKey: cfg.Storage.S3SecretKey,
+			PathStyle: cfg.Storage.S3PathStyle,
+			Timeout:   cfg.Storage.S3Timeout,
+		})
+	} else {
+		files, err = storage.NewLocal(cfg.Storage.LocalDir)
+	}
+	if err != nil {
+		slog.Error("storage setup failed", "backend", cfg.Storage.Backend, "error", err)
+		os.Exit(1)
+	}
+	storage.Files = files
+	storage.PublicBaseURL = cfg.Storage.PublicURL
+	handlers.ConfigureVacancyApplications(int64(cfg.Storage.MaxCVSizeMB) << 20)
+	handlers.ConfigureReviewImages(cfg.Storage.MaxReviewImages, int64(cfg.Storage.MaxImageSizeMB)<<20)
+	admin_handlers.ConfigureProductImages(cfg.Storage.MaxProductImages, int64(cfg.Storage.MaxImageSizeMB)<<20)
+
+	// Почта для ответов на обращения (MAIL_BACKEND=log|smtp)
+	if cfg.Mail.Backend == "smtp" {
+		m, err := mailer.NewSMTPMailer(mailer.SMTPConfig{
+			Host:     cfg.Mail.SMTPHost,
+			Port:     cfg.Mail.SMTPPort,
+			Username: cfg.Mail.SMTPUsername,
+			Password: cfg.Mail.SMTPPassword,
+			From:     cfg.Mail.From,
+			Timeout:  cfg.Mail.SMTPTimeout,
+		})
+		if err != nil {
+			slog.Error("mailer setup failed", "error", err)
+			os.Exit(1)
+		}
+		admin_handlers.ConfigureMailer(m)
+	}
+
 	// Создаем роутер
 	router := chi.NewRouter()
 
+	// ID запроса, access-лог и перехват паник — до всего остального, чтобы любая ошибка ушла
+	// в едином JSON-формате и попала в лог с request_id
+	router.Use(middleware.RequestID)
+	router.Use(middleware.Tracing)
+	router.Use(middleware.LoggingMiddleware(cfg.Log.ResponseBodies))
+	router.Use(middleware.Metrics)
+	router.Use(middleware.Recoverer)
+	router.NotFound(middleware.NotFound)
+	router.MethodNotAllowed(middleware.MethodNotAllowed)
+
 	// Применяем CORS (вынесено в internal/server)
-	server.ApplyCORS(router, origins, allowCred)
+	server.ApplyCORS(router, cfg.CORS.Origins, cfg.CORS.AllowCredentials)
+
+	// Prometheus (text format), без авторизации — предполагается скрейп из внутренней сети
+	router.Method(http.MethodGet, "/metrics", metrics.Handler())
 
-	// Подключаем logging middleware (включается когда DEBUG=true)
-	router.Use(middleware.LoggingMiddleware(debug))
+	// Пробы для оркестратора: liveness и readiness (БД + миграции)
+	router.Get("/healthz", handlers.HealthzHandler)
+	router.Get("/readyz", handlers.ReadyzHandler)
 
 	// Настраиваем остальные роуты
 	routes.SetupRoutes(router)
 
-	addr := ":" + os.Getenv("APP_PORT")
-	if os.Getenv("APP_PORT") == "" {
-		addr = ":8080"
-	}
+	addr := cfg.App.Addr()
+	srv := server.NewHTTPServer(addr, router, cfg.HTTP)
+	slog.Info("server listening", "addr", addr, "cors_origins", cfg.CORS.Origins, "allow_credentials", cfg.CORS.AllowCredentials)
+	err = server.Run(ctx, srv, cfg.HTTP.ShutdownTimeout, handlers.SetDraining)
+
+	// Отправляем накопленные спаны до выхода
+	tctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	_ = shutdownTracing(tctx)
+	cancel()
 
-	log.Printf("Server listening on %s (CORS origins=%v allowCred=%v)\n", addr, origins, allowCred)
-	log.Fatal(http.ListenAndServe(addr, router))
+	if err != nil {
+		slog.Error("server stopped", "error", err)
+		db.DB.Close()
+		os.Exit(1)
+	}
 }
@@ -0,0 +1,57 @@
+// Команда backfill-ratings пересчитывает денормализованные рейтинги товаров (product_ratings)
+// из таблицы reviews: после ручных правок отзывов в БД или при подозрении на расхождение.
+//
+//	go run ./cmd/backfill-ratings [-config file] [-product id]
+package main
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
+
+	"x86trade_backend/internal/config"
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/repository"
+)
+
+func main() {
+	configFile := flag.String("config", "", "path to YAML/TOML config file (default: $CONFIG_FILE)")
+	productID := flag.Int("product", 0, "recompute only this product (default: all products)")
+	flag.Parse()
+
+	cfg, err := config.Load(config.Options{File: *configFile})
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+	logging.Setup(cfg.Log.Level, cfg.Log.Format)
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+
+	if err := db.Connect(ctx, cfg.DB); err != nil {
+		slog.Error("db connect failed", "error", err)
+		os.Exit(1)
+	}
+	defer db.DB.Close()
+
+	// таблица product_ratings создаётся миграцией
+	if err := db.Migrate(ctx); err != nil {
+		slog.Error("migrate failed", "error", err)
+		os.Exit(1)
+	}
+
+	started := time.Now()
+	n, err := repository.RecomputeProductRatings(ctx, *productID)
+	if err != nil {
+		slog.Error("recompute ratings failed", "error", err)
+		os.Exit(1)
+	}
+	slog.Info("product ratings recomputed", "products", n, "duration", time.Since(started))
+}
@@ -0,0 +1,82 @@
+// Package antispam — проверка сообщений формы обратной связи на спам.
+// Pipeline прогоняет сообщение через набор проверок (Check); каждая сработавшая проверка
+// добавляет очки и причину. Сообщение, набравшее не меньше порога, помечается как спам —
+// но всё равно сохраняется, чтобы администратор мог его просмотреть и при ошибке вернуть.
+package antispam
+
+import (
+	"context"
+	"time"
+
+	"x86trade_backend/internal/logging"
+)
+
+// Submission — отправленная форма и сведения о запросе.
+type Submission struct {
+	FullName    string
+	ContactInfo string
+	Message     string
+	// Honeypot — скрытое поле формы: человек его не видит и не заполняет, боты — заполняют.
+	Honeypot string
+	// FormToken — подписанный токен, выданный при открытии формы (см. Tokens).
+	FormToken  string
+	IP         string
+	ReceivedAt time.Time
+}
+
+// Hit — сработавшее правило.
+type Hit struct {
+	Reason string
+	Score  int
+}
+
+// Check — одна проверка. Ошибка (например, недоступна БД) не блокирует сообщение:
+// проверка пропускается, остальные продолжают работать.
+type Check interface {
+	Name() string
+	Check(ctx context.Context, s *Submission) ([]Hit, error)
+}
+
+// Verdict — итог проверки сообщения.
+type Verdict struct {
+	Score   int
+	Reasons []string
+	Spam    bool
+}
+
+// Pipeline — упорядоченный набор проверок с порогом срабатывания.
+type Pipeline struct {
+	threshold int
+	checks    []Check
+}
+
+// NewPipeline создаёт конвейер: сообщение с суммой очков >= threshold считается спамом.
+func NewPipeline(threshold int, checks ...Check) *Pipeline {
+	return &Pipeline{threshold: threshold, checks: checks}
+}
+
+// Use добавляет проверку в конец конвейера.
+func (p *Pipeline) Use(c Check) {
+	p.checks = append(p.checks, c)
+}
+
+// Evaluate прогоняет сообщение через все проверки. Nil-конвейер ничего не помечает.
+func (p *Pipeline) Evaluate(ctx context.Context, s *Submission) Verdict {
+	var v Verdict
+	if p == nil {
+		return v
+	}
+	for _, c := range p.checks {
+		hits, err := c.Check(ctx, s)
+		if err != nil {
+			logging.FromContext(ctx).Warn("antispam check failed, skipping", "check", c.Name(), "error", err)
+			continue
+		}
+		for _, h := range hits {
+			v.Score += h.Score
+			v.Reasons = append(v.Reasons, h.Reason)
+		}
+	}
+	v.Spam = p.threshold > 0 && v.Score >= p.threshold
+	return v
+}
@@ -0,0 +1,125 @@
+package antispam
+
+import (
+	"context"
+	"regexp"
+	"strings"
+	"time"
+
+	"x86trade_backend/internal/models"
+)
+
+// Honeypot помечает сообщения с заполненным скрытым полем — почти наверняка бот.
+type Honeypot struct{}
+
+func (Honeypot) Name() string { return "honeypot" }
+
+func (Honeypot) Check(_ context.Context, s *Submission) ([]Hit, error) {
+	if strings.TrimSpace(s.Honeypot) != "" {
+		return []Hit{{Reason: "honeypot", Score: 100}}, nil
+	}
+	return nil, nil
+}
+
+// FormTime проверяет токен формы: он должен быть, быть подписан нами и выдан не раньше
+// MaxAge и не позже MinFill до отправки (человек не заполняет форму за секунду).
+type FormTime struct {
+	Tokens  *Tokens
+	MinFill time.Duration
+	MaxAge  time.Duration
+}
+
+func (FormTime) Name() string { return "form_time" }
+
+func (c FormTime) Check(_ context.Context, s *Submission) ([]Hit, error) {
+	if s.FormToken == "" {
+		return []Hit{{Reason: "form_token_missing", Score: 50}}, nil
+	}
+	issued, err := c.Tokens.IssuedAt(s.FormToken)
+	if err != nil {
+		return []Hit{{Reason: "form_token_invalid", Score: 50}}, nil
+	}
+	elapsed := s.ReceivedAt.Sub(issued)
+	switch {
+	case elapsed < c.MinFill:
+		return []Hit{{Reason: "filled_too_fast", Score: 50}}, nil
+	case c.MaxAge > 0 && elapsed > c.MaxAge:
+		// вкладку могли просто долго не закрывать — подозрительно, но не приговор
+		return []Hit{{Reason: "form_token_expired", Score: 20}}, nil
+	}
+	return nil, nil
+}
+
+var (
+	linkRe   = regexp.MustCompile(`(?i)\b(?:https?://|www\.)\S+`)
+	markupRe = regexp.MustCompile(`(?i)\[url[=\]]|<a\s+href`)
+	emailRe  = regexp.MustCompile(`(?i)[a-z0-9._%+\-]+@[a-z0-9.\-]+\.[a-z]{2,}`)
+)
+
+// Links — эвристики по ссылкам: их слишком много, BB-код/HTML-разметка ссылок,
+// ссылка вместо имени.
+type Links struct {
+	MaxLinks int
+}
+
+func (Links) Name() string { return "links" }
+
+func (c Links) Check(_ context.Context, s *Submission) ([]Hit, error) {
+	var hits []Hit
+	if n := len(linkRe.FindAllString(s.Message, -1)); n > c.MaxLinks {
+		hits = append(hits, Hit{Reason: "too_many_links", Score: 50})
+	}
+	if markupRe.MatchString(s.Message) {
+		hits = append(hits, Hit{Reason: "link_markup", Score: 50})
+	}
+	if linkRe.MatchString(s.FullName) {
+		hits = append(hits, Hit{Reason: "link_in_name", Score: 50})
+	}
+	return hits, nil
+}
+
+// BlocklistSource возвращает актуальный стоп-лист (обычно repository.GetSpamBlocklist).
+type BlocklistSource func(ctx context.Context) ([]models.SpamBlocklistEntry, error)
+
+// Blocklist сверяет сообщение со стоп-листом, который ведут администраторы:
+// слова/фразы ищутся во всех полях без учёта регистра, адреса — среди e-mail в контактах и тексте.
+type Blocklist struct {
+	Source BlocklistSource
+}
+
+func (Blocklist) Name() string { return "blocklist" }
+
+func (c Blocklist) Check(ctx context.Context, s *Submission) ([]Hit, error) {
+	entries, err := c.Source(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if len(entries) == 0 {
+		return nil, nil
+	}
+
+	text := strings.ToLower(s.FullName + "\n" + s.ContactInfo + "\n" + s.Message)
+	emails := emailRe.FindAllString(text, -1)
+
+	var hits []Hit
+	for _, e := range entries {
+		v := strings.ToLower(strings.TrimSpace(e.Value))
+		if v == "" {
+			continue
+		}
+		switch e.Kind {
+		case models.SpamBlockTerm:
+			if strings.Contains(text, v) {
+				hits = append(hits, Hit{Reason: "blocklisted_term:" + e.Value, Score: 100})
+			}
+		case models.SpamBlockEmail:
+			for _, addr := range emails {
+				if addr == v || (strings.HasPrefix(v, "@") && strings.HasSuffix(addr, v)) {
+					hits = append(hits, Hit{Reason: "blocklisted_email:" + e.Value, Score: 100})
+					break
+				}
+			}
+		}
+	}
+	return hits, nil
+}
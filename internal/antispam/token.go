@@ -0,0 +1,70 @@
+package antispam
+
+import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// ErrInvalidToken — токен формы повреждён или подписан другим ключом.
+var ErrInvalidToken = errors.New("invalid form token")
+
+// maxClockSkew — насколько время выдачи токена может быть «в будущем» (часы разных инстансов).
+const maxClockSkew = time.Minute
+
+// Tokens выдаёт и проверяет токены формы: "<unix-время выдачи>.<nonce>.<HMAC-SHA256>".
+// Токен ничего не хранит на сервере — по нему только восстанавливается, когда форму открыли.
+type Tokens struct {
+	key []byte
+	now func() time.Time
+}
+
+// NewTokens создаёт выпускающего токены. Ключ подписи выводится из secret, так что
+// можно переиспользовать общий секрет приложения, не рискуя подделкой JWT.
+func NewTokens(secret string) *Tokens {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte("x86trade contact form token"))
+	return &Tokens{key: mac.Sum(nil), now: time.Now}
+}
+
+// Issue выпускает токен с текущим временем.
+func (t *Tokens) Issue() string {
+	nonce := make([]byte, 8)
+	_, _ = rand.Read(nonce)
+	payload := strconv.FormatInt(t.now().Unix(), 10) + "." + hex.EncodeToString(nonce)
+	return payload + "." + t.sign(payload)
+}
+
+// IssuedAt проверяет подпись и возвращает время выдачи токена.
+func (t *Tokens) IssuedAt(token string) (time.Time, error) {
+	i := strings.LastIndexByte(token, '.')
+	if i < 0 {
+		return time.Time{}, ErrInvalidToken
+	}
+	payload, sig := token[:i], token[i+1:]
+	if !hmac.Equal([]byte(sig), []byte(t.sign(payload))) {
+		return time.Time{}, ErrInvalidToken
+	}
+	ts, _, _ := strings.Cut(payload, ".")
+	sec, err := strconv.ParseInt(ts, 10, 64)
+	if err != nil {
+		return time.Time{}, ErrInvalidToken
+	}
+	issued := time.Unix(sec, 0)
+	if issued.After(t.now().Add(maxClockSkew)) {
+		return time.Time{}, ErrInvalidToken
+	}
+	return issued, nil
+}
+
+func (t *Tokens) sign(payload string) string {
+	mac := hmac.New(sha256.New, t.key)
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
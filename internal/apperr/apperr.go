@@ -0,0 +1,135 @@
+// Package apperr — типизированные ошибки приложения и единый JSON-формат ответа об ошибке:
+//
+//	{"code": "...", "message": "...", "details": ..., "request_id": "..."}
+//
+// Хендлеры возвращают error, а не пишут ответ сами; рендеринг — в Write.
+package apperr
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"x86trade_backend/internal/validation"
+
+	"github.com/lib/pq"
+)
+
+// Стабильные коды ошибок верхнего уровня.
+const (
+	CodeBadRequest       = "bad_request"
+	CodeUnauthorized     = "unauthorized"
+	CodeForbidden        = "forbidden"
+	CodeNotFound         = "not_found"
+	CodeConflict         = "conflict"
+	CodeValidation       = "validation_failed"
+	CodeMethodNotAllowed = "method_not_allowed"
+	CodeInternal         = "internal_error"
+)
+
+// Error — ошибка с HTTP-статусом и кодом для клиента. Cause в ответ не попадает, только в лог.
+type Error struct {
+	Status  int
+	Code    string
+	Message string
+	Details interface{}
+	Cause   error
+}
+
+func (e *Error) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%s: %s: %v", e.Code, e.Message, e.Cause)
+	}
+	return e.Code + ": " + e.Message
+}
+
+func (e *Error) Unwrap() error { return e.Cause }
+
+// WithCause прикрепляет исходную ошибку (для логов и errors.Is).
+func (e *Error) WithCause(err error) *Error {
+	e.Cause = err
+	return e
+}
+
+// New создаёт ошибку с произвольным статусом и кодом (например, "cart_empty").
+func New(status int, code, message string) *Error {
+	return &Error{Status: status, Code: code, Message: message}
+}
+
+func BadRequest(message string) *Error {
+	return New(http.StatusBadRequest, CodeBadRequest, message)
+}
+
+func Unauthorized(message string) *Error {
+	return New(http.StatusUnauthorized, CodeUnauthorized, message)
+}
+
+func Forbidden(message string) *Error {
+	return New(http.StatusForbidden, CodeForbidden, message)
+}
+
+func NotFound(message string) *Error {
+	return New(http.StatusNotFound, CodeNotFound, message)
+}
+
+func Conflict(message string) *Error {
+	return New(http.StatusConflict, CodeConflict, message)
+}
+
+// Validation — 422 с деталями по полям (обычно validation.Errors).
+func Validation(message string, details interface{}) *Error {
+	return &Error{Status: http.StatusUnprocessableEntity, Code: CodeValidation, Message: message, Details: details}
+}
+
+// Internal оборачивает непредвиденную ошибку; клиент увидит только "internal server error".
+func Internal(err error) *Error {
+	return &Error{Status: http.StatusInternalServerError, Code: CodeInternal, Message: "internal server error", Cause: err}
+}
+
+// From приводит произвольную ошибку к *Error. Здесь же централизованно разбираются
+// ошибки Postgres, чтобы хендлерам не нужно было знать про коды SQLSTATE.
+// sql.ErrNoRows сюда доходить не должен: «не найдено» хендлеры отдают сами (NotFound),
+// а неожиданное отсутствие строки — внутренняя ошибка (500).
+func From(err error) *Error {
+	if err == nil {
+		return nil
+	}
+
+	var ae *Error
+	if errors.As(err, &ae) {
+		return ae
+	}
+
+	var ve validation.Errors
+	if errors.As(err, &ve) {
+		return Validation("request validation failed", ve)
+	}
+
+	var pqErr *pq.Error
+	if errors.As(err, &pqErr) {
+		switch pqErr.Code {
+		case "23503": // foreign_key_violation
+			if isDeleteFK(pqErr) {
+				return Conflict("cannot delete, resource is referenced by other records").WithCause(err)
+			}
+			return Validation("referenced resource does not exist", nil).WithCause(err)
+		case "23505": // unique_violation
+			return Conflict("resource already exists").WithCause(err)
+		case "23502", "23514", "22P02", "22001", "22003": // not_null, check, invalid text, too long, out of range
+			return Validation("invalid value", nil).WithCause(err)
+		}
+	}
+
+	return Internal(err)
+}
+
+// isDeleteFK отличает удаление строки, на которую ссылаются ("... is still referenced from table ..."),
+// от вставки со ссылкой на несуществующую строку ("... is not present in table ...").
+func isDeleteFK(e *pq.Error) bool {
+	return containsFold(e.Detail, "is still referenced") || containsFold(e.Message, "update or delete")
+}
+
+func containsFold(s, sub string) bool {
+	return strings.Contains(strings.ToLower(s), strings.ToLower(sub))
+}
@@ -0,0 +1,50 @@
+package apperr
+
+import (
+	"encoding/json"
+	"net/http"
+
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/requestid"
+)
+
+type envelope struct {
+	Code      string      `json:"code"`
+	Message   string      `json:"message"`
+	Details   interface{} `json:"details,omitempty"`
+	RequestID string      `json:"request_id,omitempty"`
+}
+
+// Write рендерит ошибку в едином JSON-формате. Внутренние ошибки логируются с причиной.
+func Write(w http.ResponseWriter, r *http.Request, err error) {
+	ae := From(err)
+	reqID := requestid.FromContext(r.Context())
+
+	if ae.Status >= http.StatusInternalServerError {
+		logging.FromContext(r.Context()).Error("request failed",
+			"method", r.Method, "path", r.URL.Path, "error", ae.Error())
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(ae.Status)
+	_ = json.NewEncoder(w).Encode(envelope{
+		Code:      ae.Code,
+		Message:   ae.Message,
+		Details:   ae.Details,
+		RequestID: reqID,
+	})
+}
+
+// HandlerFunc — хендлер, возвращающий ошибку вместо записи ответа.
+type HandlerFunc func(w http.ResponseWriter, r *http.Request) error
+
+func (h HandlerFunc) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if err := h(w, r); err != nil {
+		Write(w, r, err)
+	}
+}
+
+// Handler адаптирует HandlerFunc к http.HandlerFunc для регистрации в chi.
+func Handler(h HandlerFunc) http.HandlerFunc {
+	return h.ServeHTTP
+}
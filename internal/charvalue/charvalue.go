@@ -0,0 +1,183 @@
+// Package charvalue — разбор и нормализация значений характеристик товаров по типу данных
+// (models.CharacteristicType.DataType). Нормализованное значение хранится текстом в
+// product_characteristics.value, а для числовых типов ещё и числом в value_num.
+package charvalue
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/models"
+)
+
+// Value — нормализованное значение: Text — каноническая запись, Num — число для сравнения.
+type Value struct {
+	Text string
+	Num  *float64
+}
+
+// ValidType сообщает, что t — известный тип данных.
+func ValidType(t string) bool {
+	switch t {
+	case models.CharString, models.CharNumber, models.CharInteger, models.CharBoolean, models.CharEnum:
+		return true
+	}
+	return false
+}
+
+// CheckDefinition проверяет описание типа характеристики: enum без допустимых значений,
+// диапазон у нечислового типа и т.п.
+func CheckDefinition(t *models.CharacteristicType) error {
+	if !ValidType(t.DataType) {
+		return fmt.Errorf("data_type must be one of string, number, integer, boolean, enum")
+	}
+	numeric := t.DataType == models.CharNumber || t.DataType == models.CharInteger
+	if !numeric && (t.MinValue != nil || t.MaxValue != nil) {
+		return errors.New("min_value/max_value are allowed only for number and integer types")
+	}
+	if t.MinValue != nil && t.MaxValue != nil && *t.MinValue > *t.MaxValue {
+		return errors.New("min_value must not exceed max_value")
+	}
+	if t.DataType == models.CharEnum && len(t.AllowedValues) == 0 {
+		return errors.New("allowed_values are required for enum type")
+	}
+	if t.DataType != models.CharEnum && len(t.AllowedValues) > 0 {
+		return errors.New("allowed_values are allowed only for enum type")
+	}
+	seen := map[string]bool{}
+	for _, v := range t.AllowedValues {
+		k := strings.ToLower(collapseSpaces(v))
+		if k == "" {
+			return errors.New("allowed_values must not contain empty values")
+		}
+		if seen[k] {
+			return fmt.Errorf("allowed_values contain duplicate %q", v)
+		}
+		seen[k] = true
+	}
+	return nil
+}
+
+// numberRe — число (допускаются пробелы-разделители тысяч и запятая) и необязательная единица.
+var numberRe = regexp.MustCompile(`^([+-]?[0-9][0-9 \x{00A0}]*(?:[.,][0-9]+)?)\s*(.*)$`)
+
+// Normalize разбирает введённое значение под тип характеристики и возвращает каноническую
+// форму. Ошибка описывает, что не так со значением, — её можно показать администратору.
+func Normalize(t *models.CharacteristicType, raw string) (Value, error) {
+	s := collapseSpaces(raw)
+	if s == "" {
+		return Value{}, errors.New("value is empty")
+	}
+
+	switch t.DataType {
+	case models.CharNumber, models.CharInteger:
+		m := numberRe.FindStringSubmatch(s)
+		if m == nil {
+			return Value{}, fmt.Errorf("%q is not a number", raw)
+		}
+		if unit := m[2]; unit != "" && !sameUnit(unit, t.Unit) {
+			if t.Unit == "" {
+				return Value{}, fmt.Errorf("unexpected unit %q", unit)
+			}
+			return Value{}, fmt.Errorf("unit %q does not match %q", unit, t.Unit)
+		}
+		digits := strings.NewReplacer(" ", "", "\u00a0", "", ",", ".").Replace(m[1])
+		x, err := strconv.ParseFloat(digits, 64)
+		if err != nil || math.IsInf(x, 0) {
+			return Value{}, fmt.Errorf("%q is not a number", raw)
+		}
+		if t.DataType == models.CharInteger && x != math.Trunc(x) {
+			return Value{}, fmt.Errorf("%q is not an integer", raw)
+		}
+		if t.MinValue != nil && x < *t.MinValue {
+			return Value{}, fmt.Errorf("%s is below minimum %s", formatNum(x), formatNum(*t.MinValue))
+		}
+		if t.MaxValue != nil && x > *t.MaxValue {
+			return Value{}, fmt.Errorf("%s is above maximum %s", formatNum(x), formatNum(*t.MaxValue))
+		}
+		return Value{Text: formatNum(x), Num: &x}, nil
+
+	case models.CharBoolean:
+		var b bool
+		switch strings.ToLower(s) {
+		case "true", "yes", "1", "да", "есть", "+":
+			b = true
+		case "false", "no", "0", "нет", "-":
+		default:
+			return Value{}, fmt.Errorf("%q is not a yes/no value", raw)
+		}
+		n := 0.0
+		if b {
+			n = 1
+		}
+		return Value{Text: strconv.FormatBool(b), Num: &n}, nil
+
+	case models.CharEnum:
+		for _, allowed := range t.AllowedValues {
+			if strings.EqualFold(collapseSpaces(allowed), s) {
+				return Value{Text: collapseSpaces(allowed)}, nil
+			}
+		}
+		return Value{}, fmt.Errorf("%q is not one of %s", raw, strings.Join(t.AllowedValues, ", "))
+	}
+
+	return Value{Text: s}, nil
+}
+
+func formatNum(x float64) string {
+	return strconv.FormatFloat(x, 'f', -1, 64)
+}
+
+func collapseSpaces(s string) string {
+	return strings.Join(strings.Fields(s), " ")
+}
+
+// unitAliases — написания одной и той же единицы (латиница/кириллица). Перевода между
+// единицами (МГц -> ГГц) нет: значение в другой единице считается ошибкой.
+var unitAliases = [][]string{
+	{"w", "вт"},
+	{"ghz", "ггц"},
+	{"mhz", "мгц"},
+	{"gb", "гб"},
+	{"mb", "мб"},
+	{"tb", "тб"},
+	{"kb", "кб"},
+	{"nm", "нм"},
+	{"mm", "мм"},
+	{"v", "в"},
+	{"rpm", "об/мин"},
+	{"db", "дб"},
+	{"gb/s", "гб/с"},
+	{"mb/s", "мб/с"},
+	{"gt/s", "гт/с"},
+	{"mt/s", "мт/с"},
+	{"шт", "pcs"},
+	{"°c", "c", "°с"},
+}
+
+// sameUnit сравнивает единицы без учёта регистра, пробелов, точки в конце и алфавита.
+func sameUnit(a, b string) bool {
+	a, b = canonUnit(a), canonUnit(b)
+	if a == b {
+		return true
+	}
+	for _, group := range unitAliases {
+		ina, inb := false, false
+		for _, u := range group {
+			ina = ina || u == a
+			inb = inb || u == b
+		}
+		if ina && inb {
+			return true
+		}
+	}
+	return false
+}
+
+func canonUnit(u string) string {
+	return strings.TrimSuffix(strings.ToLower(strings.ReplaceAll(u, " ", "")), ".")
+}
@@ -0,0 +1,218 @@
+// Package config — вся конфигурация приложения в одном типизированном struct.
+// Значения собираются один раз при старте в порядке возрастания приоритета:
+// значения по умолчанию → файл конфигурации (YAML или TOML, опционально) → .env → переменные окружения.
+// Описание каждого параметра — в тегах полей:
+//
+//	env      — имя переменной окружения
+//	file     — ключ в файле конфигурации (section.key)
+//	default  — значение по умолчанию
+//	required — значение обязательно
+//	secret   — не показывается в --print-config
+//	min/max  — допустимый диапазон для чисел и длительностей
+//	oneof    — допустимые значения через |
+package config
+
+import (
+	"strconv"
+	"strings"
+	"time"
+
+	"x86trade_backend/internal/ratelimit"
+)
+
+// Окружения приложения.
+const (
+	EnvDevelopment = "development"
+	EnvProduction  = "production"
+)
+
+// devJWTSecret — секрет по умолчанию для локальной разработки; в production запрещён.
+const devJWTSecret = "verysecretkey"
+
+type Config struct {
+	App     App
+	DB      DB
+	HTTP    HTTP
+	CORS    CORS
+	JWT     JWT
+	Log     Log
+	Tracing Tracing
+
+	RateLimit RateLimit
+	AntiSpam  AntiSpam
+	Mail      Mail
+	Storage   Storage
+
+	// Откуда что загружено — для логов при старте.
+	File         string
+	DotEnvLoaded bool
+
+	sources map[string]string // env-ключ -> default|file|env
+}
+
+type App struct {
+	Env  string `env:"APP_ENV" file:"app.env" default:"development" oneof:"development|production"`
+	Port int    `env:"APP_PORT" file:"app.port" default:"8080" min:"1" max:"65535"`
+}
+
+type DB struct {
+	Host            string `env:"DB_HOST" file:"db.host" default:"localhost" required:"true"`
+	Port            int    `env:"DB_PORT" file:"db.port" default:"5432" min:"1" max:"65535"`
+	User            string `env:"DB_USER" file:"db.user" required:"true"`
+	Password        string `env:"DB_PASS" file:"db.password" secret:"true"`
+	Name            string `env:"DB_NAME" file:"db.name" required:"true"`
+	SSLMode         string `env:"DB_SSLMODE" file:"db.sslmode" default:"require" oneof:"disable|allow|prefer|require|verify-ca|verify-full"`
+	ConnectAttempts int    `env:"DB_CONNECT_ATTEMPTS" file:"db.connect_attempts" default:"10" min:"1" max:"100"`
+	MaxOpenConns    int    `env:"DB_MAX_OPEN_CONNS" file:"db.max_open_conns" default:"25" min:"1" max:"1000"`
+	MaxIdleConns    int    `env:"DB_MAX_IDLE_CONNS" file:"db.max_idle_conns" default:"25" min:"0" max:"1000"`
+}
+
+type HTTP struct {
+	ReadTimeout     time.Duration `env:"HTTP_READ_TIMEOUT" file:"http.read_timeout" default:"15s" min:"1s" max:"10m"`
+	WriteTimeout    time.Duration `env:"HTTP_WRITE_TIMEOUT" file:"http.write_timeout" default:"30s" min:"1s" max:"10m"`
+	IdleTimeout     time.Duration `env:"HTTP_IDLE_TIMEOUT" file:"http.idle_timeout" default:"60s" min:"1s" max:"1h"`
+	ShutdownTimeout time.Duration `env:"SHUTDOWN_TIMEOUT" file:"http.shutdown_timeout" default:"25s" min:"1s" max:"10m"`
+	// TrustedProxies — CIDR/адреса прокси, которым верим в X-Forwarded-For; пусто — не верим никому.
+	TrustedProxies []string `env:"TRUSTED_PROXIES" file:"http.trusted_proxies"`
+}
+
+type CORS struct {
+	Origins          []string `env:"FRONTEND_ORIGIN" file:"cors.origins" default:"http://localhost:5500,http://127.0.0.1:5500"`
+	AllowCredentials bool     `env:"CORS_ALLOW_CREDENTIALS" file:"cors.allow_credentials" default:"false"`
+}
+
+type JWT struct {
+	Secret        string `env:"JWT_SECRET" file:"jwt.secret" secret:"true"`
+	AccessMinutes int    `env:"JWT_ACCESS_MINUTES" file:"jwt.access_minutes" default:"15" min:"1" max:"1440"`
+	RefreshDays   int    `env:"JWT_REFRESH_DAYS" file:"jwt.refresh_days" default:"7" min:"1" max:"365"`
+}
+
+type Log struct {
+	Level          string `env:"LOG_LEVEL" file:"log.level" default:"info" oneof:"debug|info|warn|warning|error"`
+	Format         string `env:"LOG_FORMAT" file:"log.format" default:"json" oneof:"json|text"`
+	Debug          bool   `env:"DEBUG" file:"log.debug" default:"false"`
+	ResponseBodies bool   `env:"LOG_RESPONSE_BODIES" file:"log.response_bodies" default:"false"`
+}
+
+type Tracing struct {
+	Exporter       string  `env:"OTEL_TRACES_EXPORTER" file:"tracing.exporter" default:"none" oneof:"none|otlp|stdout|console"`
+	ServiceName    string  `env:"OTEL_SERVICE_NAME" file:"tracing.service_name" default:"x86trade_backend"`
+	Endpoint       string  `env:"OTEL_EXPORTER_OTLP_ENDPOINT" file:"tracing.endpoint" default:"http://localhost:4318"`
+	TracesEndpoint string  `env:"OTEL_EXPORTER_OTLP_TRACES_ENDPOINT" file:"tracing.traces_endpoint"`
+	Headers        string  `env:"OTEL_EXPORTER_OTLP_HEADERS" file:"tracing.headers" secret:"true"`
+	SampleRatio    float64 `env:"OTEL_TRACES_SAMPLER_ARG" file:"tracing.sample_ratio" default:"1" min:"0" max:"1"`
+}
+
+// RateLimit — лимиты по группам роутов в формате "N/период" ("5/10m"); "off" выключает группу.
+type RateLimit struct {
+	Enabled      bool             `env:"RATE_LIMIT_ENABLED" file:"rate_limit.enabled" default:"true"`
+	Backend      string           `env:"RATE_LIMIT_BACKEND" file:"rate_limit.backend" default:"memory" oneof:"memory|postgres"`
+	Auth         ratelimit.Policy `env:"RATE_LIMIT_AUTH" file:"rate_limit.auth" default:"10/1m"`
+	Contact      ratelimit.Policy `env:"RATE_LIMIT_CONTACT" file:"rate_limit.contact" default:"5/10m"`
+	Catalog      ratelimit.Policy `env:"RATE_LIMIT_CATALOG" file:"rate_limit.catalog" default:"120/1m"`
+	User         ratelimit.Policy `env:"RATE_LIMIT_USER" file:"rate_limit.user" default:"300/1m"`
+	Applications ratelimit.Policy `env:"RATE_LIMIT_APPLICATIONS" file:"rate_limit.applications" default:"5/1h"`
+	Suggest      ratelimit.Policy `env:"RATE_LIMIT_SUGGEST" file:"rate_limit.suggest" default:"600/1m"`
+}
+
+// AntiSpam — проверки формы обратной связи. Сообщение, набравшее Threshold очков, помечается
+// как спам (сохраняется, но не считается обычной заявкой).
+type AntiSpam struct {
+	Enabled bool `env:"ANTISPAM_ENABLED" file:"antispam.enabled" default:"true"`
+	// Secret — ключ подписи токенов формы; пусто — выводится из JWT_SECRET.
+	Secret      string        `env:"ANTISPAM_SECRET" file:"antispam.secret" secret:"true"`
+	MinFillTime time.Duration `env:"ANTISPAM_MIN_FILL_TIME" file:"antispam.min_fill_time" default:"3s" min:"0s" max:"5m"`
+	TokenTTL    time.Duration `env:"ANTISPAM_TOKEN_TTL" file:"antispam.token_ttl" default:"24h" min:"1m" max:"720h"`
+	MaxLinks    int           `env:"ANTISPAM_MAX_LINKS" file:"antispam.max_links" default:"2" min:"0" max:"100"`
+	Threshold   int           `env:"ANTISPAM_THRESHOLD" file:"antispam.threshold" default:"50" min:"1" max:"1000"`
+}
+
+// Mail — исходящая почта (ответы на обращения). Backend log только пишет письма в лог.
+type Mail struct {
+	Backend      string        `env:"MAIL_BACKEND" file:"mail.backend" default:"log" oneof:"log|smtp"`
+	From         string        `env:"MAIL_FROM" file:"mail.from" default:"x86trade <no-reply@localhost>"`
+	SMTPHost     string        `env:"SMTP_HOST" file:"mail.smtp_host"`
+	SMTPPort     int           `env:"SMTP_PORT" file:"mail.smtp_port" default:"587" min:"1" max:"65535"`
+	SMTPUsername string        `env:"SMTP_USERNAME" file:"mail.smtp_username"`
+	SMTPPassword string        `env:"SMTP_PASSWORD" file:"mail.smtp_password" secret:"true"`
+	SMTPTimeout  time.Duration `env:"SMTP_TIMEOUT" file:"mail.smtp_timeout" default:"30s" min:"1s" max:"5m"`
+}
+
+// Storage — где хранятся загруженные файлы (резюме кандидатов, изображения товаров и отзывов).
+type Storage struct {
+	Backend  string `env:"STORAGE_BACKEND" file:"storage.backend" default:"local" oneof:"local|s3"`
+	LocalDir string `env:"STORAGE_LOCAL_DIR" file:"storage.local_dir" default:"./uploads"`
+	// PublicURL — префикс публичных ссылок на изображения. По умолчанию их раздаёт сам API;
+	// можно указать CDN или публичный адрес бакета.
+	PublicURL string `env:"STORAGE_PUBLIC_URL" file:"storage.public_url" default:"/api/files/"`
+	// S3-совместимое хранилище (STORAGE_BACKEND=s3). Для MinIO нужен S3_PATH_STYLE=true.
+	S3Endpoint  string        `env:"S3_ENDPOINT" file:"storage.s3_endpoint"`
+	S3Region    string        `env:"S3_REGION" file:"storage.s3_region" default:"us-east-1"`
+	S3Bucket    string        `env:"S3_BUCKET" file:"storage.s3_bucket"`
+	S3AccessKey string        `env:"S3_ACCESS_KEY" file:"storage.s3_access_key"`
+	S3SecretKey string        `env:"S3_SECRET_KEY" file:"storage.s3_secret_key" secret:"true"`
+	S3PathStyle bool          `env:"S3_PATH_STYLE" file:"storage.s3_path_style" default:"false"`
+	S3Timeout   time.Duration `env:"S3_TIMEOUT" file:"storage.s3_timeout" default:"30s" min:"1s" max:"5m"`
+	// MaxCVSizeMB — предельный размер файла резюме.
+	MaxCVSizeMB int `env:"UPLOAD_MAX_CV_MB" file:"storage.max_cv_mb" default:"5" min:"1" max:"50"`
+	// MaxImageSizeMB — предельный размер одного загружаемого изображения.
+	MaxImageSizeMB int `env:"UPLOAD_MAX_IMAGE_MB" file:"storage.max_image_mb" default:"5" min:"1" max:"50"`
+	// MaxReviewImages — сколько фотографий можно прикрепить к одному отзыву (0 — нельзя).
+	MaxReviewImages int `env:"UPLOAD_MAX_REVIEW_IMAGES" file:"storage.max_review_images" default:"5" min:"0" max:"20"`
+	// MaxProductImages — сколько изображений может быть у одного товара.
+	MaxProductImages int `env:"UPLOAD_MAX_PRODUCT_IMAGES" file:"storage.max_product_images" default:"10" min:"1" max:"50"`
+}
+
+// DSN — строка подключения для lib/pq.
+func (d DB) DSN() string {
+	return "host=" + quoteDSN(d.Host) +
+		" port=" + strconv.Itoa(d.Port) +
+		" user=" + quoteDSN(d.User) +
+		" password=" + quoteDSN(d.Password) +
+		" dbname=" + quoteDSN(d.Name) +
+		" sslmode=" + quoteDSN(d.SSLMode)
+}
+
+// Addr — адрес, который слушает HTTP-сервер.
+func (a App) Addr() string {
+	return ":" + strconv.Itoa(a.Port)
+}
+
+// IsProduction сообщает, что приложение запущено в production.
+func (a App) IsProduction() bool {
+	return a.Env == EnvProduction
+}
+
+// OTLPTracesURL — полный URL приёма спанов: явный OTEL_EXPORTER_OTLP_TRACES_ENDPOINT
+// или OTEL_EXPORTER_OTLP_ENDPOINT + /v1/traces.
+func (t Tracing) OTLPTracesURL() string {
+	if t.TracesEndpoint != "" {
+		return t.TracesEndpoint
+	}
+	return strings.TrimRight(t.Endpoint, "/") + "/v1/traces"
+}
+
+// OTLPHeaders разбирает OTEL_EXPORTER_OTLP_HEADERS (k=v,k=v).
+func (t Tracing) OTLPHeaders() map[string]string {
+	out := map[string]string{}
+	for _, kv := range strings.Split(t.Headers, ",") {
+		k, v, ok := strings.Cut(kv, "=")
+		if k = strings.TrimSpace(k); ok && k != "" {
+			out[k] = strings.TrimSpace(v)
+		}
+	}
+	return out
+}
+
+// quoteDSN экранирует значение для key=value DSN lib/pq.
+func quoteDSN(v string) string {
+	return "'" + strings.NewReplacer(`\`, `\\`, `'`, `\'`).Replace(v) + "'"
+}
+
+// Source возвращает, откуда взято значение параметра (default, file, env) по имени переменной окружения.
+func (c *Config) Source(envKey string) string {
+	if s, ok := c.sources[envKey]; ok {
+		return s
+	}
+	return "default"
+}
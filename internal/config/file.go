@@ -0,0 +1,149 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"gopkg.in/yaml.v3"
+)
+
+// parseFile читает файл конфигурации и возвращает плоскую карту "section.key" -> значение.
+// Формат выбирается по расширению: .yaml/.yml (gopkg.in/yaml.v3) или .toml (BurntSushi/toml).
+// Скаляры берутся как записаны в файле (30s, 0.5, true), списки склеиваются через запятую
+// (как в переменных окружения).
+func parseFile(path string) (map[string]string, error) {
+	var parse func([]byte) (map[string]string, error)
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		parse = parseYAML
+	case ".toml":
+		parse = parseTOML
+	default:
+		return nil, fmt.Errorf("config file %s: unsupported format (use .yaml, .yml or .toml)", path)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+	out, err := parse(data)
+	if err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+	return out, nil
+}
+
+func parseYAML(data []byte) (map[string]string, error) {
+	var doc yaml.Node
+	if err := yaml.Unmarshal(data, &doc); err != nil && !errors.Is(err, io.EOF) {
+		return nil, err
+	}
+	out := map[string]string{}
+	if len(doc.Content) == 0 {
+		return out, nil
+	}
+	if err := flattenYAML(doc.Content[0], "", out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func parseTOML(data []byte) (map[string]string, error) {
+	var doc map[string]any
+	if err := toml.Unmarshal(data, &doc); err != nil {
+		return nil, err
+	}
+	out := map[string]string{}
+	if err := flattenTOML(doc, "", out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// flattenTOML раскладывает таблицы в ключи через точку.
+func flattenTOML(table map[string]any, prefix string, out map[string]string) error {
+	for k, v := range table {
+		key := k
+		if prefix != "" {
+			key = prefix + "." + k
+		}
+		switch v := v.(type) {
+		case map[string]any:
+			if err := flattenTOML(v, key, out); err != nil {
+				return err
+			}
+		case []map[string]any:
+			return fmt.Errorf("%s: arrays of tables are not supported", key)
+		case []any:
+			items := make([]string, 0, len(v))
+			for _, it := range v {
+				switch it.(type) {
+				case map[string]any, []any:
+					return fmt.Errorf("%s: list items must be scalars", key)
+				}
+				items = append(items, tomlScalar(it))
+			}
+			out[key] = strings.Join(items, ",")
+		default:
+			out[key] = tomlScalar(v)
+		}
+	}
+	return nil
+}
+
+// tomlScalar — значение TOML в том виде, в каком его ждёт setValue (как из переменной окружения).
+func tomlScalar(v any) string {
+	switch v := v.(type) {
+	case string:
+		return v
+	case time.Time:
+		return v.Format(time.RFC3339Nano)
+	default:
+		return fmt.Sprint(v)
+	}
+}
+
+// flattenYAML раскладывает вложенные словари в ключи через точку.
+func flattenYAML(n *yaml.Node, prefix string, out map[string]string) error {
+	if n.Kind == yaml.AliasNode {
+		n = n.Alias
+	}
+	switch n.Kind {
+	case yaml.MappingNode:
+		for i := 0; i+1 < len(n.Content); i += 2 {
+			key := n.Content[i].Value
+			if prefix != "" {
+				key = prefix + "." + key
+			}
+			if err := flattenYAML(n.Content[i+1], key, out); err != nil {
+				return err
+			}
+		}
+	case yaml.SequenceNode:
+		items := make([]string, 0, len(n.Content))
+		for _, it := range n.Content {
+			if it.Kind == yaml.AliasNode {
+				it = it.Alias
+			}
+			if it.Kind != yaml.ScalarNode {
+				return fmt.Errorf("line %d: %s: list items must be scalars", it.Line, prefix)
+			}
+			items = append(items, it.Value)
+		}
+		out[prefix] = strings.Join(items, ",")
+	case yaml.ScalarNode:
+		if prefix == "" {
+			return fmt.Errorf("line %d: expected a mapping at the top level", n.Line)
+		}
+		// "section:" без значения — пустая секция, а не ключ
+		if n.Tag != "!!null" {
+			out[prefix] = n.Value
+		}
+	}
+	return nil
+}
@@ -0,0 +1,312 @@
+package config
+
+import (
+	"encoding"
+	"errors"
+	"fmt"
+	"net/mail"
+	"net/netip"
+	"os"
+	"reflect"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/joho/godotenv"
+)
+
+// Options — откуда брать конфигурацию.
+type Options struct {
+	// File — путь к YAML/TOML-файлу; пусто — берётся из CONFIG_FILE, если задан.
+	File string
+	// DotEnv — путь к .env; пусто — ".env" в рабочем каталоге. Отсутствие файла не ошибка.
+	DotEnv string
+}
+
+// Errors — все найденные проблемы конфигурации разом, чтобы не чинить их по одной.
+type Errors []string
+
+func (e Errors) Error() string {
+	return "invalid configuration:\n  - " + strings.Join(e, "\n  - ")
+}
+
+// Load собирает конфигурацию и проверяет её. При ошибках возвращает Errors со списком
+// всех проблем (и частично заполненный Config — он пригодится для --print-config).
+func Load(opts Options) (*Config, error) {
+	cfg := &Config{sources: map[string]string{}}
+
+	dotenv := opts.DotEnv
+	if dotenv == "" {
+		dotenv = ".env"
+	}
+	// godotenv не перезаписывает уже заданные переменные — окружение процесса приоритетнее .env
+	if err := godotenv.Load(dotenv); err == nil {
+		cfg.DotEnvLoaded = true
+	} else if !errors.Is(err, os.ErrNotExist) {
+		return cfg, fmt.Errorf("load %s: %w", dotenv, err)
+	}
+
+	cfg.File = opts.File
+	if cfg.File == "" {
+		cfg.File = os.Getenv("CONFIG_FILE")
+	}
+	var fileValues map[string]string
+	if cfg.File != "" {
+		var err error
+		if fileValues, err = parseFile(cfg.File); err != nil {
+			return cfg, err
+		}
+	}
+
+	var errs Errors
+	used := map[string]bool{}
+	eachField(cfg, func(f field) {
+		raw, src := f.tag.Get("default"), "default"
+		if fk := f.tag.Get("file"); fk != "" {
+			if v, ok := fileValues[fk]; ok {
+				raw, src = v, "file"
+				used[fk] = true
+			}
+		}
+		if v := strings.TrimSpace(os.Getenv(f.env)); v != "" {
+			raw, src = v, "env"
+		}
+		cfg.sources[f.env] = src
+
+		if err := setValue(f.value, raw); err != nil {
+			errs = append(errs, fmt.Sprintf("%s: %v", f.env, err))
+			return
+		}
+		errs = append(errs, checkField(f)...)
+	})
+
+	// опечатки в файле лучше поймать сразу, чем гадать, почему настройка не применилась
+	var unknown []string
+	for k := range fileValues {
+		if !used[k] {
+			unknown = append(unknown, k)
+		}
+	}
+	sort.Strings(unknown)
+	for _, k := range unknown {
+		errs = append(errs, fmt.Sprintf("%s: unknown key %q", cfg.File, k))
+	}
+
+	errs = append(errs, cfg.finalize()...)
+	if len(errs) > 0 {
+		return cfg, errs
+	}
+	return cfg, nil
+}
+
+// finalize — значения, зависящие от других параметров, и межполевые проверки.
+func (c *Config) finalize() Errors {
+	var errs Errors
+
+	// DEBUG=true без явного LOG_LEVEL включает debug-логи (как раньше)
+	if c.Log.Debug && c.Source("LOG_LEVEL") == "default" {
+		c.Log.Level = "debug"
+		c.sources["LOG_LEVEL"] = "DEBUG=true"
+	}
+
+	switch {
+	case c.JWT.Secret == "" && c.App.IsProduction():
+		errs = append(errs, "JWT_SECRET: required when APP_ENV=production")
+	case c.JWT.Secret == "":
+		c.JWT.Secret = devJWTSecret
+	case c.App.IsProduction() && (c.JWT.Secret == devJWTSecret || len(c.JWT.Secret) < 32):
+		errs = append(errs, "JWT_SECRET: must be at least 32 characters and not the development default when APP_ENV=production")
+	}
+
+	if c.AntiSpam.Secret == "" {
+		c.AntiSpam.Secret = c.JWT.Secret
+		c.sources["ANTISPAM_SECRET"] = "JWT_SECRET"
+	}
+
+	if c.DB.MaxIdleConns > c.DB.MaxOpenConns {
+		errs = append(errs, fmt.Sprintf("DB_MAX_IDLE_CONNS: must not exceed DB_MAX_OPEN_CONNS (%d)", c.DB.MaxOpenConns))
+	}
+	for _, p := range c.HTTP.TrustedProxies {
+		if _, err := netip.ParsePrefix(p); err == nil {
+			continue
+		}
+		if _, err := netip.ParseAddr(p); err != nil {
+			errs = append(errs, fmt.Sprintf("TRUSTED_PROXIES: %q is neither an IP address nor a CIDR", p))
+		}
+	}
+	if c.Mail.Backend == "smtp" && c.Mail.SMTPHost == "" {
+		errs = append(errs, "SMTP_HOST: required when MAIL_BACKEND=smtp")
+	}
+	if c.Storage.Backend == "s3" {
+		for _, p := range [][2]string{
+			{"S3_ENDPOINT", c.Storage.S3Endpoint},
+			{"S3_BUCKET", c.Storage.S3Bucket},
+			{"S3_ACCESS_KEY", c.Storage.S3AccessKey},
+			{"S3_SECRET_KEY", c.Storage.S3SecretKey},
+		} {
+			if p[1] == "" {
+				errs = append(errs, p[0]+": required when STORAGE_BACKEND=s3")
+			}
+		}
+	}
+	if !strings.HasSuffix(c.Storage.PublicURL, "/") {
+		c.Storage.PublicURL += "/"
+	}
+	if _, err := mail.ParseAddress(c.Mail.From); err != nil {
+		errs = append(errs, fmt.Sprintf("MAIL_FROM: %q is not a valid address", c.Mail.From))
+	}
+	if c.CORS.AllowCredentials {
+		for _, o := range c.CORS.Origins {
+			if o == "*" {
+				errs = append(errs, "FRONTEND_ORIGIN: \"*\" cannot be combined with CORS_ALLOW_CREDENTIALS=true")
+			}
+		}
+	}
+	return errs
+}
+
+// field — листовой параметр конфигурации.
+type field struct {
+	section string
+	name    string
+	env     string
+	tag     reflect.StructTag
+	value   reflect.Value
+}
+
+// eachField обходит все параметры (поля секций с тегом env) в порядке объявления.
+func eachField(cfg *Config, fn func(field)) {
+	root := reflect.ValueOf(cfg).Elem()
+	rt := root.Type()
+	for i := 0; i < rt.NumField(); i++ {
+		sf := rt.Field(i)
+		if !sf.IsExported() || sf.Type.Kind() != reflect.Struct {
+			continue
+		}
+		sec := root.Field(i)
+		st := sf.Type
+		for j := 0; j < st.NumField(); j++ {
+			lf := st.Field(j)
+			env := lf.Tag.Get("env")
+			if env == "" {
+				continue
+			}
+			fn(field{section: sf.Name, name: lf.Name, env: env, tag: lf.Tag, value: sec.Field(j)})
+		}
+	}
+}
+
+var durationType = reflect.TypeOf(time.Duration(0))
+
+func setValue(v reflect.Value, raw string) error {
+	raw = strings.TrimSpace(raw)
+	// типы со своим разбором (например, ratelimit.Policy)
+	if u, ok := v.Addr().Interface().(encoding.TextUnmarshaler); ok {
+		return u.UnmarshalText([]byte(raw))
+	}
+	switch {
+	case v.Type() == durationType:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		d, err := time.ParseDuration(raw)
+		if err != nil {
+			return fmt.Errorf("invalid duration %q (examples: 500ms, 15s, 2m)", raw)
+		}
+		v.SetInt(int64(d))
+	case v.Kind() == reflect.String:
+		v.SetString(raw)
+	case v.Kind() == reflect.Int:
+		if raw == "" {
+			v.SetInt(0)
+			return nil
+		}
+		n, err := strconv.Atoi(raw)
+		if err != nil {
+			return fmt.Errorf("invalid integer %q", raw)
+		}
+		v.SetInt(int64(n))
+	case v.Kind() == reflect.Float64:
+		if raw == "" {
+			v.SetFloat(0)
+			return nil
+		}
+		f, err := strconv.ParseFloat(raw, 64)
+		if err != nil {
+			return fmt.Errorf("invalid number %q", raw)
+		}
+		v.SetFloat(f)
+	case v.Kind() == reflect.Bool:
+		switch strings.ToLower(raw) {
+		case "1", "true", "yes", "on":
+			v.SetBool(true)
+		case "", "0", "false", "no", "off":
+			v.SetBool(false)
+		default:
+			return fmt.Errorf("invalid boolean %q (use true/false)", raw)
+		}
+	case v.Kind() == reflect.Slice && v.Type().Elem().Kind() == reflect.String:
+		var items []string
+		for _, s := range strings.Split(raw, ",") {
+			if s = strings.TrimSpace(s); s != "" {
+				items = append(items, s)
+			}
+		}
+		v.Set(reflect.ValueOf(items))
+	default:
+		return fmt.Errorf("unsupported config field type %s", v.Type())
+	}
+	return nil
+}
+
+// checkField проверяет required, min/max и oneof.
+func checkField(f field) Errors {
+	var errs Errors
+	v := f.value
+
+	if f.tag.Get("required") == "true" && v.IsZero() {
+		errs = append(errs, fmt.Sprintf("%s: required", f.env))
+		return errs
+	}
+
+	if oneof := f.tag.Get("oneof"); oneof != "" && v.Kind() == reflect.String {
+		allowed := strings.Split(oneof, "|")
+		ok := false
+		for _, a := range allowed {
+			if strings.EqualFold(v.String(), a) {
+				v.SetString(a)
+				ok = true
+				break
+			}
+		}
+		if !ok {
+			errs = append(errs, fmt.Sprintf("%s: %q is not one of %s", f.env, v.String(), strings.Join(allowed, ", ")))
+		}
+	}
+
+	minTag, maxTag := f.tag.Get("min"), f.tag.Get("max")
+	if minTag == "" && maxTag == "" {
+		return errs
+	}
+	switch {
+	case v.Type() == durationType:
+		d := time.Duration(v.Int())
+		if lo, err := time.ParseDuration(minTag); err == nil && d < lo {
+			errs = append(errs, fmt.Sprintf("%s: %s is below minimum %s", f.env, d, lo))
+		}
+		if hi, err := time.ParseDuration(maxTag); err == nil && d > hi {
+			errs = append(errs, fmt.Sprintf("%s: %s is above maximum %s", f.env, d, hi))
+		}
+	case v.Kind() == reflect.Int || v.Kind() == reflect.Float64:
+		x := v.Convert(reflect.TypeOf(float64(0))).Float()
+		if lo, err := strconv.ParseFloat(minTag, 64); err == nil && x < lo {
+			errs = append(errs, fmt.Sprintf("%s: %v is below minimum %s", f.env, v.Interface(), minTag))
+		}
+		if hi, err := strconv.ParseFloat(maxTag, 64); err == nil && x > hi {
+			errs = append(errs, fmt.Sprintf("%s: %v is above maximum %s", f.env, v.Interface(), maxTag))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,53 @@
+package config
+
+import (
+	"fmt"
+	"io"
+	"reflect"
+	"strings"
+	"text/tabwriter"
+	"time"
+)
+
+// Print выводит действующие настройки (для --print-config): переменная, ключ в файле,
+// значение и источник. Значения секретов не выводятся — только факт, заданы ли они.
+func Print(w io.Writer, c *Config) {
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	if c.File != "" {
+		fmt.Fprintf(tw, "# config file: %s\n", c.File)
+	}
+	fmt.Fprintf(tw, "# .env loaded: %v\n", c.DotEnvLoaded)
+
+	section := ""
+	eachField(c, func(f field) {
+		if f.section != section {
+			section = f.section
+			fmt.Fprintf(tw, "\n[%s]\n", strings.ToLower(section))
+		}
+		fmt.Fprintf(tw, "%s\t%s\t%s\t(%s)\n", f.env, f.tag.Get("file"), displayValue(f), c.Source(f.env))
+	})
+	_ = tw.Flush()
+}
+
+func displayValue(f field) string {
+	v := f.value
+	if f.tag.Get("secret") == "true" {
+		if v.IsZero() {
+			return "<empty>"
+		}
+		return "<redacted>"
+	}
+	switch {
+	case v.Type() == durationType:
+		return time.Duration(v.Int()).String()
+	case v.Kind() == reflect.Slice:
+		items := make([]string, v.Len())
+		for i := range items {
+			items[i] = v.Index(i).String()
+		}
+		return strings.Join(items, ",")
+	case v.Kind() == reflect.String && v.String() == "":
+		return `""`
+	}
+	return fmt.Sprint(v.Interface())
+}
@@ -0,0 +1,44 @@
+// Package cursor — непрозрачные курсоры для keyset-пагинации.
+//
+// Курсор хранит значения ключей сортировки граничной строки страницы в текстовом виде
+// (как их отдаёт Postgres при приведении к text), поэтому при подстановке обратно
+// в запрос значения сравниваются без потерь точности.
+package cursor
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+)
+
+// ErrInvalid — курсор повреждён или выдан для другой сортировки.
+var ErrInvalid = errors.New("invalid cursor")
+
+// Cursor — позиция в упорядоченном списке.
+type Cursor struct {
+	// Sort — сортировка, для которой выдан курсор; с другой сортировкой он не имеет смысла.
+	Sort string `json:"s,omitempty"`
+	// Keys — значения ключей сортировки граничной строки.
+	Keys []string `json:"k"`
+	// Backward — курсор на предыдущую страницу (строки перед граничной).
+	Backward bool `json:"b,omitempty"`
+}
+
+// Encode кодирует курсор в строку для URL.
+func Encode(c Cursor) string {
+	b, _ := json.Marshal(c)
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+// Decode разбирает строку, полученную от Encode.
+func Decode(s string) (*Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, ErrInvalid
+	}
+	var c Cursor
+	if err := json.Unmarshal(b, &c); err != nil || len(c.Keys) == 0 {
+		return nil, ErrInvalid
+	}
+	return &c, nil
+}
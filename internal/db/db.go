@@ -1,39 +1,71 @@
 package db
 
 import (
+	"context"
 	"database/sql"
 	"fmt"
-	"log"
-	"os"
+	"log/slog"
+	"time"
 
-	_ "github.com/lib/pq"
+	"x86trade_backend/internal/config"
+	"x86trade_backend/internal/tracing"
+
+	"github.com/lib/pq"
 )
 
 var DB *sql.DB
 
-func Connect() {
-	host := os.Getenv("DB_HOST")
-	port := os.Getenv("DB_PORT")
-	user := os.Getenv("DB_USER")
-	pass := os.Getenv("DB_PASS")
-	name := os.Getenv("DB_NAME")
-	ssl := os.Getenv("DB_SSLMODE")
+// driverName — pq, обёрнутый трассировкой: запросы с контекстом запроса попадают в трассу дочерними спанами.
+const driverName = "postgres+tracing"
 
-	dsn := fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=%s",
-		host, port, user, pass, name, ssl)
+func init() {
+	sql.Register(driverName, tracing.WrapDriver(&pq.Driver{}))
+}
 
+// Задержки между попытками подключения при старте: Postgres может подниматься
+// одновременно с приложением (docker compose, рестарт кластера).
+const (
+	connectBackoffStart = 500 * time.Millisecond
+	connectBackoffMax   = 10 * time.Second
+	pingTimeout         = 5 * time.Second
+)
+
+// Connect открывает пул и проверяет соединение. Если Postgres недоступен, пытается снова
+// с экспоненциальной задержкой (0.5s, 1s, 2s ... до 10s), не более cfg.ConnectAttempts раз.
+// Ожидание прерывается отменой ctx (например, SIGTERM во время старта).
+func Connect(ctx context.Context, cfg config.DB) error {
 	var err error
-	DB, err = sql.Open("postgres", dsn)
+	DB, err = sql.Open(driverName, cfg.DSN())
 	if err != nil {
-		log.Fatalf("sql.Open: %v", err)
+		return fmt.Errorf("sql.Open: %w", err)
 	}
 
+	// Настройки пула
+	DB.SetMaxOpenConns(cfg.MaxOpenConns)
+	DB.SetMaxIdleConns(cfg.MaxIdleConns)
+
+	attempts := cfg.ConnectAttempts
+
 	// Проверка соединения
-	if err = DB.Ping(); err != nil {
-		log.Fatalf("db ping: %v", err)
-	}
+	backoff := connectBackoffStart
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, pingTimeout)
+		err = DB.PingContext(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("db ping after %d attempts: %w", attempt, err)
+		}
 
-	// Настройки пула
-	DB.SetMaxOpenConns(25)
-	DB.SetMaxIdleConns(25)
+		slog.Warn("database not available, retrying",
+			"attempt", attempt, "max_attempts", attempts, "retry_in", backoff.String(), "error", err)
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("db connect: %w", ctx.Err())
+		case <-time.After(backoff):
+		}
+		backoff = min(backoff*2, connectBackoffMax)
+	}
 }
@@ -0,0 +1,135 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"fmt"
+	"io/fs"
+	"log/slog"
+	"sort"
+	"strings"
+)
+
+// Миграции лежат в internal/db/migrations и вшиваются в бинарник.
+// Имя файла: NNNN_описание.sql, применяются по возрастанию имени,
+// применённые версии записываются в schema_migrations.
+//
+//go:embed migrations/*.sql
+var migrationsFS embed.FS
+
+// migrationLockKey — ключ advisory-блокировки, под которой идут миграции.
+const migrationLockKey = 4700
+
+// Migrate применяет все ещё не применённые миграции, каждую в своей транзакции.
+// Реплики, стартующие одновременно, мигрируют по очереди: весь проход идёт под
+// pg_advisory_lock на отдельном соединении, а список применённых версий читается уже
+// после её получения — вторая реплика увидит работу первой и ничего не повторит.
+func Migrate(ctx context.Context) (err error) {
+	conn, err := DB.Conn(ctx)
+	if err != nil {
+		return fmt.Errorf("migrate: %w", err)
+	}
+	defer conn.Close()
+
+	if _, err := conn.ExecContext(ctx, `SELECT pg_advisory_lock($1)`, migrationLockKey); err != nil {
+		return fmt.Errorf("migrate: lock: %w", err)
+	}
+	defer func() {
+		// ctx мог быть отменён — снимаем блокировку в любом случае (при закрытии сессии она снимется сама)
+		if _, uerr := conn.ExecContext(context.Background(), `SELECT pg_advisory_unlock($1)`, migrationLockKey); uerr != nil && err == nil {
+			err = fmt.Errorf("migrate: unlock: %w", uerr)
+		}
+	}()
+
+	if _, err := conn.ExecContext(ctx, `CREATE TABLE IF NOT EXISTS schema_migrations (
+		version    TEXT PRIMARY KEY,
+		applied_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+	)`); err != nil {
+		return fmt.Errorf("create schema_migrations: %w", err)
+	}
+
+	applied, err := appliedMigrations(ctx, conn)
+	if err != nil {
+		return err
+	}
+
+	for _, name := range migrationNames() {
+		version := strings.TrimSuffix(name, ".sql")
+		if applied[version] {
+			continue
+		}
+		body, err := migrationsFS.ReadFile("migrations/" + name)
+		if err != nil {
+			return err
+		}
+
+		tx, err := conn.BeginTx(ctx, nil)
+		if err != nil {
+			return err
+		}
+		if _, err := tx.ExecContext(ctx, string(body)); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		if _, err := tx.ExecContext(ctx, `INSERT INTO schema_migrations (version) VALUES ($1)`, version); err != nil {
+			_ = tx.Rollback()
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		if err := tx.Commit(); err != nil {
+			return fmt.Errorf("migration %s: %w", version, err)
+		}
+		slog.Info("migration applied", "version", version)
+	}
+	return nil
+}
+
+// PendingMigrations возвращает версии, которые ещё не применены к БД.
+func PendingMigrations(ctx context.Context) ([]string, error) {
+	applied, err := appliedMigrations(ctx, DB)
+	if err != nil {
+		return nil, err
+	}
+	var pending []string
+	for _, name := range migrationNames() {
+		version := strings.TrimSuffix(name, ".sql")
+		if !applied[version] {
+			pending = append(pending, version)
+		}
+	}
+	return pending, nil
+}
+
+// queryer — *sql.DB или *sql.Conn.
+type queryer interface {
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+}
+
+func appliedMigrations(ctx context.Context, q queryer) (map[string]bool, error) {
+	rows, err := q.QueryContext(ctx, `SELECT version FROM schema_migrations`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	applied := map[string]bool{}
+	for rows.Next() {
+		var v string
+		if err := rows.Scan(&v); err != nil {
+			return nil, err
+		}
+		applied[v] = true
+	}
+	return applied, rows.Err()
+}
+
+func migrationNames() []string {
+	entries, _ := fs.ReadDir(migrationsFS, "migrations")
+	var names []string
+	for _, e := range entries {
+		if !e.IsDir() && strings.HasSuffix(e.Name(), ".sql") {
+			names = append(names, e.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,31 @@
+-- Возвраты (RMA): заявка на возврат создаётся покупателем по конкретной позиции заказа.
+CREATE TABLE IF NOT EXISTS returns (
+    id             SERIAL PRIMARY KEY,
+    order_id       INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    order_item_id  INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    user_id        INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    product_id     INTEGER NOT NULL,
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    reason         TEXT NOT NULL,
+    status         VARCHAR(20) NOT NULL DEFAULT 'requested',
+    refund_amount  NUMERIC(12,2),
+    admin_comment  TEXT,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_returns_user_id ON returns(user_id);
+CREATE INDEX IF NOT EXISTS idx_returns_order_item_id ON returns(order_item_id);
+CREATE INDEX IF NOT EXISTS idx_returns_status ON returns(status);
+
+-- История статусов каждой заявки.
+CREATE TABLE IF NOT EXISTS return_status_history (
+    id          SERIAL PRIMARY KEY,
+    return_id   INTEGER NOT NULL REFERENCES returns(id) ON DELETE CASCADE,
+    status      VARCHAR(20) NOT NULL,
+    comment     TEXT,
+    changed_by  INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at  TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_return_status_history_return_id ON return_status_history(return_id);
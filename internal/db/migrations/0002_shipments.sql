@@ -0,0 +1,33 @@
+-- Отправления по заказу: заказ может уходить несколькими посылками (частичная отгрузка).
+CREATE TABLE IF NOT EXISTS shipments (
+    id               SERIAL PRIMARY KEY,
+    order_id         INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    carrier          VARCHAR(100) NOT NULL,
+    tracking_number  VARCHAR(100) NOT NULL,
+    status           VARCHAR(30) NOT NULL DEFAULT 'pending',
+    shipped_at       TIMESTAMPTZ,
+    delivered_at     TIMESTAMPTZ,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipments_order_id ON shipments(order_id);
+
+CREATE TABLE IF NOT EXISTS shipment_items (
+    shipment_id    INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    order_item_id  INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    quantity       INTEGER NOT NULL CHECK (quantity > 0),
+    PRIMARY KEY (shipment_id, order_item_id)
+);
+
+-- Хронология отправления (для отображения трекинга покупателю).
+CREATE TABLE IF NOT EXISTS shipment_events (
+    id           SERIAL PRIMARY KEY,
+    shipment_id  INTEGER NOT NULL REFERENCES shipments(id) ON DELETE CASCADE,
+    status       VARCHAR(30) NOT NULL,
+    location     TEXT,
+    comment      TEXT,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_shipment_events_shipment_id ON shipment_events(shipment_id);
@@ -0,0 +1,16 @@
+-- Адресная книга пользователя.
+CREATE TABLE IF NOT EXISTS user_addresses (
+    id               SERIAL PRIMARY KEY,
+    user_id          INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    label            VARCHAR(100),
+    address          TEXT NOT NULL,
+    recipient_name   VARCHAR(255) NOT NULL,
+    recipient_phone  VARCHAR(50) NOT NULL,
+    is_default       BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at       TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at       TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_user_addresses_user_id ON user_addresses(user_id);
+-- не более одного адреса по умолчанию на пользователя
+CREATE UNIQUE INDEX IF NOT EXISTS uq_user_addresses_default ON user_addresses(user_id) WHERE is_default;
@@ -0,0 +1,2 @@
+-- Способ доставки может не требовать адреса (например, самовывоз).
+ALTER TABLE delivery_methods ADD COLUMN IF NOT EXISTS requires_address BOOLEAN NOT NULL DEFAULT TRUE;
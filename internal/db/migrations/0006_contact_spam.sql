@@ -0,0 +1,17 @@
+-- Антиспам формы обратной связи: подозрительные сообщения сохраняются с пометкой, а не отбрасываются.
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS is_spam BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS spam_score INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS spam_reasons TEXT[] NOT NULL DEFAULT '{}';
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS client_ip TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_contact_messages_is_spam ON contact_messages (is_spam);
+
+-- Стоп-лист, которым управляют администраторы: слова/фразы в тексте и адреса e-mail (или домены "@example.com").
+CREATE TABLE IF NOT EXISTS spam_blocklist (
+    id         SERIAL PRIMARY KEY,
+    kind       VARCHAR(10) NOT NULL CHECK (kind IN ('term', 'email')),
+    value      TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_spam_blocklist_kind_value ON spam_blocklist (kind, lower(value));
@@ -0,0 +1,10 @@
+-- Входящие обращения в админке: назначение ответственному и учёт ответа.
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS response_message TEXT;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS response_at TIMESTAMPTZ;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS responded_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS assigned_to INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE contact_messages ADD COLUMN IF NOT EXISTS assigned_at TIMESTAMPTZ;
+
+CREATE INDEX IF NOT EXISTS idx_contact_messages_created_at ON contact_messages (created_at);
+CREATE INDEX IF NOT EXISTS idx_contact_messages_is_processed ON contact_messages (is_processed);
+CREATE INDEX IF NOT EXISTS idx_contact_messages_assigned_to ON contact_messages (assigned_to);
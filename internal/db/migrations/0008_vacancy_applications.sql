@@ -0,0 +1,34 @@
+-- Отклики на вакансии: данные кандидата, резюме (файл в хранилище) и статус рассмотрения.
+CREATE TABLE IF NOT EXISTS vacancy_applications (
+    id              SERIAL PRIMARY KEY,
+    vacancy_id      INTEGER NOT NULL REFERENCES vacancies(id) ON DELETE CASCADE,
+    full_name       TEXT NOT NULL,
+    email           TEXT NOT NULL,
+    phone           TEXT,
+    cover_letter    TEXT,
+    cv_key          TEXT NOT NULL,
+    cv_filename     TEXT NOT NULL,
+    cv_content_type TEXT NOT NULL,
+    cv_size         BIGINT NOT NULL,
+    status          VARCHAR(20) NOT NULL DEFAULT 'new'
+                    CHECK (status IN ('new', 'screening', 'interview', 'rejected', 'hired')),
+    hr_comment      TEXT,
+    created_at      TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    updated_at      TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vacancy_applications_vacancy_id ON vacancy_applications(vacancy_id);
+CREATE INDEX IF NOT EXISTS idx_vacancy_applications_status ON vacancy_applications(status);
+-- один отклик кандидата на вакансию
+CREATE UNIQUE INDEX IF NOT EXISTS idx_vacancy_applications_vacancy_email ON vacancy_applications(vacancy_id, lower(email));
+
+CREATE TABLE IF NOT EXISTS vacancy_application_status_history (
+    id             SERIAL PRIMARY KEY,
+    application_id INTEGER NOT NULL REFERENCES vacancy_applications(id) ON DELETE CASCADE,
+    status         VARCHAR(20) NOT NULL,
+    comment        TEXT,
+    changed_by     INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at     TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_vacancy_application_history_application_id ON vacancy_application_status_history(application_id);
@@ -0,0 +1,6 @@
+-- Фасетный фильтр каталога: у типа характеристики появляется код для URL (?char[socket]=AM5).
+ALTER TABLE characteristic_types ADD COLUMN IF NOT EXISTS code VARCHAR(64);
+CREATE UNIQUE INDEX IF NOT EXISTS idx_characteristic_types_code ON characteristic_types (lower(code)) WHERE code IS NOT NULL;
+
+CREATE INDEX IF NOT EXISTS idx_product_characteristics_type_value ON product_characteristics (characteristic_type_id, lower(value));
+CREATE INDEX IF NOT EXISTS idx_product_characteristics_product_id ON product_characteristics (product_id);
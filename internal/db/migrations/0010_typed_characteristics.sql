@@ -0,0 +1,13 @@
+-- Типизированные характеристики: тип данных, допустимые значения/диапазон и числовое
+-- представление значения для сравнения в фильтрах. Существующие значения нормализуются
+-- отдельно (POST /api/admin/characteristic_types/{id}/normalize), отчёт о нераспознанных —
+-- GET /api/admin/characteristic_types/migration_report.
+ALTER TABLE characteristic_types ADD COLUMN IF NOT EXISTS data_type VARCHAR(10) NOT NULL DEFAULT 'string'
+    CHECK (data_type IN ('string', 'number', 'integer', 'boolean', 'enum'));
+ALTER TABLE characteristic_types ADD COLUMN IF NOT EXISTS allowed_values TEXT[];
+ALTER TABLE characteristic_types ADD COLUMN IF NOT EXISTS min_value NUMERIC;
+ALTER TABLE characteristic_types ADD COLUMN IF NOT EXISTS max_value NUMERIC;
+
+ALTER TABLE product_characteristics ADD COLUMN IF NOT EXISTS value_num NUMERIC;
+CREATE INDEX IF NOT EXISTS idx_product_characteristics_type_num ON product_characteristics (characteristic_type_id, value_num)
+    WHERE value_num IS NOT NULL;
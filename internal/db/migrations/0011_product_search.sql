@@ -0,0 +1,84 @@
+-- Полнотекстовый поиск по каталогу: поисковый вектор товара собирается из названия, SKU,
+-- производителя, категории, значений характеристик и описания (русская и английская морфология).
+-- Вектор зависит от других таблиц, поэтому хранится в колонке и обновляется триггерами.
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+
+ALTER TABLE products ADD COLUMN IF NOT EXISTS search_vector tsvector;
+
+CREATE OR REPLACE FUNCTION product_search_vector(pid INTEGER) RETURNS tsvector AS $$
+    SELECT
+        setweight(to_tsvector('russian', coalesce(p.name, '')), 'A') ||
+        setweight(to_tsvector('english', coalesce(p.name, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(p.sku, '')), 'A') ||
+        setweight(to_tsvector('simple', coalesce(m.name, '')), 'B') ||
+        setweight(to_tsvector('russian', coalesce(c.name, '')), 'B') ||
+        setweight(to_tsvector('russian', coalesce(
+            (SELECT string_agg(pc.value, ' ') FROM product_characteristics pc WHERE pc.product_id = p.id), '')), 'C') ||
+        setweight(to_tsvector('russian', coalesce(p.description, '')), 'D') ||
+        setweight(to_tsvector('english', coalesce(p.description, '')), 'D')
+    FROM products p
+    LEFT JOIN manufacturers m ON m.id = p.manufacturer_id
+    LEFT JOIN categories c ON c.id = p.category_id
+    WHERE p.id = pid
+$$ LANGUAGE sql STABLE;
+
+CREATE OR REPLACE FUNCTION products_search_refresh() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = product_search_vector(NEW.id) WHERE id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+-- UPDATE OF не срабатывает на изменение самой search_vector, поэтому рекурсии нет
+DROP TRIGGER IF EXISTS trg_products_search ON products;
+CREATE TRIGGER trg_products_search
+    AFTER INSERT OR UPDATE OF name, sku, description, manufacturer_id, category_id ON products
+    FOR EACH ROW EXECUTE FUNCTION products_search_refresh();
+
+CREATE OR REPLACE FUNCTION product_characteristics_search_refresh() RETURNS trigger AS $$
+BEGIN
+    IF TG_OP <> 'INSERT' THEN
+        UPDATE products SET search_vector = product_search_vector(OLD.product_id) WHERE id = OLD.product_id;
+    END IF;
+    IF TG_OP <> 'DELETE' AND (TG_OP = 'INSERT' OR NEW.product_id IS DISTINCT FROM OLD.product_id) THEN
+        UPDATE products SET search_vector = product_search_vector(NEW.product_id) WHERE id = NEW.product_id;
+    END IF;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_product_characteristics_search ON product_characteristics;
+CREATE TRIGGER trg_product_characteristics_search
+    AFTER INSERT OR UPDATE OF product_id, value OR DELETE ON product_characteristics
+    FOR EACH ROW EXECUTE FUNCTION product_characteristics_search_refresh();
+
+CREATE OR REPLACE FUNCTION manufacturers_search_refresh() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = product_search_vector(id) WHERE manufacturer_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_manufacturers_search ON manufacturers;
+CREATE TRIGGER trg_manufacturers_search
+    AFTER UPDATE OF name ON manufacturers
+    FOR EACH ROW EXECUTE FUNCTION manufacturers_search_refresh();
+
+CREATE OR REPLACE FUNCTION categories_search_refresh() RETURNS trigger AS $$
+BEGIN
+    UPDATE products SET search_vector = product_search_vector(id) WHERE category_id = NEW.id;
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS trg_categories_search ON categories;
+CREATE TRIGGER trg_categories_search
+    AFTER UPDATE OF name ON categories
+    FOR EACH ROW EXECUTE FUNCTION categories_search_refresh();
+
+UPDATE products SET search_vector = product_search_vector(id);
+
+CREATE INDEX IF NOT EXISTS idx_products_search_vector ON products USING GIN (search_vector);
+-- триграммы для запасного нечёткого поиска (опечатки)
+CREATE INDEX IF NOT EXISTS idx_products_name_trgm ON products USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_trgm ON products USING GIN (sku gin_trgm_ops);
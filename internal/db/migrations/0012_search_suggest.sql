@@ -0,0 +1,8 @@
+-- Подсказки поиска: префиксный поиск по lower(name)/lower(sku) и триграммы по названиям
+-- категорий и производителей (для товаров триграммные индексы созданы в 0011).
+CREATE INDEX IF NOT EXISTS idx_products_name_prefix ON products (lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_products_sku_prefix ON products (lower(sku) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_categories_name_prefix ON categories (lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_categories_name_trgm ON categories USING GIN (name gin_trgm_ops);
+CREATE INDEX IF NOT EXISTS idx_manufacturers_name_prefix ON manufacturers (lower(name) text_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_manufacturers_name_trgm ON manufacturers USING GIN (name gin_trgm_ops);
@@ -0,0 +1,6 @@
+-- Курсорная пагинация: индексы под ключи сортировки каталога и списка заказов,
+-- выражения совпадают с keyset в репозитории.
+CREATE INDEX IF NOT EXISTS idx_products_keyset_newest ON products ((COALESCE(created_at, '-infinity')), id);
+CREATE INDEX IF NOT EXISTS idx_products_keyset_price ON products (price, id);
+CREATE INDEX IF NOT EXISTS idx_products_keyset_name ON products (name, id);
+CREATE INDEX IF NOT EXISTS idx_orders_keyset_created ON orders ((COALESCE(created_at, '-infinity')), id);
@@ -0,0 +1,28 @@
+-- Денормализованный рейтинг товара: количество, сумма, среднее и распределение оценок.
+-- Пересчитывается в той же транзакции, что и изменение отзыва (repository.refreshProductRating),
+-- полностью — командой cmd/backfill-ratings.
+CREATE TABLE IF NOT EXISTS product_ratings (
+    product_id   INTEGER PRIMARY KEY REFERENCES products(id) ON DELETE CASCADE,
+    rating_count INTEGER NOT NULL DEFAULT 0,
+    rating_sum   INTEGER NOT NULL DEFAULT 0,
+    rating_avg   NUMERIC(3,2),
+    count_1      INTEGER NOT NULL DEFAULT 0,
+    count_2      INTEGER NOT NULL DEFAULT 0,
+    count_3      INTEGER NOT NULL DEFAULT 0,
+    count_4      INTEGER NOT NULL DEFAULT 0,
+    count_5      INTEGER NOT NULL DEFAULT 0,
+    updated_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+
+CREATE INDEX IF NOT EXISTS idx_product_ratings_avg ON product_ratings (rating_avg DESC NULLS LAST, rating_count DESC);
+CREATE INDEX IF NOT EXISTS idx_reviews_product_id ON reviews (product_id);
+
+INSERT INTO product_ratings (product_id, rating_count, rating_sum, rating_avg, count_1, count_2, count_3, count_4, count_5)
+SELECT r.product_id, COUNT(*), SUM(r.rating), ROUND(AVG(r.rating), 2),
+       COUNT(*) FILTER (WHERE r.rating = 1), COUNT(*) FILTER (WHERE r.rating = 2),
+       COUNT(*) FILTER (WHERE r.rating = 3), COUNT(*) FILTER (WHERE r.rating = 4),
+       COUNT(*) FILTER (WHERE r.rating = 5)
+  FROM reviews r
+  JOIN products p ON p.id = r.product_id
+ GROUP BY r.product_id
+ON CONFLICT (product_id) DO NOTHING;
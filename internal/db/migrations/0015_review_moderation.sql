@@ -0,0 +1,45 @@
+-- Модерация отзывов, отметка «проверенная покупка» и один отзыв на товар от пользователя.
+-- Уже опубликованные отзывы считаются одобренными; новые попадают в очередь модерации.
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS status VARCHAR(16) NOT NULL DEFAULT 'approved';
+ALTER TABLE reviews ALTER COLUMN status SET DEFAULT 'pending';
+ALTER TABLE reviews DROP CONSTRAINT IF EXISTS reviews_status_check;
+ALTER TABLE reviews ADD CONSTRAINT reviews_status_check CHECK (status IN ('pending', 'approved', 'rejected'));
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS verified_purchase BOOLEAN NOT NULL DEFAULT FALSE;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS updated_at TIMESTAMPTZ;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderated_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderated_at TIMESTAMPTZ;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS moderation_note TEXT;
+
+-- раньше отзывов на товар можно было оставить сколько угодно: самый свежий остаётся как есть,
+-- более старые не удаляются, а отклоняются с пометкой — автор и модератор их по-прежнему видят
+UPDATE reviews r
+   SET status = 'rejected', moderated_at = NOW(),
+       moderation_note = 'Duplicate: superseded by a newer review of the same product'
+ WHERE r.status <> 'rejected'
+   AND EXISTS (SELECT 1 FROM reviews newer
+                WHERE newer.user_id = r.user_id
+                  AND newer.product_id = r.product_id
+                  AND (COALESCE(newer.created_at, '-infinity'), newer.id) > (COALESCE(r.created_at, '-infinity'), r.id));
+-- один действующий отзыв на товар; отклонённый не мешает написать новый
+CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_product ON reviews (user_id, product_id) WHERE status <> 'rejected';
+CREATE INDEX IF NOT EXISTS idx_reviews_status ON reviews (status, created_at);
+
+UPDATE reviews r SET verified_purchase = TRUE
+ WHERE EXISTS (SELECT 1 FROM orders o
+                 JOIN order_items oi ON oi.order_id = o.id
+                WHERE o.user_id = r.user_id AND oi.product_id = r.product_id AND o.status = 'delivered');
+
+-- отклонённые дубли выпадают из рейтинга: пересчитываем по одобренным отзывам
+UPDATE product_ratings pr SET
+    rating_count = s.cnt, rating_sum = s.total, rating_avg = s.avg,
+    count_1 = s.c1, count_2 = s.c2, count_3 = s.c3, count_4 = s.c4, count_5 = s.c5,
+    updated_at = NOW()
+  FROM (SELECT p.id AS product_id, COUNT(r.id) AS cnt, COALESCE(SUM(r.rating), 0) AS total,
+               ROUND(AVG(r.rating), 2) AS avg,
+               COUNT(r.id) FILTER (WHERE r.rating = 1) AS c1, COUNT(r.id) FILTER (WHERE r.rating = 2) AS c2,
+               COUNT(r.id) FILTER (WHERE r.rating = 3) AS c3, COUNT(r.id) FILTER (WHERE r.rating = 4) AS c4,
+               COUNT(r.id) FILTER (WHERE r.rating = 5) AS c5
+          FROM products p
+          LEFT JOIN reviews r ON r.product_id = p.id AND r.status = 'approved'
+         GROUP BY p.id) s
+ WHERE pr.product_id = s.product_id;
@@ -0,0 +1,26 @@
+-- Оценки полезности отзывов (один голос пользователя на отзыв) и фотографии к отзывам.
+CREATE TABLE IF NOT EXISTS review_votes (
+    review_id  INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    user_id    INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    helpful    BOOLEAN NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+    PRIMARY KEY (review_id, user_id)
+);
+
+-- счётчики голосов денормализованы для сортировки «сначала полезные»
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS helpful_count INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE reviews ADD COLUMN IF NOT EXISTS unhelpful_count INTEGER NOT NULL DEFAULT 0;
+
+CREATE TABLE IF NOT EXISTS review_images (
+    id           SERIAL PRIMARY KEY,
+    review_id    INTEGER NOT NULL REFERENCES reviews(id) ON DELETE CASCADE,
+    storage_key  VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    position     INTEGER NOT NULL DEFAULT 0,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_review_images_review ON review_images (review_id, position);
+
+CREATE INDEX IF NOT EXISTS idx_reviews_product_listing ON reviews (product_id, status, created_at DESC);
+CREATE INDEX IF NOT EXISTS idx_reviews_product_helpful ON reviews (product_id, status, helpful_count DESC);
@@ -0,0 +1,30 @@
+-- Изображения товаров: несколько упорядоченных изображений, одно из них главное.
+-- Файлы лежат в хранилище (storage), в БД — только ключи. products.image_path
+-- продолжает заполняться (превью главного изображения) для старых клиентов.
+CREATE TABLE IF NOT EXISTS product_images (
+    id           SERIAL PRIMARY KEY,
+    product_id   INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    storage_key  VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    width        INTEGER NOT NULL,
+    height       INTEGER NOT NULL,
+    position     INTEGER NOT NULL DEFAULT 0,
+    is_primary   BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at   TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS idx_product_images_product ON product_images (product_id, position, id);
+-- не больше одного главного изображения у товара
+CREATE UNIQUE INDEX IF NOT EXISTS uq_product_images_primary ON product_images (product_id) WHERE is_primary;
+
+-- Уменьшенные копии (thumb, medium) в исходном формате и в WebP.
+CREATE TABLE IF NOT EXISTS product_image_variants (
+    image_id     INTEGER NOT NULL REFERENCES product_images(id) ON DELETE CASCADE,
+    name         VARCHAR(20) NOT NULL,
+    storage_key  VARCHAR(255) NOT NULL,
+    content_type VARCHAR(100) NOT NULL,
+    size_bytes   BIGINT NOT NULL,
+    width        INTEGER NOT NULL,
+    height       INTEGER NOT NULL,
+    PRIMARY KEY (image_id, name, content_type)
+);
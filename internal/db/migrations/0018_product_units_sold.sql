@@ -0,0 +1,14 @@
+-- Денормализованная популярность товара: сколько штук продано по неотменённым заказам.
+-- Меняется в тех же транзакциях, что создают заказ и переводят его в 'cancelled' или обратно
+-- (repository.adjustUnitsSold); ключ сортировки ?sort=popularity — (units_sold, id).
+ALTER TABLE products ADD COLUMN IF NOT EXISTS units_sold INTEGER NOT NULL DEFAULT 0;
+
+UPDATE products p SET units_sold = s.units
+  FROM (SELECT oi.product_id, SUM(oi.quantity) AS units
+          FROM order_items oi
+          JOIN orders o ON o.id = oi.order_id
+         WHERE o.status <> 'cancelled'
+         GROUP BY oi.product_id) s
+ WHERE p.id = s.product_id;
+
+CREATE INDEX IF NOT EXISTS idx_products_keyset_popularity ON products (units_sold, id);
@@ -0,0 +1,114 @@
+package handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+func decodeAddressPayload(r *http.Request) (*models.UserAddressPayload, error) {
+	var p models.UserAddressPayload
+	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
+		return nil, err
+	}
+	p.Label = strings.TrimSpace(p.Label)
+	p.Address = strings.TrimSpace(p.Address)
+	p.RecipientName = strings.TrimSpace(p.RecipientName)
+	p.RecipientPhone = strings.TrimSpace(p.RecipientPhone)
+	return &p, nil
+}
+
+// GetMyAddressesHandler — GET /api/auth/me/addresses
+func GetMyAddressesHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	list, err := repository.GetUserAddresses(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	return nil
+}
+
+// CreateMyAddressHandler — POST /api/auth/me/addresses
+func CreateMyAddressHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	p, err := decodeAddressPayload(r)
+	if err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if p.Address == "" || p.RecipientName == "" || p.RecipientPhone == "" {
+		return apperr.BadRequest("address, recipient_name and recipient_phone required")
+	}
+	id, err := repository.CreateUserAddress(r.Context(), userID, p)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
+}
+
+// UpdateMyAddressHandler — PUT /api/auth/me/addresses/{id}
+func UpdateMyAddressHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	p, err := decodeAddressPayload(r)
+	if err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if p.Address == "" || p.RecipientName == "" || p.RecipientPhone == "" {
+		return apperr.BadRequest("address, recipient_name and recipient_phone required")
+	}
+	if err := repository.UpdateUserAddress(r.Context(), userID, id, p); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("not found")
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// DeleteMyAddressHandler — DELETE /api/auth/me/addresses/{id}
+func DeleteMyAddressHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	if err := repository.DeleteUserAddress(r.Context(), userID, id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("not found")
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
@@ -5,97 +5,90 @@ import (
 	"net/http"
 	"strconv"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func AdminGetCategories(w http.ResponseWriter, r *http.Request) {
+func AdminGetCategories(w http.ResponseWriter, r *http.Request) error {
 	cats, err := repository.GetAllCategories(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(cats)
+	return nil
 }
 
-func AdminGetCategory(w http.ResponseWriter, r *http.Request) {
+func AdminGetCategory(w http.ResponseWriter, r *http.Request) error {
 	categoryIDStr := chi.URLParam(r, "id")
 	categoryID, err := strconv.Atoi(categoryIDStr)
 	if err != nil || categoryID <= 0 {
-		http.Error(w, "bad request: invalid category id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid category id")
 	}
 
 	c, err := repository.GetCategoryByID(r.Context(), categoryID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if c == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(c)
+	return nil
 }
 
-func AdminCreateCategory(w http.ResponseWriter, r *http.Request) {
+func AdminCreateCategory(w http.ResponseWriter, r *http.Request) error {
 	var payload models.Category
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	id, err := repository.CreateCategory(r.Context(), &payload)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func AdminUpdateCategory(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateCategory(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var payload models.Category
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	payload.ID = id
 	if payload.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	if err := repository.UpdateCategory(r.Context(), &payload); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminDeleteCategory(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteCategory(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeleteCategory(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
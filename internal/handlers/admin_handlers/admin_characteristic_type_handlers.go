@@ -3,69 +3,190 @@ package admin_handlers
 import (
 	"encoding/json"
 	"net/http"
+	"regexp"
 	"strconv"
+	"strings"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/charvalue"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func AdminGetCharacteristicTypes(w http.ResponseWriter, r *http.Request) {
+// characteristicCodeRe — формат кода характеристики для URL фильтра (?char[socket]=AM5).
+var characteristicCodeRe = regexp.MustCompile(`^[a-z][a-z0-9_]{0,63}$`)
+
+// checkCharacteristicType нормализует и проверяет код и описание типа данных.
+func checkCharacteristicType(p *models.CharacteristicType) error {
+	p.Code = strings.ToLower(strings.TrimSpace(p.Code))
+	if p.Code != "" && !characteristicCodeRe.MatchString(p.Code) {
+		return apperr.BadRequest("code must start with a latin letter and contain only a-z, 0-9 and _")
+	}
+	if p.DataType == "" {
+		p.DataType = models.CharString
+	}
+	if err := charvalue.CheckDefinition(p); err != nil {
+		return apperr.BadRequest(err.Error())
+	}
+	return nil
+}
+
+func AdminGetCharacteristicTypes(w http.ResponseWriter, r *http.Request) error {
 	types, err := repository.GetAllCharacteristicTypes(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(types)
+	return nil
 }
 
-func AdminCreateCharacteristicType(w http.ResponseWriter, r *http.Request) {
+func AdminCreateCharacteristicType(w http.ResponseWriter, r *http.Request) error {
 	var p models.CharacteristicType
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
+	}
+	if err := checkCharacteristicType(&p); err != nil {
+		return err
 	}
 	id, err := repository.CreateCharacteristicType(r.Context(), &p)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func AdminUpdateCharacteristicType(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateCharacteristicType(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	var p models.CharacteristicType
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	p.ID = id
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
+	}
+	if err := checkCharacteristicType(&p); err != nil {
+		return err
 	}
 	if err := repository.UpdateCharacteristicType(r.Context(), &p); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminDeleteCharacteristicType(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteCharacteristicType(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if err := repository.DeleteCharacteristicType(r.Context(), id); err != nil {
-		// если FK -> зависимые записи, БД вернёт ошибку; её можно перехватить и вернуть 409 Conflict
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		// если FK -> зависимые записи, apperr.From вернёт 409 Conflict
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// AdminCharacteristicMigrationReport — какие сохранённые значения не разбираются под тип данных.
+// Без параметров — по всем нестроковым типам; ?type_id= — по одному типу, а вместе с
+// ?data_type= (и allowed_values/min_value/max_value) — предпросмотр перед сменой типа.
+func AdminCharacteristicMigrationReport(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+	var types []models.CharacteristicType
+
+	if idStr := q.Get("type_id"); idStr != "" {
+		id, _ := strconv.Atoi(idStr)
+		if id <= 0 {
+			return apperr.BadRequest("invalid type_id")
+		}
+		t, err := repository.GetCharacteristicTypeByID(r.Context(), id)
+		if err != nil {
+			return err
+		}
+		if t == nil {
+			return apperr.NotFound("characteristic type not found")
+		}
+		if dt := q.Get("data_type"); dt != "" {
+			t.DataType = dt
+			t.AllowedValues = nil
+			for _, v := range strings.Split(q.Get("allowed_values"), ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					t.AllowedValues = append(t.AllowedValues, v)
+				}
+			}
+			var errMin, errMax error
+			t.MinValue, errMin = parseOptionalFloat(q.Get("min_value"))
+			t.MaxValue, errMax = parseOptionalFloat(q.Get("max_value"))
+			if errMin != nil || errMax != nil {
+				return apperr.BadRequest("min_value and max_value must be numbers")
+			}
+			if err := charvalue.CheckDefinition(t); err != nil {
+				return apperr.BadRequest(err.Error())
+			}
+		}
+		types = append(types, *t)
+	} else {
+		all, err := repository.GetAllCharacteristicTypes(r.Context())
+		if err != nil {
+			return err
+		}
+		for _, t := range all {
+			if t.DataType != models.CharString {
+				types = append(types, t)
+			}
+		}
+	}
+
+	reports := make([]*models.CharacteristicMigrationReport, 0, len(types))
+	for i := range types {
+		rep, err := repository.NormalizeCharacteristicValues(r.Context(), &types[i], false)
+		if err != nil {
+			return err
+		}
+		reports = append(reports, rep)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(reports)
+	return nil
+}
+
+// AdminNormalizeCharacteristicValues переписывает сохранённые значения характеристики
+// в каноническом виде; нераспознанные значения не трогаются и возвращаются в отчёте.
+func AdminNormalizeCharacteristicValues(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	t, err := repository.GetCharacteristicTypeByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if t == nil {
+		return apperr.NotFound("characteristic type not found")
+	}
+	rep, err := repository.NormalizeCharacteristicValues(r.Context(), t, true)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rep)
+	return nil
+}
+
+func parseOptionalFloat(s string) (*float64, error) {
+	if strings.TrimSpace(s) == "" {
+		return nil, nil
+	}
+	f, err := strconv.ParseFloat(strings.TrimSpace(s), 64)
+	if err != nil {
+		return nil, err
+	}
+	return &f, nil
 }
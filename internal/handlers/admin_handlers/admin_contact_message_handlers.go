@@ -0,0 +1,314 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/mailer"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
+)
+
+const (
+	maxContactReplyLen        = 10000
+	maxContactReplySubjectLen = 200
+	defaultContactReplySubj   = "Ответ на ваше обращение"
+)
+
+// replyMailer отправляет ответы на обращения; задаётся при старте (ConfigureMailer).
+var replyMailer mailer.Mailer = mailer.LogMailer{}
+
+// ConfigureMailer задаёт отправителя писем для ответов на обращения.
+func ConfigureMailer(m mailer.Mailer) {
+	replyMailer = m
+}
+
+// AdminGetContactMessages — входящие обращения с пагинацией.
+// Фильтры: ?processed=true|false, ?spam=false (по умолчанию)|true|all, ?assigned_to=<id>|me,
+// ?from=, ?to= — дата (YYYY-MM-DD, to включительно) или RFC3339.
+func AdminGetContactMessages(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	page := 1
+	limit := 20
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+
+	f := models.ContactMessageFilter{Limit: limit, Offset: (page - 1) * limit}
+	var errs validation.Errors
+
+	if s := q.Get("processed"); s != "" {
+		v, err := strconv.ParseBool(s)
+		if err != nil {
+			errs.Add("processed", validation.CodeInvalidFormat, "processed must be true or false")
+		}
+		f.Processed = &v
+	}
+	// спам по умолчанию скрыт, чтобы не засорять входящие
+	switch s := q.Get("spam"); s {
+	case "all":
+	case "", "false":
+		v := false
+		f.Spam = &v
+	case "true":
+		v := true
+		f.Spam = &v
+	default:
+		errs.Add("spam", validation.CodeInvalidFormat, "spam must be true, false or all")
+	}
+	if s := q.Get("assigned_to"); s != "" {
+		var id int
+		if s == "me" {
+			id, _ = middleware.UserIDFromContext(r.Context())
+		} else if v, err := strconv.Atoi(s); err == nil && v > 0 {
+			id = v
+		} else {
+			errs.Add("assigned_to", validation.CodeInvalidFormat, "assigned_to must be an admin id or me")
+		}
+		f.AssignedTo = &id
+	}
+	if s := q.Get("from"); s != "" {
+		t, ok := parseFilterDate(s, false)
+		if !ok {
+			errs.Add("from", validation.CodeInvalidFormat, "from must be YYYY-MM-DD or RFC3339")
+		}
+		f.From = &t
+	}
+	if s := q.Get("to"); s != "" {
+		t, ok := parseFilterDate(s, true)
+		if !ok {
+			errs.Add("to", validation.CodeInvalidFormat, "to must be YYYY-MM-DD or RFC3339")
+		}
+		f.To = &t
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	list, err := repository.GetContactMessages(r.Context(), f)
+	if err != nil {
+		return err
+	}
+	total, err := repository.CountContactMessages(r.Context(), f)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  list,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+	return nil
+}
+
+// parseFilterDate разбирает дату фильтра. Для верхней границы дата без времени означает
+// «весь этот день включительно», поэтому возвращается начало следующего дня.
+func parseFilterDate(s string, upper bool) (time.Time, bool) {
+	if t, err := time.Parse(time.RFC3339, s); err == nil {
+		return t, true
+	}
+	t, err := time.ParseInLocation("2006-01-02", s, time.Local)
+	if err != nil {
+		return time.Time{}, false
+	}
+	if upper {
+		t = t.AddDate(0, 0, 1)
+	}
+	return t, true
+}
+
+// AdminGetContactMessageByID — обращение целиком.
+func AdminGetContactMessageByID(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	m, err := repository.GetContactMessageByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if m == nil {
+		return apperr.NotFound("contact message not found")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(m)
+	return nil
+}
+
+// AdminSetContactMessageProcessed — отметка «обработано». JSON: { "is_processed": true }
+func AdminSetContactMessageProcessed(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	var payload struct {
+		IsProcessed *bool `json:"is_processed"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.IsProcessed == nil {
+		return apperr.BadRequest("is_processed is required")
+	}
+	if err := repository.SetContactMessageProcessed(r.Context(), id, *payload.IsProcessed); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("contact message not found")
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// AdminAssignContactMessage — назначение ответственного. JSON: { "admin_id": 5 } или { "admin_id": null }.
+func AdminAssignContactMessage(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	var payload struct {
+		AdminID *int `json:"admin_id"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.AdminID != nil {
+		u, err := repository.GetUserByID(r.Context(), *payload.AdminID)
+		if err != nil {
+			return err
+		}
+		var errs validation.Errors
+		switch {
+		case u == nil:
+			errs.Add("admin_id", validation.CodeNotFound, "user does not exist")
+		case !u.IsAdmin:
+			errs.Add("admin_id", validation.CodeNotAllowed, "user is not an admin")
+		}
+		if errs.HasErrors() {
+			return errs
+		}
+	}
+	if err := repository.AssignContactMessage(r.Context(), id, payload.AdminID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("contact message not found")
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// AdminReplyContactMessage — ответ на обращение. Если contact_info — e-mail, ответ уходит письмом;
+// в любом случае он сохраняется в response_message, а обращение отмечается обработанным.
+// JSON: { "subject": "...", "message": "..." }
+func AdminReplyContactMessage(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var payload models.ContactReplyPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	payload.Subject = strings.TrimSpace(payload.Subject)
+	payload.Message = strings.TrimSpace(payload.Message)
+	if payload.Subject == "" {
+		payload.Subject = defaultContactReplySubj
+	}
+
+	var errs validation.Errors
+	switch {
+	case payload.Message == "":
+		errs.Add("message", validation.CodeRequired, "message is required")
+	case utf8.RuneCountInString(payload.Message) > maxContactReplyLen:
+		errs.Add("message", validation.CodeTooLong, "message is too long")
+	}
+	if utf8.RuneCountInString(payload.Subject) > maxContactReplySubjectLen || strings.ContainsAny(payload.Subject, "\r\n") {
+		errs.Add("subject", validation.CodeInvalidFormat, "subject must be a single line up to 200 characters")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	msg, err := repository.GetContactMessageByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if msg == nil {
+		return apperr.NotFound("contact message not found")
+	}
+	if msg.ResponseAt != nil {
+		return apperr.Conflict("contact message already answered")
+	}
+
+	// Ответ сначала закрепляется за этим администратором, потом уходит письмо: при параллельном
+	// ответе письмо получит клиент один раз, а при ошибке почты обращение останется неотвеченным
+	emailed := false
+	var sendErr error
+	at, err := repository.SaveContactReply(r.Context(), id, adminID, payload.Message, func() error {
+		to := strings.TrimSpace(msg.ContactInfo)
+		if !validation.IsValidEmail(to) {
+			return nil
+		}
+		sendErr = replyMailer.Send(r.Context(), mailer.Message{
+			To:      to,
+			Subject: payload.Subject,
+			Body:    contactReplyBody(msg, payload.Message),
+		})
+		emailed = sendErr == nil
+		return sendErr
+	})
+	if err != nil {
+		switch {
+		case sendErr != nil:
+			return apperr.New(http.StatusBadGateway, "mail_send_failed", "failed to send reply email").WithCause(err)
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("contact message not found")
+		case errors.Is(err, repository.ErrContactAlreadyAnswered):
+			return apperr.Conflict(err.Error())
+		}
+		return err
+	}
+	logging.FromContext(r.Context()).Info("contact message answered", "contact_message_id", id, "admin_id", adminID, "emailed", emailed)
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"message":     "Reply saved",
+		"response_at": at,
+		"emailed":     emailed,
+	})
+	return nil
+}
+
+// contactReplyBody — текст письма: ответ и цитата исходного обращения.
+func contactReplyBody(msg *models.ContactMessage, reply string) string {
+	var b strings.Builder
+	b.WriteString("Здравствуйте, " + msg.FullName + "!\n\n")
+	b.WriteString(reply)
+	b.WriteString("\n\n---\nВаше обращение от " + msg.CreatedAt.Format("02.01.2006 15:04") + ":\n")
+	for _, line := range strings.Split(msg.Message, "\n") {
+		b.WriteString("> " + line + "\n")
+	}
+	return b.String()
+}
@@ -8,30 +8,29 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 )
 
-func AdminGetDeliveryMethods(w http.ResponseWriter, r *http.Request) {
+func AdminGetDeliveryMethods(w http.ResponseWriter, r *http.Request) error {
 	methods, err := repository.GetDeliveryMethods(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(methods)
+	return nil
 }
 
 // AdminCreateDeliveryMethod — принимает простой JSON, конвертирует в models.DeliveryMethod и вызывает Create.
-func AdminCreateDeliveryMethod(w http.ResponseWriter, r *http.Request) {
+func AdminCreateDeliveryMethod(w http.ResponseWriter, r *http.Request) error {
 	var p models.DeliveryPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	var d models.DeliveryMethod
 	d.Name = p.Name
@@ -51,32 +50,30 @@ func AdminCreateDeliveryMethod(w http.ResponseWriter, r *http.Request) {
 	} else {
 		d.EstimatedDays = sql.NullInt64{}
 	}
+	d.RequiresAddress = p.RequiresAddress == nil || *p.RequiresAddress
 
 	id, err := repository.CreateDeliveryMethod(r.Context(), &d)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
 // AdminUpdateDeliveryMethod
-func AdminUpdateDeliveryMethod(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateDeliveryMethod(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var p models.DeliveryPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	var d models.DeliveryMethod
 	d.ID = id
@@ -97,25 +94,25 @@ func AdminUpdateDeliveryMethod(w http.ResponseWriter, r *http.Request) {
 	} else {
 		d.EstimatedDays = sql.NullInt64{}
 	}
+	d.RequiresAddress = p.RequiresAddress == nil || *p.RequiresAddress
 
 	if err := repository.UpdateDeliveryMethod(r.Context(), &d); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
 // AdminDeleteDeliveryMethod
-func AdminDeleteDeliveryMethod(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteDeliveryMethod(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeleteDeliveryMethod(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
@@ -2,17 +2,17 @@ package admin_handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 )
 
-func AdminGetManufacturers(w http.ResponseWriter, r *http.Request) {
+func AdminGetManufacturers(w http.ResponseWriter, r *http.Request) error {
 	// Получаем параметры пагинации
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
@@ -38,16 +38,13 @@ func AdminGetManufacturers(w http.ResponseWriter, r *http.Request) {
 	// Получаем производителей
 	ms, err := repository.GetManufacturersWithPagination(r.Context(), limit, offset)
 	if err != nil {
-		log.Printf("AdminGetManufacturers: repository error: %v", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// Получаем общее количество производителей
 	total, err := repository.CountManufacturers(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// Формируем ответ с пагинацией
@@ -60,61 +57,56 @@ func AdminGetManufacturers(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
+	return nil
 }
 
-func AdminCreateManufacturer(w http.ResponseWriter, r *http.Request) {
+func AdminCreateManufacturer(w http.ResponseWriter, r *http.Request) error {
 	var payload models.Manufacturer
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	id, err := repository.CreateManufacturer(r.Context(), &payload)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func AdminUpdateManufacturer(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateManufacturer(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var payload models.Manufacturer
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	payload.ID = id
 	if err := repository.UpdateManufacturer(r.Context(), &payload); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminDeleteManufacturer(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteManufacturer(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeleteManufacturer(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
@@ -2,15 +2,20 @@ package admin_handlers
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/cursor"
+	"x86trade_backend/internal/metrics"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func AdminGetOrders(w http.ResponseWriter, r *http.Request) {
+func AdminGetOrders(w http.ResponseWriter, r *http.Request) error {
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
 
@@ -31,10 +36,25 @@ func AdminGetOrders(w http.ResponseWriter, r *http.Request) {
 
 	offset := (page - 1) * limit
 
-	orders, err := repository.GetOrdersWithPagination(r.Context(), limit, offset)
-	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	// ?cursor= (пустой — первая страница) — курсорная пагинация вместо page
+	var orders []models.Order
+	var cursors repository.CursorPage
+	keyset := r.URL.Query().Has("cursor")
+	if keyset {
+		cur, err := parseCursor(r.URL.Query().Get("cursor"))
+		if err != nil {
+			return err
+		}
+		orders, cursors, err = repository.GetOrdersByCursor(r.Context(), limit, cur)
+		if err != nil {
+			return cursorError(err)
+		}
+	} else {
+		var err error
+		orders, err = repository.GetOrdersWithPagination(r.Context(), limit, offset)
+		if err != nil {
+			return err
+		}
 	}
 
 	ordersWithDetails := make([]map[string]interface{}, 0, len(orders))
@@ -80,60 +100,65 @@ func AdminGetOrders(w http.ResponseWriter, r *http.Request) {
 
 	total, err := repository.CountOrders(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	response := map[string]interface{}{
 		"data":  ordersWithDetails,
 		"total": total,
-		"page":  page,
 		"limit": limit,
 	}
+	if keyset {
+		response["next_cursor"] = cursors.Next
+		response["prev_cursor"] = cursors.Prev
+	} else {
+		response["page"] = page
+	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
+	return nil
 }
 
-func AdminGetOrder(w http.ResponseWriter, r *http.Request) {
+func AdminGetOrder(w http.ResponseWriter, r *http.Request) error {
 	orderIDStr := chi.URLParam(r, "id")
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil || orderID <= 0 {
-		http.Error(w, "bad request: invalid order id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid order id")
 	}
 
 	ord, items, err := repository.GetOrderWithItems(r.Context(), orderID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if ord == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 
 	deliveryInfo, _ := repository.GetOrderDelivery(r.Context(), orderID)
 
 	user, _ := repository.GetUserByID(r.Context(), ord.UserID)
 
+	shipments, _ := repository.GetOrderShipments(r.Context(), orderID)
+
 	response := map[string]interface{}{
-		"order":    ord,
-		"items":    items,
-		"delivery": deliveryInfo,
-		"user":     user,
+		"order":     ord,
+		"items":     items,
+		"delivery":  deliveryInfo,
+		"user":      user,
+		"shipments": shipments,
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
+	return nil
 }
 
-func AdminUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateOrderStatus(w http.ResponseWriter, r *http.Request) error {
 	orderIDStr := chi.URLParam(r, "id")
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil || orderID <= 0 {
-		http.Error(w, "bad request: invalid order id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid order id")
 	}
 
 	var payload struct {
@@ -141,61 +166,77 @@ func AdminUpdateOrderStatus(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 
 	if payload.Status == "" {
-		http.Error(w, "bad request: status is required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("status is required")
 	}
 
 	ord, _, err := repository.GetOrderWithItems(r.Context(), orderID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if ord == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 
 	if err := repository.UpdateOrderStatus(r.Context(), orderID, payload.Status); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
+	}
+	if payload.Status == "cancelled" && ord.Status != "cancelled" {
+		metrics.OrdersCancelled.Inc("admin")
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]string{
 		"message": "Order status updated successfully",
 	})
+	return nil
 }
 
-func AdminUpdateOrder(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateOrder(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	orderID, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "invalid order id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid order id")
 	}
 
 	var payload struct {
 		Status string `json:"status"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 
 	if payload.Status == "" {
-		http.Error(w, "status is required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("status is required")
 	}
 
 	if err := repository.UpdateOrderStatus(r.Context(), orderID, payload.Status); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// parseCursor разбирает ?cursor= списков админки; пустая строка — первая страница (nil).
+func parseCursor(s string) (*cursor.Cursor, error) {
+	if s == "" {
+		return nil, nil
+	}
+	c, err := cursor.Decode(s)
+	if err != nil {
+		return nil, validation.Errors{{Field: "cursor", Code: validation.CodeInvalidFormat, Message: "invalid cursor"}}
+	}
+	return c, nil
+}
+
+// cursorError превращает несовпадение курсора со списком в ошибку валидации.
+func cursorError(err error) error {
+	if errors.Is(err, cursor.ErrInvalid) {
+		return validation.Errors{{Field: "cursor", Code: validation.CodeInvalidFormat, Message: "cursor does not match the list"}}
+	}
+	return err
 }
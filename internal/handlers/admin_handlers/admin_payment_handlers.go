@@ -8,31 +8,30 @@ import (
 
 	"github.com/go-chi/chi/v5"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 )
 
 // AdminGetPaymentMethods — возвращаем список (переиспользуем GetPaymentMethods).
-func AdminGetPaymentMethods(w http.ResponseWriter, r *http.Request) {
+func AdminGetPaymentMethods(w http.ResponseWriter, r *http.Request) error {
 	methods, err := repository.GetPaymentMethods(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(methods)
+	return nil
 }
 
 // AdminCreatePaymentMethod — принимает простой JSON, конвертирует в repository.PaymentMethod.
-func AdminCreatePaymentMethod(w http.ResponseWriter, r *http.Request) {
+func AdminCreatePaymentMethod(w http.ResponseWriter, r *http.Request) error {
 	var p models.PaymentPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 
 	var pm models.PaymentMethod
@@ -51,35 +50,31 @@ func AdminCreatePaymentMethod(w http.ResponseWriter, r *http.Request) {
 
 	id, err := repository.CreatePaymentMethod(r.Context(), &pm)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
 // AdminUpdatePaymentMethod — ожидаем полный payload (name + is_active желательно).
-func AdminUpdatePaymentMethod(w http.ResponseWriter, r *http.Request) {
+func AdminUpdatePaymentMethod(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 
 	var p models.PaymentPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Name == "" {
-		http.Error(w, "name required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("name required")
 	}
 	// Требуем явный is_active в update, чтобы не нечаянно сбросить флаг.
 	if p.IsActive == nil {
-		http.Error(w, "is_active required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("is_active required")
 	}
 
 	var pm models.PaymentMethod
@@ -93,24 +88,23 @@ func AdminUpdatePaymentMethod(w http.ResponseWriter, r *http.Request) {
 	pm.IsActive = *p.IsActive
 
 	if err := repository.UpdatePaymentMethod(r.Context(), &pm); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
 // AdminDeletePaymentMethod — удаление по id.
-func AdminDeletePaymentMethod(w http.ResponseWriter, r *http.Request) {
+func AdminDeletePaymentMethod(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeletePaymentMethod(r.Context(), id); err != nil {
 		// если FK в orders -> вернётся ошибка; даём понятный код при конфликте
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
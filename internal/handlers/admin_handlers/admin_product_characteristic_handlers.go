@@ -2,16 +2,20 @@ package admin_handlers
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"net/http"
 	"strconv"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func AdminListProductCharacteristics(w http.ResponseWriter, r *http.Request) {
+func AdminListProductCharacteristics(w http.ResponseWriter, r *http.Request) error {
 	pageStr := r.URL.Query().Get("page")
 	limitStr := r.URL.Query().Get("limit")
 	page := 1
@@ -28,121 +32,151 @@ func AdminListProductCharacteristics(w http.ResponseWriter, r *http.Request) {
 	}
 	offset := (page - 1) * limit
 
-	list, err := repository.GetAllProductCharacteristicsWithPagination(r.Context(), limit, offset)
-	if err != nil {
-		// логируем в stdout/stderr (поможет диагностировать 500)
-		// log.Printf("AdminListProductCharacteristics error: %v", err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	// ?cursor= (пустой — первая страница) — курсорная пагинация вместо page
+	var list []models.ProductCharacteristic
+	var cursors repository.CursorPage
+	keyset := r.URL.Query().Has("cursor")
+	if keyset {
+		cur, err := parseCursor(r.URL.Query().Get("cursor"))
+		if err != nil {
+			return err
+		}
+		list, cursors, err = repository.GetAllProductCharacteristicsByCursor(r.Context(), limit, cur)
+		if err != nil {
+			return cursorError(err)
+		}
+	} else {
+		var err error
+		list, err = repository.GetAllProductCharacteristicsWithPagination(r.Context(), limit, offset)
+		if err != nil {
+			return err
+		}
 	}
 
 	total, err := repository.CountProductCharacteristics(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	resp := map[string]interface{}{
 		"data":  list,
 		"total": total,
-		"page":  page,
 		"limit": limit,
 	}
+	if keyset {
+		resp["next_cursor"] = cursors.Next
+		resp["prev_cursor"] = cursors.Prev
+	} else {
+		resp["page"] = page
+	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
+	return nil
 }
 
-func AdminGetProductCharacteristics(w http.ResponseWriter, r *http.Request) {
+func AdminGetProductCharacteristics(w http.ResponseWriter, r *http.Request) error {
 	productIDStr := chi.URLParam(r, "product_id")
 	productID, _ := strconv.Atoi(productIDStr)
 	if productID <= 0 {
-		http.Error(w, "bad request: product id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid product id")
 	}
 	chars, err := repository.GetProductCharacteristics(r.Context(), productID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(chars)
+	return nil
 }
 
-func AdminCreateProductCharacteristic(w http.ResponseWriter, r *http.Request) {
+func AdminCreateProductCharacteristic(w http.ResponseWriter, r *http.Request) error {
 	var p models.ProductCharacteristicInput
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.ProductID <= 0 || p.CharacteristicTypeID <= 0 || p.Value == "" {
-		http.Error(w, "product_id, characteristic_type_id and value required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("product_id, characteristic_type_id and value required")
 	}
 	id, err := repository.CreateProductCharacteristic(r.Context(), &p)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return characteristicValueErrors(err, false)
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func AdminUpdateProductCharacteristic(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateProductCharacteristic(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	var p models.ProductCharacteristicInput
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	p.ID = id
 	if p.ID <= 0 || p.CharacteristicTypeID <= 0 || p.Value == "" {
-		http.Error(w, "id, characteristic_type_id and value required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("id, characteristic_type_id and value required")
 	}
 	if err := repository.UpdateProductCharacteristic(r.Context(), &p); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return characteristicValueErrors(err, false)
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminDeleteProductCharacteristic(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteProductCharacteristic(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeleteProductCharacteristic(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminReplaceProductCharacteristics(w http.ResponseWriter, r *http.Request) {
+func AdminReplaceProductCharacteristics(w http.ResponseWriter, r *http.Request) error {
 	productIDStr := chi.URLParam(r, "product_id")
 	productID, _ := strconv.Atoi(productIDStr)
 	if productID <= 0 {
-		http.Error(w, "bad request: product id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid product id")
 	}
 	var inputs []models.ProductCharacteristicInput
 	if err := json.NewDecoder(r.Body).Decode(&inputs); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	for _, it := range inputs {
 		if it.CharacteristicTypeID <= 0 || it.Value == "" {
-			http.Error(w, "each characteristic must include characteristic_type_id and non-empty value", http.StatusBadRequest)
-			return
+			return apperr.BadRequest("each characteristic must include characteristic_type_id and non-empty value")
 		}
 		it.ProductID = productID
 	}
 	if err := repository.ReplaceProductCharacteristics(r.Context(), productID, inputs); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return characteristicValueErrors(err, true)
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// characteristicValueErrors переводит ошибки значений характеристик в ошибки полей (422).
+// list — запрос был массивом, поле указывается с индексом ("[2].value").
+func characteristicValueErrors(err error, list bool) error {
+	var ves repository.CharacteristicValueErrors
+	if !errors.As(err, &ves) {
+		return err
+	}
+	var errs validation.Errors
+	for _, ve := range ves {
+		field, code := "value", validation.CodeInvalidFormat
+		if errors.Is(ve.Err, repository.ErrUnknownCharacteristicType) {
+			field, code = "characteristic_type_id", validation.CodeNotFound
+		}
+		if list {
+			field = fmt.Sprintf("[%d].%s", ve.Index, field)
+		}
+		errs.Add(field, code, ve.Err.Error())
+	}
+	return errs
 }
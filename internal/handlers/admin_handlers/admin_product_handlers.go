@@ -2,93 +2,87 @@ package admin_handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func AdminGetProducts(w http.ResponseWriter, r *http.Request) {
+func AdminGetProducts(w http.ResponseWriter, r *http.Request) error {
 	products, err := repository.GetAllProducts(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(products)
+	return nil
 }
 
-func AdminCreateProduct(w http.ResponseWriter, r *http.Request) {
+func AdminCreateProduct(w http.ResponseWriter, r *http.Request) error {
 	var payload models.Product
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	id, err := repository.CreateProduct(r.Context(), &payload)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func AdminUpdateProduct(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateProduct(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var payload models.Product
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	payload.ID = id
 	if err := repository.UpdateProduct(r.Context(), &payload); err != nil {
-		log.Printf("AdminUpdateProduct error id=%d: %v", id, err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminDeleteProduct(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteProduct(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
-	if err := repository.DeleteProduct(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	fileKeys, err := repository.DeleteProduct(r.Context(), id)
+	if err != nil {
+		return err
 	}
+	deleteProductFiles(r.Context(), fileKeys)
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminGetProductByID(w http.ResponseWriter, r *http.Request) {
+func AdminGetProductByID(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	p, err := repository.GetProductByID(r.Context(), id)
 	if err != nil {
-		log.Printf("AdminGetProductByID error id=%d: %v", id, err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if p == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(p)
+	return nil
 }
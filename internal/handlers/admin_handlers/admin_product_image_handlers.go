@@ -0,0 +1,321 @@
+package admin_handlers
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/imaging"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Лимиты изображений товара; задаются при старте (ConfigureProductImages).
+var (
+	maxProductImages          = 10
+	maxProductImageSize int64 = 5 << 20
+)
+
+// ConfigureProductImages задаёт, сколько изображений может быть у товара и их предельный размер.
+func ConfigureProductImages(maxImages int, maxImageBytes int64) {
+	maxProductImages = maxImages
+	maxProductImageSize = maxImageBytes
+}
+
+// productImageSizes — уменьшенные копии, которые строятся при загрузке (сторона — по большей стороне).
+var productImageSizes = []struct {
+	name string
+	side int
+}{
+	{models.ImageVariantThumb, 240},
+	{models.ImageVariantMedium, 640},
+}
+
+// AdminGetProductImages — изображения товара по порядку.
+func AdminGetProductImages(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	p, err := repository.GetProductByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return apperr.NotFound("product not found")
+	}
+	return writeProductImages(w, r, id, http.StatusOK)
+}
+
+// AdminUploadProductImages — POST /api/admin/products/{id}/images, multipart/form-data с одним
+// или несколькими файлами images (JPEG или PNG). Изображения добавляются в конец списка; для
+// каждого строятся превью thumb и medium в исходном формате и в WebP. Первое загруженное
+// изображение товара становится главным.
+func AdminUploadProductImages(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxProductImages)*maxProductImageSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return productImageTooLarge()
+		}
+		return apperr.BadRequest("expected multipart/form-data body")
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	p, err := repository.GetProductByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if p == nil {
+		return apperr.NotFound("product not found")
+	}
+	existing, err := repository.GetProductImages(r.Context(), id)
+	if err != nil {
+		return err
+	}
+
+	headers := r.MultipartForm.File["images"]
+	var errs validation.Errors
+	switch {
+	case len(headers) == 0:
+		errs.Add("images", validation.CodeRequired, "at least one image is required")
+	case len(existing)+len(headers) > maxProductImages:
+		errs.Add("images", validation.CodeNotAllowed,
+			"a product can have at most "+strconv.Itoa(maxProductImages)+" images")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	images := make([]models.ProductImage, 0, len(headers))
+	var files []productFile
+	for i, h := range headers {
+		field := "images[" + strconv.Itoa(i) + "]"
+		if h.Size > maxProductImageSize {
+			return productImageTooLarge()
+		}
+		f, err := h.Open()
+		if err != nil {
+			return apperr.BadRequest("invalid image file")
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxProductImageSize+1))
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > maxProductImageSize {
+			return productImageTooLarge()
+		}
+
+		img, imgFiles, err := processProductImage(data)
+		switch {
+		case errors.Is(err, errUnsupportedImage):
+			errs.Add(field, validation.CodeNotAllowed, "image must be JPEG or PNG")
+			continue
+		case errors.Is(err, imaging.ErrTooLarge):
+			errs.Add(field, validation.CodeNotAllowed, "image must not exceed "+
+				strconv.Itoa(imaging.MaxPixels/1_000_000)+" megapixels")
+			continue
+		case err != nil:
+			errs.Add(field, validation.CodeInvalidFormat, "image is corrupted")
+			continue
+		}
+		images = append(images, img)
+		files = append(files, imgFiles...)
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	// файлы кладём до записи в БД; если запись не удалась — убираем их
+	stored := 0
+	cleanup := func() {
+		keys := make([]string, 0, stored)
+		for _, f := range files[:stored] {
+			keys = append(keys, f.key)
+		}
+		deleteProductFiles(r.Context(), keys)
+	}
+	for _, f := range files {
+		if err := storage.Files.Put(r.Context(), f.key, bytes.NewReader(f.data), int64(len(f.data)), f.contentType); err != nil {
+			cleanup()
+			return err
+		}
+		stored++
+	}
+	if err := repository.AddProductImages(r.Context(), id, images, maxProductImages); err != nil {
+		cleanup()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("product not found")
+		case errors.Is(err, repository.ErrTooManyProductImages):
+			return validation.Errors{{Field: "images", Code: validation.CodeNotAllowed,
+				Message: "a product can have at most " + strconv.Itoa(maxProductImages) + " images"}}
+		}
+		return err
+	}
+	return writeProductImages(w, r, id, http.StatusCreated)
+}
+
+// AdminReorderProductImages — PUT /api/admin/products/{id}/images/order
+// с телом {"image_ids": [...]}: все изображения товара в новом порядке.
+func AdminReorderProductImages(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	var payload models.ProductImageOrderPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if err := repository.ReorderProductImages(r.Context(), id, payload.ImageIDs); err != nil {
+		if errors.Is(err, repository.ErrImageOrderMismatch) {
+			return validation.Errors{{Field: "image_ids", Code: validation.CodeNotAllowed,
+				Message: "image_ids must list every image of the product exactly once"}}
+		}
+		return err
+	}
+	return writeProductImages(w, r, id, http.StatusOK)
+}
+
+// AdminSetPrimaryProductImage — PUT /api/admin/products/{id}/images/{imageID}/primary.
+// Превью главного изображения попадает в image_path товара.
+func AdminSetPrimaryProductImage(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	imageID, _ := strconv.Atoi(chi.URLParam(r, "imageID"))
+	if id <= 0 || imageID <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	if err := repository.SetPrimaryProductImage(r.Context(), id, imageID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("image not found")
+		}
+		return err
+	}
+	return writeProductImages(w, r, id, http.StatusOK)
+}
+
+// AdminDeleteProductImage — DELETE /api/admin/products/{id}/images/{imageID}: удаляет
+// изображение вместе с превью. Если оно было главным, главным становится следующее.
+func AdminDeleteProductImage(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	imageID, _ := strconv.Atoi(chi.URLParam(r, "imageID"))
+	if id <= 0 || imageID <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	keys, err := repository.DeleteProductImage(r.Context(), id, imageID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("image not found")
+		}
+		return err
+	}
+	deleteProductFiles(r.Context(), keys)
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func writeProductImages(w http.ResponseWriter, r *http.Request, productID, status int) error {
+	images, err := repository.GetProductImages(r.Context(), productID)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(images)
+	return nil
+}
+
+// errUnsupportedImage — файл не JPEG и не PNG (WebP стандартная библиотека декодировать не умеет).
+var errUnsupportedImage = errors.New("unsupported image format")
+
+// productFile — файл, который нужно положить в хранилище.
+type productFile struct {
+	key         string
+	contentType string
+	data        []byte
+}
+
+// processProductImage проверяет изображение и строит его превью. Ключи превью выводятся из
+// ключа оригинала: products/2026/10/<id>.jpg → <id>_thumb.jpg, <id>_thumb.webp и т.д.
+func processProductImage(data []byte) (models.ProductImage, []productFile, error) {
+	ct, ext, ok := imaging.Detect(data)
+	if !ok || ct == imaging.WebP {
+		return models.ProductImage{}, nil, errUnsupportedImage
+	}
+	src, err := imaging.Decode(data)
+	if err != nil {
+		return models.ProductImage{}, nil, err
+	}
+
+	key := storage.NewKey("products", ext)
+	base := strings.TrimSuffix(key, ext)
+	img := models.ProductImage{
+		Key:         key,
+		ContentType: ct,
+		Size:        int64(len(data)),
+		Width:       src.Bounds().Dx(),
+		Height:      src.Bounds().Dy(),
+	}
+	files := []productFile{{key: key, contentType: ct, data: data}}
+
+	for _, size := range productImageSizes {
+		resized := imaging.Fit(src, size.side)
+		var buf bytes.Buffer
+		vct, err := imaging.Encode(&buf, resized)
+		if err != nil {
+			return models.ProductImage{}, nil, err
+		}
+		var webp bytes.Buffer
+		if err := imaging.EncodeWebP(&webp, resized); err != nil {
+			return models.ProductImage{}, nil, err
+		}
+		for _, v := range []productFile{
+			{key: base + "_" + size.name + imaging.Ext(vct), contentType: vct, data: buf.Bytes()},
+			{key: base + "_" + size.name + imaging.Ext(imaging.WebP), contentType: imaging.WebP, data: webp.Bytes()},
+		} {
+			img.Variants = append(img.Variants, models.ProductImageVariant{
+				Name:        size.name,
+				Key:         v.key,
+				ContentType: v.contentType,
+				Size:        int64(len(v.data)),
+				Width:       resized.Rect.Dx(),
+				Height:      resized.Rect.Dy(),
+			})
+			files = append(files, v)
+		}
+	}
+	return img, files, nil
+}
+
+// deleteProductFiles удаляет файлы из хранилища; ошибки только логируются — строки
+// в БД уже удалены, а осиротевший файл безвреден.
+func deleteProductFiles(ctx context.Context, keys []string) {
+	for _, key := range keys {
+		if err := storage.Files.Delete(ctx, key); err != nil {
+			logging.FromContext(ctx).Warn("failed to delete product file", "key", key, "error", err)
+		}
+	}
+}
+
+func productImageTooLarge() error {
+	return apperr.New(http.StatusRequestEntityTooLarge, "file_too_large",
+		"each image must not exceed "+strconv.FormatInt(maxProductImageSize>>20, 10)+" MB")
+}
@@ -0,0 +1,82 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminGetReturns — список заявок на возврат, ?status= фильтрует по статусу.
+func AdminGetReturns(w http.ResponseWriter, r *http.Request) error {
+	list, err := repository.GetReturns(r.Context(), nil, r.URL.Query().Get("status"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+	return nil
+}
+
+// AdminGetReturnByID — заявка с историей статусов.
+func AdminGetReturnByID(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	rr, err := repository.GetReturnByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if rr == nil {
+		return apperr.NotFound("not found")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(rr)
+	return nil
+}
+
+// AdminUpdateReturnStatus — одобрение / отказ / приёмка / возврат денег.
+// JSON: { "status": "approved|rejected|received|refunded", "comment": "...", "refund_amount": 123.45 }
+func AdminUpdateReturnStatus(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var payload models.ReturnStatusPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.Status == "" {
+		return apperr.BadRequest("status is required")
+	}
+	if payload.RefundAmount != nil && *payload.RefundAmount < 0 {
+		return apperr.BadRequest("refund_amount must be non-negative")
+	}
+
+	if err := repository.TransitionReturn(r.Context(), id, adminID, &payload); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("return not found")
+		case errors.Is(err, repository.ErrReturnTransition):
+			return apperr.Conflict(err.Error())
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message": "Return status updated successfully",
+	})
+	return nil
+}
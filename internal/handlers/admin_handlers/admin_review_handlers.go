@@ -0,0 +1,131 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminGetReviews — очередь модерации отзывов с пагинацией.
+// ?status=pending (по умолчанию)|approved|rejected|all.
+func AdminGetReviews(w http.ResponseWriter, r *http.Request) error {
+	q := r.URL.Query()
+
+	page := 1
+	limit := 20
+	if p, err := strconv.Atoi(q.Get("page")); err == nil && p > 0 {
+		page = p
+	}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		limit = min(l, 100)
+	}
+
+	status := q.Get("status")
+	switch status {
+	case "":
+		status = models.ReviewPending
+	case "all":
+		status = ""
+	case models.ReviewPending, models.ReviewApproved, models.ReviewRejected:
+	default:
+		return validation.Errors{{Field: "status", Code: validation.CodeNotAllowed,
+			Message: "status must be pending, approved, rejected or all"}}
+	}
+
+	list, err := repository.GetReviews(r.Context(), status, limit, (page-1)*limit)
+	if err != nil {
+		return err
+	}
+	total, err := repository.CountReviews(r.Context(), status)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]interface{}{
+		"data":  list,
+		"total": total,
+		"page":  page,
+		"limit": limit,
+	})
+	return nil
+}
+
+// AdminModerateReview — одобрение или отклонение отзыва; рейтинг товара пересчитывается.
+func AdminModerateReview(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var payload models.ReviewModerationPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	payload.Note = strings.TrimSpace(payload.Note)
+
+	var errs validation.Errors
+	if payload.Status != models.ReviewApproved && payload.Status != models.ReviewRejected {
+		errs.Add("status", validation.CodeNotAllowed, "status must be approved or rejected")
+	}
+	if len(payload.Note) > 1000 {
+		errs.Add("note", validation.CodeTooLong, "note must be at most 1000 characters")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	if err := repository.ModerateReview(r.Context(), id, adminID, payload); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("review not found")
+		}
+		if errors.Is(err, repository.ErrReviewExists) {
+			return apperr.Conflict("the author already has another active review of this product")
+		}
+		return err
+	}
+
+	review, err := repository.GetReviewByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(review)
+	return nil
+}
+
+// AdminDeleteReview удаляет отзыв (например, спам) и пересчитывает рейтинг товара.
+func AdminDeleteReview(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	imageKeys, err := repository.DeleteReview(r.Context(), id, 0)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("review not found")
+		}
+		return err
+	}
+	for _, key := range imageKeys {
+		if err := storage.Files.Delete(r.Context(), key); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete review image", "key", key, "error", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
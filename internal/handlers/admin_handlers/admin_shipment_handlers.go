@@ -0,0 +1,110 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AdminGetOrderShipments — отправления заказа с позициями и хронологией.
+func AdminGetOrderShipments(w http.ResponseWriter, r *http.Request) error {
+	orderID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if orderID <= 0 {
+		return apperr.BadRequest("invalid order id")
+	}
+	shipments, err := repository.GetOrderShipments(r.Context(), orderID)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(shipments)
+	return nil
+}
+
+// AdminCreateShipment — создаёт отправление (в т.ч. частичное) по заказу.
+// JSON: { "carrier": "CDEK", "tracking_number": "...", "items": [{ "order_item_id": 1, "quantity": 2 }] }
+// Без items отгружается весь неотгруженный остаток.
+func AdminCreateShipment(w http.ResponseWriter, r *http.Request) error {
+	orderID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if orderID <= 0 {
+		return apperr.BadRequest("invalid order id")
+	}
+	var payload models.CreateShipmentPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	payload.Carrier = strings.TrimSpace(payload.Carrier)
+	payload.TrackingNumber = strings.TrimSpace(payload.TrackingNumber)
+	if payload.Carrier == "" || payload.TrackingNumber == "" {
+		return apperr.BadRequest("carrier and tracking_number required")
+	}
+	// одна позиция заказа — одна строка отправления (shipment_items: PK shipment_id+order_item_id)
+	var errs validation.Errors
+	seen := make(map[int]bool, len(payload.Items))
+	for i, it := range payload.Items {
+		if seen[it.OrderItemID] {
+			errs.Add("items["+strconv.Itoa(i)+"].order_item_id", validation.CodeNotAllowed,
+				"order item is listed more than once")
+		}
+		seen[it.OrderItemID] = true
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	id, err := repository.CreateShipment(r.Context(), orderID, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("order not found")
+		case errors.Is(err, repository.ErrShipmentNotAllowed), errors.Is(err, repository.ErrNothingToShip):
+			return apperr.Conflict(err.Error())
+		case errors.Is(err, repository.ErrShipmentQuantity), errors.Is(err, repository.ErrShipmentItemNotFound):
+			return apperr.BadRequest(err.Error())
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
+}
+
+// AdminUpdateShipmentStatus — обновляет статус отправления; статус заказа пересчитывается автоматически.
+// JSON: { "status": "shipped|in_transit|out_for_delivery|delivered|failed", "location": "...", "comment": "..." }
+func AdminUpdateShipmentStatus(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	var payload models.ShipmentStatusPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.Status == "" {
+		return apperr.BadRequest("status is required")
+	}
+
+	if err := repository.UpdateShipmentStatus(r.Context(), id, &payload); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("shipment not found")
+		case errors.Is(err, repository.ErrShipmentStatus):
+			return apperr.BadRequest(err.Error())
+		case errors.Is(err, repository.ErrShipmentTransition):
+			return apperr.Conflict(err.Error())
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
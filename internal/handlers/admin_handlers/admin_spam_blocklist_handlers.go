@@ -0,0 +1,79 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/go-chi/chi/v5"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
+)
+
+const maxSpamBlocklistValueLen = 255
+
+func AdminGetSpamBlocklist(w http.ResponseWriter, r *http.Request) error {
+	entries, err := repository.GetSpamBlocklist(r.Context())
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(entries)
+	return nil
+}
+
+func AdminCreateSpamBlocklistEntry(w http.ResponseWriter, r *http.Request) error {
+	var payload models.SpamBlocklistEntry
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	payload.Kind = strings.ToLower(strings.TrimSpace(payload.Kind))
+	payload.Value = strings.TrimSpace(payload.Value)
+
+	var errs validation.Errors
+	if payload.Kind != models.SpamBlockTerm && payload.Kind != models.SpamBlockEmail {
+		errs.Add("kind", validation.CodeNotAllowed, "kind must be term or email")
+	}
+	switch {
+	case payload.Value == "":
+		errs.Add("value", validation.CodeRequired, "value is required")
+	case len([]rune(payload.Value)) > maxSpamBlocklistValueLen:
+		errs.Add("value", validation.CodeTooLong, "value is too long")
+	case payload.Kind == models.SpamBlockEmail && !strings.Contains(payload.Value, "@"):
+		errs.Add("value", validation.CodeInvalidFormat, "email entry must be an address or a domain starting with @")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	id, err := repository.CreateSpamBlocklistEntry(r.Context(), &payload)
+	if err != nil {
+		return err
+	}
+	payload.ID = id
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	_ = json.NewEncoder(w).Encode(payload)
+	return nil
+}
+
+func AdminDeleteSpamBlocklistEntry(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	if err := repository.DeleteSpamBlocklistEntry(r.Context(), id); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("blocklist entry not found")
+		}
+		return err
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
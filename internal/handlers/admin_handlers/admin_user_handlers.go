@@ -2,33 +2,31 @@ package admin_handlers
 
 import (
 	"encoding/json"
-	"errors"
-	"log"
 	"net/http"
 	"strconv"
 
 	"github.com/go-chi/chi/v5"
-	"github.com/lib/pq"
 	"golang.org/x/crypto/bcrypt"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 )
 
 // AdminGetUsers — возвращает всех пользователей (без password_hash).
-func AdminGetUsers(w http.ResponseWriter, r *http.Request) {
+func AdminGetUsers(w http.ResponseWriter, r *http.Request) error {
 	users, err := repository.GetAllUsers(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(users)
+	return nil
 }
 
 // AdminCreateUser — создаёт пользователя (принимает пароль в теле).
 // JSON: { "email": "...", "password": "...", "first_name": "...", "last_name": "...", "phone": "...", "is_admin": true/false }
-func AdminCreateUser(w http.ResponseWriter, r *http.Request) {
+func AdminCreateUser(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		Email     string `json:"email"`
 		Password  string `json:"password"`
@@ -38,18 +36,15 @@ func AdminCreateUser(w http.ResponseWriter, r *http.Request) {
 		IsAdmin   bool   `json:"is_admin"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.Email == "" || payload.Password == "" {
-		http.Error(w, "email and password required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("email and password required")
 	}
 	// hash password
 	h, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	u := &models.User{
 		Email:     payload.Email,
@@ -60,21 +55,20 @@ func AdminCreateUser(w http.ResponseWriter, r *http.Request) {
 	}
 	id, err := repository.CreateUser(r.Context(), u, string(h))
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
 // AdminUpdateUser — обновляет данные пользователя (не меняет пароль).
 // JSON: { "email": "...", "first_name": "...", "last_name": "...", "phone": "...", "is_admin": true/false }
-func AdminUpdateUser(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateUser(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var payload struct {
 		Email     string `json:"email"`
@@ -84,8 +78,7 @@ func AdminUpdateUser(w http.ResponseWriter, r *http.Request) {
 		IsAdmin   bool   `json:"is_admin"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	u := &models.User{
 		ID:        id,
@@ -96,87 +89,73 @@ func AdminUpdateUser(w http.ResponseWriter, r *http.Request) {
 		IsAdmin:   payload.IsAdmin,
 	}
 	if err := repository.UpdateUser(r.Context(), u); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
 // AdminDeleteUser — удаляет пользователя по id.
-func AdminDeleteUser(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteUser(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
+	// FK violation (пользователь упомянут в заказах и т.п.) превращается в 409 в apperr.From
 	if err := repository.DeleteUser(r.Context(), id); err != nil {
-		log.Printf("AdminDeleteUser error id=%d: %v", id, err)
-		var pqErr *pq.Error
-		if errors.As(err, &pqErr) && pqErr.Code == "23503" {
-			// FK violation
-			http.Error(w, "conflict: cannot delete, resource is referenced by other records", http.StatusConflict)
-			return
-		}
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func AdminGetUserByID(w http.ResponseWriter, r *http.Request) {
+func AdminGetUserByID(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	u, err := repository.GetUserByID(r.Context(), id)
 	if err != nil {
-		log.Printf("AdminGetUserByID error id=%d: %v", id, err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if u == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(u)
+	return nil
 }
 
 // AdminUpdateUserPassword обновляет пароль пользователя
-func AdminUpdateUserPassword(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateUserPassword(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	userID, err := strconv.Atoi(idStr)
 	if err != nil {
-		http.Error(w, "invalid id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 
 	var payload struct {
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 
 	if len(payload.Password) < 6 {
-		http.Error(w, "password must be at least 6 characters", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("password must be at least 6 characters")
 	}
 
 	hashed, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	if err := repository.UpdateUserPassword(r.Context(), userID, string(hashed)); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
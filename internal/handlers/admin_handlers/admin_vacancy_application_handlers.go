@@ -0,0 +1,127 @@
+package admin_handlers
+
+import (
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+
+	"github.com/go-chi/chi/v5"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+)
+
+// AdminGetVacancyApplications — отклики на вакансию, ?status= фильтрует по статусу.
+func AdminGetVacancyApplications(w http.ResponseWriter, r *http.Request) error {
+	vacancyID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if vacancyID <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	status := r.URL.Query().Get("status")
+	if status != "" && !repository.IsApplicationStatus(status) {
+		return apperr.BadRequest("unknown status")
+	}
+	v, err := repository.GetVacancyByID(r.Context(), vacancyID)
+	if err != nil {
+		return err
+	}
+	if v == nil {
+		return apperr.NotFound("vacancy not found")
+	}
+	list, err := repository.GetVacancyApplications(r.Context(), vacancyID, status)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(list)
+	return nil
+}
+
+// AdminGetVacancyApplicationByID — отклик с историей статусов.
+func AdminGetVacancyApplicationByID(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	a, err := repository.GetVacancyApplicationByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return apperr.NotFound("application not found")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(a)
+	return nil
+}
+
+// AdminUpdateVacancyApplicationStatus — смена статуса отклика.
+// JSON: { "status": "screening|interview|rejected|hired", "comment": "..." }
+func AdminUpdateVacancyApplicationStatus(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	adminID, _ := middleware.UserIDFromContext(r.Context())
+
+	var payload models.VacancyApplicationStatusPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.Status == "" {
+		return apperr.BadRequest("status is required")
+	}
+
+	if err := repository.TransitionVacancyApplication(r.Context(), id, adminID, &payload); err != nil {
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("application not found")
+		case errors.Is(err, repository.ErrApplicationTransition):
+			return apperr.Conflict(err.Error())
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	_ = json.NewEncoder(w).Encode(map[string]string{
+		"message": "Application status updated successfully",
+	})
+	return nil
+}
+
+// AdminDownloadVacancyApplicationCV — файл резюме кандидата.
+func AdminDownloadVacancyApplicationCV(w http.ResponseWriter, r *http.Request) error {
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	a, err := repository.GetVacancyApplicationByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if a == nil {
+		return apperr.NotFound("application not found")
+	}
+	f, err := storage.Files.Open(r.Context(), a.CVKey)
+	if errors.Is(err, storage.ErrNotFound) {
+		return apperr.NotFound("CV file is missing")
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	w.Header().Set("Content-Type", a.CVContentType)
+	w.Header().Set("Content-Length", strconv.FormatInt(a.CVSize, 10))
+	w.Header().Set("Content-Disposition", mime.FormatMediaType("attachment", map[string]string{"filename": a.CVFilename}))
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = io.Copy(w, f)
+	return nil
+}
@@ -4,54 +4,53 @@ import (
 	"encoding/json"
 	"net/http"
 	"strconv"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
 
 	"github.com/go-chi/chi/v5"
 )
 
 // AdminGetVacancies — переиспользует существующую функцию GetVacancies.
-func AdminGetVacancies(w http.ResponseWriter, r *http.Request) {
+func AdminGetVacancies(w http.ResponseWriter, r *http.Request) error {
 	vacancies, err := repository.GetVacancies(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(vacancies)
+	return nil
 }
 
 // AdminGetVacancyByID — возвращает одну вакансию по id.
-func AdminGetVacancyByID(w http.ResponseWriter, r *http.Request) {
+func AdminGetVacancyByID(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	v, err := repository.GetVacancyByID(r.Context(), id)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if v == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	_ = json.NewEncoder(w).Encode(v)
+	return nil
 }
 
 // AdminCreateVacancy — создаёт вакансию.
-func AdminCreateVacancy(w http.ResponseWriter, r *http.Request) {
+func AdminCreateVacancy(w http.ResponseWriter, r *http.Request) error {
 	var p models.VacancyPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Title == "" {
-		http.Error(w, "title required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("title required")
 	}
 	v := &models.Vacancy{
 		Title:        p.Title,
@@ -62,29 +61,26 @@ func AdminCreateVacancy(w http.ResponseWriter, r *http.Request) {
 	}
 	id, err := repository.CreateVacancy(r.Context(), v)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	_ = json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
 // AdminUpdateVacancy — обновляет вакансию по id.
-func AdminUpdateVacancy(w http.ResponseWriter, r *http.Request) {
+func AdminUpdateVacancy(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	var p models.VacancyPayload
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.Title == "" {
-		http.Error(w, "title required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("title required")
 	}
 	v := &models.Vacancy{
 		ID:           id,
@@ -95,23 +91,32 @@ func AdminUpdateVacancy(w http.ResponseWriter, r *http.Request) {
 		ContactEmail: p.ContactEmail,
 	}
 	if err := repository.UpdateVacancy(r.Context(), v); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
 // AdminDeleteVacancy — удаляет вакансию.
-func AdminDeleteVacancy(w http.ResponseWriter, r *http.Request) {
+func AdminDeleteVacancy(w http.ResponseWriter, r *http.Request) error {
 	idStr := chi.URLParam(r, "id")
 	id, _ := strconv.Atoi(idStr)
 	if id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
+	}
+	// отклики удаляются каскадом, а файлы резюме — вручную после удаления вакансии
+	cvKeys, err := repository.GetVacancyCVKeys(r.Context(), id)
+	if err != nil {
+		return err
 	}
 	if err := repository.DeleteVacancy(r.Context(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
+	}
+	for _, key := range cvKeys {
+		if err := storage.Files.Delete(r.Context(), key); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete CV file", "key", key, "error", err)
+		}
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
@@ -7,6 +7,8 @@ import (
 	"net/http"
 	"time"
 
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/metrics"
 	"x86trade_backend/internal/middleware"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
@@ -16,7 +18,7 @@ import (
 )
 
 // Register
-func RegisterHandler(w http.ResponseWriter, r *http.Request) {
+func RegisterHandler(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		Email     string `json:"email"`
 		Password  string `json:"password"`
@@ -24,23 +26,19 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 		LastName  string `json:"last_name"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.Email == "" || payload.Password == "" {
-		http.Error(w, "email and password required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("email and password required")
 	}
 	// check existing
 	if existing, _ := repository.GetUserByEmail(r.Context(), payload.Email); existing != nil {
-		http.Error(w, "email already registered", http.StatusConflict)
-		return
+		return apperr.Conflict("email already registered")
 	}
 	// hash pwd
 	hashed, err := bcrypt.GenerateFromPassword([]byte(payload.Password), bcrypt.DefaultCost)
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	user := &models.User{
 		Email:     payload.Email,
@@ -49,54 +47,50 @@ func RegisterHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	id, err := repository.CreateUser(r.Context(), user, string(hashed))
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
 // Login
-func LoginHandler(w http.ResponseWriter, r *http.Request) {
+func LoginHandler(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		Email    string `json:"email"`
 		Password string `json:"password"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	u, err := repository.GetUserByEmail(r.Context(), payload.Email)
 	if err != nil || u == nil {
-		http.Error(w, "invalid credentials", http.StatusUnauthorized)
-		return
+		metrics.LoginFailures.Inc("unknown_user")
+		return apperr.Unauthorized("invalid credentials")
 	}
 	if err := bcrypt.CompareHashAndPassword([]byte(u.PasswordHash), []byte(payload.Password)); err != nil {
-		http.Error(w, "invalid credentials", http.StatusUnauthorized)
-		return
+		metrics.LoginFailures.Inc("bad_password")
+		return apperr.Unauthorized("invalid credentials")
 	}
 
 	// generate access token
-	accessMinutes := utils.GetEnvInt("JWT_ACCESS_MINUTES", 15)
+	accessMinutes := utils.AccessTokenMinutes()
 	accessToken, err := utils.GenerateAccessToken(u.ID, accessMinutes)
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// generate refresh token (random string) and save
-	refreshDays := utils.GetEnvInt("JWT_REFRESH_DAYS", 7)
+	refreshDays := utils.RefreshTokenDays()
 	// random 32 bytes -> hex
 	b := make([]byte, 32)
 	if _, err := rand.Read(b); err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	refreshToken := hex.EncodeToString(b)
 	expiresAt := time.Now().Add(time.Duration(refreshDays) * 24 * time.Hour)
 	if err := repository.SaveRefreshToken(r.Context(), u.ID, refreshToken, expiresAt); err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// response
@@ -108,38 +102,34 @@ func LoginHandler(w http.ResponseWriter, r *http.Request) {
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(resp)
+	return nil
 }
 
 // Refresh
-func RefreshHandler(w http.ResponseWriter, r *http.Request) {
+func RefreshHandler(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		RefreshToken string `json:"refresh_token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.RefreshToken == "" {
-		http.Error(w, "refresh_token required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("refresh_token required")
 	}
 	userID, expiresAt, err := repository.GetRefreshToken(r.Context(), payload.RefreshToken)
 	if err != nil {
-		http.Error(w, "invalid refresh token", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("invalid refresh token")
 	}
 	if time.Now().After(expiresAt) {
 		// token expired — delete and ask to login again
 		_ = repository.DeleteRefreshToken(r.Context(), payload.RefreshToken)
-		http.Error(w, "refresh token expired", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("refresh token expired")
 	}
 
-	accessMinutes := utils.GetEnvInt("JWT_ACCESS_MINUTES", 15)
+	accessMinutes := utils.AccessTokenMinutes()
 	accessToken, err := utils.GenerateAccessToken(userID, accessMinutes)
 	if err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	w.Header().Set("Content-Type", "application/json")
@@ -148,49 +138,49 @@ func RefreshHandler(w http.ResponseWriter, r *http.Request) {
 		"token_type":     "bearer",
 		"expires_in_min": accessMinutes,
 	})
+	return nil
 }
 
 // Logout: delete refresh token
-func LogoutHandler(w http.ResponseWriter, r *http.Request) {
+func LogoutHandler(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		RefreshToken string `json:"refresh_token"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.RefreshToken == "" {
-		http.Error(w, "refresh_token required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("refresh_token required")
 	}
 	if err := repository.DeleteRefreshToken(r.Context(), payload.RefreshToken); err != nil {
-		http.Error(w, "server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
 // Get profile
-func MeHandler(w http.ResponseWriter, r *http.Request) {
+func MeHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	u, err := repository.GetUserByID(r.Context(), userID)
-	if err != nil || u == nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	if err != nil {
+		return err
+	}
+	if u == nil {
+		return apperr.NotFound("user not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(u)
+	return nil
 }
 
-func UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
+func UpdateMeHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	var payload struct {
 		FirstName string `json:"first_name"`
@@ -198,12 +188,11 @@ func UpdateMeHandler(w http.ResponseWriter, r *http.Request) {
 		Phone     string `json:"phone"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if err := repository.UpdateUserProfile(r.Context(), userID, payload.FirstName, payload.LastName, payload.Phone); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
@@ -2,88 +2,84 @@ package handlers
 
 import (
 	"encoding/json"
-	"log"
 	"net/http"
 	"strconv"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/metrics"
 	"x86trade_backend/internal/middleware"
 	"x86trade_backend/internal/repository"
 )
 
-func GetCartHandler(w http.ResponseWriter, r *http.Request) {
+func GetCartHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	items, err := repository.GetCartByUserID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(items)
+	return nil
 }
 
-func AddToCartHandler(w http.ResponseWriter, r *http.Request) {
+func AddToCartHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	var payload struct {
 		ProductID int `json:"product_id"`
 		Quantity  int `json:"quantity"`
 	}
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if payload.ProductID <= 0 || payload.Quantity <= 0 {
-		http.Error(w, "product_id and positive quantity required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("product_id and positive quantity required")
 	}
 	if err := repository.AddOrUpdateCartItem(r.Context(), userID, payload.ProductID, payload.Quantity); err != nil {
-		// логируем ошибку в stdout/stderr для диагностики
-		log.Printf("AddOrUpdateCartItem error user=%d product=%d qty=%d: %v\n", userID, payload.ProductID, payload.Quantity, err)
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		logging.FromContext(r.Context()).Error("AddOrUpdateCartItem failed",
+			"user_id", userID, "product_id", payload.ProductID, "quantity", payload.Quantity, "error", err)
+		return err
 	}
+	metrics.CartAdds.Inc()
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func UpdateCartHandler(w http.ResponseWriter, r *http.Request) {
+func UpdateCartHandler(w http.ResponseWriter, r *http.Request) error {
 	// same as AddToCart but requires id present
-	AddToCartHandler(w, r)
+	return AddToCartHandler(w, r)
 }
 
-func RemoveFromCartHandler(w http.ResponseWriter, r *http.Request) {
+func RemoveFromCartHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	pidStr := r.URL.Query().Get("product_id")
 	pid, err := strconv.Atoi(pidStr)
 	if err != nil || pid <= 0 {
-		http.Error(w, "product_id required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("product_id required")
 	}
 	if err := repository.RemoveCartItem(r.Context(), userID, pid); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func ClearCartHandler(w http.ResponseWriter, r *http.Request) {
+func ClearCartHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	if err := repository.ClearCart(r.Context(), userID); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
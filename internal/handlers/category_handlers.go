@@ -6,84 +6,79 @@ import (
 	"net/http"
 	"strconv"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
 )
 
-func GetCategoriesHandler(w http.ResponseWriter, r *http.Request) {
+func GetCategoriesHandler(w http.ResponseWriter, r *http.Request) error {
 	ctx := r.Context()
 	cats, err := repository.GetCategories(ctx)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(cats)
+	return nil
 }
 
-func GetCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func GetCategoryHandler(w http.ResponseWriter, r *http.Request) error {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	cat, err := repository.GetCategoryByID(context.Background(), id)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if cat == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(cat)
+	return nil
 }
 
-func CreateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func CreateCategoryHandler(w http.ResponseWriter, r *http.Request) error {
 	var c models.Category
 	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	id, err := repository.CreateCategory(context.Background(), &c)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func UpdateCategoryHandler(w http.ResponseWriter, r *http.Request) error {
 	var c models.Category
 	if err := json.NewDecoder(r.Body).Decode(&c); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if c.ID == 0 {
-		http.Error(w, "bad request: id required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("id required")
 	}
 	if err := repository.UpdateCategory(context.Background(), &c); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) {
+func DeleteCategoryHandler(w http.ResponseWriter, r *http.Request) error {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	if err := repository.DeleteCategory(context.Background(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
@@ -3,49 +3,133 @@ package handlers
 import (
 	"encoding/json"
 	"net/http"
+	"strings"
 	"time"
+	"unicode/utf8"
+	"x86trade_backend/internal/antispam"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/metrics"
+	"x86trade_backend/internal/middleware"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
 )
 
-func CreateContactMessageHandler(w http.ResponseWriter, r *http.Request) {
+const (
+	maxContactNameLen    = 255
+	maxContactInfoLen    = 255
+	maxContactMessageLen = 5000
+)
+
+// Антиспам формы обратной связи; задаётся один раз при старте (ConfigureContactSpam).
+var (
+	contactSpam       *antispam.Pipeline
+	contactTokens     *antispam.Tokens
+	contactMinFillSec int
+)
+
+// ConfigureContactSpam задаёт конвейер проверок и выпускающего токены формы.
+func ConfigureContactSpam(p *antispam.Pipeline, t *antispam.Tokens, minFill time.Duration) {
+	contactSpam = p
+	contactTokens = t
+	contactMinFillSec = int(minFill.Seconds())
+}
+
+// GetContactFormTokenHandler выдаёт токен, который фронтенд кладёт в form_token при отправке формы.
+func GetContactFormTokenHandler(w http.ResponseWriter, r *http.Request) error {
+	if contactTokens == nil {
+		return apperr.New(http.StatusServiceUnavailable, "antispam_not_configured", "contact form is not available")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"token":            contactTokens.Issue(),
+		"min_fill_seconds": contactMinFillSec,
+	})
+	return nil
+}
+
+func CreateContactMessageHandler(w http.ResponseWriter, r *http.Request) error {
 	var payload struct {
 		FullName    string `json:"full_name"`
 		ContactInfo string `json:"contact_info"`
 		Message     string `json:"message"`
+		// website — поле-ловушка: в форме оно скрыто, заполнять его должен только бот
+		Website   string `json:"website"`
+		FormToken string `json:"form_token"`
 	}
 
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 
-	// Валидация обязательных полей
-	if payload.FullName == "" || payload.ContactInfo == "" || payload.Message == "" {
-		http.Error(w, "bad request: all fields are required", http.StatusBadRequest)
-		return
+	payload.FullName = strings.TrimSpace(payload.FullName)
+	payload.ContactInfo = strings.TrimSpace(payload.ContactInfo)
+	payload.Message = strings.TrimSpace(payload.Message)
+
+	// Валидация обязательных полей и длины
+	var errs validation.Errors
+	checkContactField(&errs, "full_name", payload.FullName, maxContactNameLen)
+	checkContactField(&errs, "contact_info", payload.ContactInfo, maxContactInfoLen)
+	checkContactField(&errs, "message", payload.Message, maxContactMessageLen)
+	if errs.HasErrors() {
+		return errs
 	}
 
-	// Создаем сообщение
+	ip := middleware.ClientIP(r)
+	verdict := contactSpam.Evaluate(r.Context(), &antispam.Submission{
+		FullName:    payload.FullName,
+		ContactInfo: payload.ContactInfo,
+		Message:     payload.Message,
+		Honeypot:    payload.Website,
+		FormToken:   payload.FormToken,
+		IP:          ip,
+		ReceivedAt:  time.Now(),
+	})
+
+	// Создаем сообщение; подозрительное сохраняется с пометкой для просмотра администратором
 	msg := &models.ContactMessage{
 		FullName:    payload.FullName,
 		ContactInfo: payload.ContactInfo,
 		Message:     payload.Message,
 		CreatedAt:   time.Now(),
 		IsProcessed: false,
+		IsSpam:      verdict.Spam,
+		SpamScore:   verdict.Score,
+		SpamReasons: verdict.Reasons,
+		ClientIP:    ip,
 	}
 
 	id, err := repository.CreateContactMessage(r.Context(), msg)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
-	// Отправляем успешный ответ
+	if verdict.Spam {
+		metrics.ContactMessages.Inc("spam")
+		logging.FromContext(r.Context()).Warn("contact message flagged as spam",
+			"contact_message_id", id, "score", verdict.Score, "reasons", verdict.Reasons, "client_ip", ip)
+	} else {
+		metrics.ContactMessages.Inc("ok")
+	}
+
+	// Отправляем успешный ответ — одинаковый для спама и обычных сообщений, чтобы не подсказывать ботам
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{
 		"id":      id,
 		"message": "Ваше сообщение успешно отправлено",
 	})
+	return nil
+}
+
+// checkContactField проверяет, что поле заполнено и не длиннее max символов.
+func checkContactField(errs *validation.Errors, field, value string, max int) {
+	switch {
+	case value == "":
+		errs.Add(field, validation.CodeRequired, field+" is required")
+	case utf8.RuneCountInString(value) > max:
+		errs.Add(field, validation.CodeTooLong, field+" is too long")
+	}
 }
@@ -7,13 +7,13 @@ import (
 	"x86trade_backend/internal/repository"
 )
 
-func GetDeliveryMethodsHandler(w http.ResponseWriter, r *http.Request) {
+func GetDeliveryMethodsHandler(w http.ResponseWriter, r *http.Request) error {
 	methods, err := repository.GetDeliveryMethods(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	// Convert sql.Null* to simple JSON fields (we can reuse structs but simplest: encode directly)
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(methods)
+	return nil
 }
@@ -0,0 +1,41 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"mime"
+	"net/http"
+	"path"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/storage"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// GetPublicFileHandler — GET /api/files/*: отдаёт публичные файлы из хранилища
+// (изображения товаров, фотографии отзывов). Ключи случайные и не переиспользуются, поэтому кэш — навсегда.
+func GetPublicFileHandler(w http.ResponseWriter, r *http.Request) error {
+	key := chi.URLParam(r, "*")
+	if !storage.IsPublic(key) {
+		return apperr.NotFound("file not found")
+	}
+	f, err := storage.Files.Open(r.Context(), key)
+	if errors.Is(err, storage.ErrNotFound) || errors.Is(err, storage.ErrInvalidKey) {
+		return apperr.NotFound("file not found")
+	}
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	if ct := mime.TypeByExtension(path.Ext(key)); ct != "" {
+		w.Header().Set("Content-Type", ct)
+	} else {
+		w.Header().Set("Content-Type", "application/octet-stream")
+	}
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	_, _ = io.Copy(w, f)
+	return nil
+}
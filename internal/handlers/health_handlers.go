@@ -0,0 +1,80 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/logging"
+)
+
+// readyCheckTimeout ограничивает проверку готовности, чтобы зависшая БД не подвешивала пробу.
+const readyCheckTimeout = 2 * time.Second
+
+// draining выставляется при получении SIGTERM: /readyz начинает отвечать 503, балансировщик
+// снимает инстанс, а уже принятые запросы дорабатывают.
+var draining atomic.Bool
+
+// SetDraining переключает /readyz в режим "не готов" на время остановки.
+func SetDraining() {
+	draining.Store(true)
+}
+
+// HealthzHandler — liveness: процесс жив и обслуживает HTTP. Зависимости не проверяет,
+// чтобы недоступность БД не приводила к перезапуску контейнера.
+func HealthzHandler(w http.ResponseWriter, r *http.Request) {
+	writeHealth(w, http.StatusOK, map[string]interface{}{"status": "ok"})
+}
+
+// ReadyzHandler — readiness: БД отвечает на ping и все миграции применены.
+func ReadyzHandler(w http.ResponseWriter, r *http.Request) {
+	checks := map[string]string{}
+	ready := true
+
+	if draining.Load() {
+		writeHealth(w, http.StatusServiceUnavailable, map[string]interface{}{
+			"status": "shutting_down",
+		})
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), readyCheckTimeout)
+	defer cancel()
+
+	if err := db.DB.PingContext(ctx); err != nil {
+		ready = false
+		checks["database"] = "unavailable"
+		logging.FromContext(r.Context()).Warn("readiness: db ping failed", "error", err)
+	} else {
+		checks["database"] = "ok"
+
+		pending, err := db.PendingMigrations(ctx)
+		switch {
+		case err != nil:
+			ready = false
+			checks["migrations"] = "unknown"
+			logging.FromContext(r.Context()).Warn("readiness: migrations check failed", "error", err)
+		case len(pending) > 0:
+			ready = false
+			checks["migrations"] = "pending"
+		default:
+			checks["migrations"] = "ok"
+		}
+	}
+
+	status, code := "ok", http.StatusOK
+	if !ready {
+		status, code = "unavailable", http.StatusServiceUnavailable
+	}
+	writeHealth(w, code, map[string]interface{}{"status": status, "checks": checks})
+}
+
+func writeHealth(w http.ResponseWriter, code int, body map[string]interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "no-store")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(body)
+}
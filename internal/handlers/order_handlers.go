@@ -4,85 +4,110 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
-	"log"
 	"net/http"
 	"strconv"
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/metrics"
 	"x86trade_backend/internal/middleware"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
 
 	"github.com/go-chi/chi/v5"
 )
 
-func CreateOrderHandler(w http.ResponseWriter, r *http.Request) {
+func CreateOrderHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	var payload models.CreateOrderPayload
 	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
-		http.Error(w, "bad request", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("request body must be valid JSON")
 	}
+	// Сохранённый адрес копируется в заказ: последующие правки адресной книги историю не меняют
+	if payload.AddressID != nil {
+		addr, err := repository.GetUserAddress(r.Context(), userID, *payload.AddressID)
+		if err != nil {
+			return err
+		}
+		if addr == nil {
+			return validation.Errors{{
+				Field: "address_id", Code: validation.CodeNotFound, Message: "address does not exist",
+			}}
+		}
+		payload.Address = addr.Address
+		payload.RecipientName = addr.RecipientName
+		payload.RecipientPhone = addr.RecipientPhone
+	}
+
+	errs, err := validateCreateOrder(r.Context(), &payload)
+	if err != nil {
+		return err
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
 	orderID, err := repository.CreateOrderFromCart(r.Context(), userID, payload.DeliveryMethodID, payload.Address, payload.RecipientName, payload.RecipientPhone, payload.Comment)
 	if err != nil {
-		http.Error(w, "internal server error: "+err.Error(), http.StatusInternalServerError)
-		return
+		if errors.Is(err, repository.ErrCartEmpty) {
+			return apperr.New(http.StatusUnprocessableEntity, "cart_empty", "cart is empty")
+		}
+		// текст ошибки БД клиенту не отдаётся: apperr логирует причину и отвечает internal_error
+		return err
 	}
+	metrics.OrdersCreated.Inc()
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]interface{}{"order_id": orderID})
+	return nil
 }
 
-func GetOrdersHandler(w http.ResponseWriter, r *http.Request) {
+func GetOrdersHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	orders, err := repository.GetOrdersByUserID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(orders)
+	return nil
 }
 
-func GetOrderHandler(w http.ResponseWriter, r *http.Request) {
+func GetOrderHandler(w http.ResponseWriter, r *http.Request) error {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	ord, items, err := repository.GetOrderWithItems(r.Context(), id)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if ord == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(map[string]interface{}{"order": ord, "items": items})
+	return nil
 }
 
-func GetOrdersWithItemsHandler(w http.ResponseWriter, r *http.Request) {
+func GetOrdersWithItemsHandler(w http.ResponseWriter, r *http.Request) error {
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 
 	// Получаем все заказы пользователя
 	orders, err := repository.GetOrdersByUserID(r.Context(), userID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// Для каждого заказа получаем детали
@@ -90,7 +115,7 @@ func GetOrdersWithItemsHandler(w http.ResponseWriter, r *http.Request) {
 	for _, order := range orders {
 		_, items, err := repository.GetOrderWithItems(r.Context(), order.ID)
 		if err != nil {
-			log.Printf("Error getting items for order %d: %v", order.ID, err)
+			logging.FromContext(r.Context()).Warn("get order items failed", "order_id", order.ID, "error", err)
 			continue
 		}
 
@@ -116,14 +141,14 @@ func GetOrdersWithItemsHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(ordersWithItems)
+	return nil
 }
 
-func CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
+func CancelOrderHandler(w http.ResponseWriter, r *http.Request) error {
 	orderIDStr := chi.URLParam(r, "orderID") // Используем chi.URLParam
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil || orderID <= 0 {
-		http.Error(w, "bad request: invalid order id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid order id")
 	}
 
 	// Получаем текущий заказ
@@ -132,39 +157,31 @@ func CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
 	err = row.Scan(&ord.ID, &ord.UserID, &ord.Status)
 	if err != nil {
 		if errors.Is(err, sql.ErrNoRows) {
-			http.Error(w, "not found", http.StatusNotFound)
-			return
+			return apperr.NotFound("not found")
 		}
-		log.Printf("Error fetching order: %v", err) // Логируем ошибку
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	// Проверяем, что пользователь может отменить этот заказ
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 
 	if ord.UserID != userID {
-		http.Error(w, "forbidden", http.StatusForbidden)
-		return
+		return apperr.Forbidden("forbidden")
 	}
 
 	// Проверяем, можно ли отменить заказ (только если статус 'created' или 'processing')
 	if ord.Status != "created" && ord.Status != "processing" {
-		http.Error(w, "bad request: cannot cancel order in current status", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("cannot cancel order in current status")
 	}
 
 	// Обновляем статус заказа на 'cancelled'
-	_, err = db.DB.ExecContext(r.Context(), `UPDATE orders SET status='cancelled', updated_at=NOW() WHERE id=$1`, orderID)
-	if err != nil {
-		log.Printf("Error updating order status: %v", err) // Логируем ошибку
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	if err := repository.UpdateOrderStatus(r.Context(), orderID, "cancelled"); err != nil {
+		return err
 	}
+	metrics.OrdersCancelled.Inc("customer")
 
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
@@ -173,36 +190,32 @@ func CancelOrderHandler(w http.ResponseWriter, r *http.Request) {
 		"order_id":   orderID,
 		"new_status": "cancelled",
 	})
+	return nil
 }
 
-func GetOrderDetailsHandler(w http.ResponseWriter, r *http.Request) {
+func GetOrderDetailsHandler(w http.ResponseWriter, r *http.Request) error {
 	orderIDStr := chi.URLParam(r, "id")
 	orderID, err := strconv.Atoi(orderIDStr)
 	if err != nil || orderID <= 0 {
-		http.Error(w, "bad request: invalid order id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid order id")
 	}
 
 	// Получаем заказ, товары и информацию о доставке
 	ord, items, err := repository.GetOrderWithItems(r.Context(), orderID)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if ord == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 
 	// Проверяем, что пользователь имеет доступ к этому заказу
 	userID, ok := middleware.UserIDFromContext(r.Context())
 	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+		return apperr.Unauthorized("unauthorized")
 	}
 	if ord.UserID != userID {
-		http.Error(w, "forbidden", http.StatusForbidden)
-		return
+		return apperr.Forbidden("forbidden")
 	}
 
 	// Получаем информацию о доставке
@@ -217,10 +230,18 @@ func GetOrderDetailsHandler(w http.ResponseWriter, r *http.Request) {
 		}
 	}
 
+	// Отправления с хронологией трекинга
+	shipments, err := repository.GetOrderShipments(r.Context(), orderID)
+	if err != nil {
+		logging.FromContext(r.Context()).Warn("get shipments failed", "order_id", orderID, "error", err)
+		shipments = []models.Shipment{}
+	}
+
 	// Формируем ответ
 	response := map[string]interface{}{
-		"order": ord,
-		"items": items,
+		"order":     ord,
+		"items":     items,
+		"shipments": shipments,
 	}
 
 	if deliveryInfo != nil {
@@ -229,4 +250,39 @@ func GetOrderDetailsHandler(w http.ResponseWriter, r *http.Request) {
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(response)
+	return nil
+}
+
+// ReorderHandler — POST /api/orders/{id}/reorder: собирает корзину из позиций прошлого заказа
+// по текущим ценам и возвращает сводку: что добавлено, что урезано по остатку, что пропущено.
+func ReorderHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || orderID <= 0 {
+		return apperr.BadRequest("invalid order id")
+	}
+
+	ord, _, err := repository.GetOrderWithItems(r.Context(), orderID)
+	if err != nil {
+		return err
+	}
+	if ord == nil {
+		return apperr.NotFound("not found")
+	}
+	if ord.UserID != userID {
+		return apperr.Forbidden("forbidden")
+	}
+
+	summary, err := repository.ReorderToCart(r.Context(), userID, orderID)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(summary)
+	return nil
 }
@@ -0,0 +1,71 @@
+package handlers
+
+import (
+	"context"
+	"strings"
+	"unicode/utf8"
+
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/validation"
+)
+
+const (
+	maxOrderCommentLen = 1000
+	maxAddressLen      = 500
+	maxRecipientLen    = 255
+)
+
+// validateCreateOrder проверяет данные оформления заказа. Сохранённый адрес (address_id)
+// к этому моменту уже подставлен в payload. Ошибки БД возвращаются отдельно от ошибок полей.
+func validateCreateOrder(ctx context.Context, p *models.CreateOrderPayload) (validation.Errors, error) {
+	var errs validation.Errors
+
+	p.Address = strings.TrimSpace(p.Address)
+	p.RecipientName = strings.TrimSpace(p.RecipientName)
+	p.RecipientPhone = strings.TrimSpace(p.RecipientPhone)
+	p.Comment = strings.TrimSpace(p.Comment)
+
+	requiresAddress := false
+	if p.DeliveryMethodID != nil {
+		method, err := repository.GetDeliveryMethodByID(ctx, *p.DeliveryMethodID)
+		if err != nil {
+			return nil, err
+		}
+		if method == nil {
+			errs.Add("delivery_method_id", validation.CodeNotFound, "delivery method does not exist")
+		} else {
+			requiresAddress = method.RequiresAddress
+		}
+	} else if p.Address != "" {
+		// раньше адрес без способа доставки молча игнорировался
+		errs.Add("delivery_method_id", validation.CodeRequired, "delivery method is required when address is provided")
+	}
+
+	if requiresAddress {
+		if p.Address == "" {
+			errs.Add("address", validation.CodeRequired, "address is required for this delivery method")
+		}
+		if p.RecipientName == "" {
+			errs.Add("recipient_name", validation.CodeRequired, "recipient name is required for this delivery method")
+		}
+		if p.RecipientPhone == "" {
+			errs.Add("recipient_phone", validation.CodeRequired, "recipient phone is required for this delivery method")
+		}
+	}
+
+	if utf8.RuneCountInString(p.Address) > maxAddressLen {
+		errs.Add("address", validation.CodeTooLong, "address is too long")
+	}
+	if utf8.RuneCountInString(p.RecipientName) > maxRecipientLen {
+		errs.Add("recipient_name", validation.CodeTooLong, "recipient name is too long")
+	}
+	if p.RecipientPhone != "" && !validation.IsValidPhone(p.RecipientPhone) {
+		errs.Add("recipient_phone", validation.CodeInvalidFormat, "phone must contain 10 to 15 digits, optionally starting with +")
+	}
+	if utf8.RuneCountInString(p.Comment) > maxOrderCommentLen {
+		errs.Add("comment", validation.CodeTooLong, "comment must be at most 1000 characters")
+	}
+
+	return errs, nil
+}
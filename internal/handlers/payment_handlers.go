@@ -6,12 +6,12 @@ import (
 	"x86trade_backend/internal/repository"
 )
 
-func GetPaymentMethodsHandler(w http.ResponseWriter, r *http.Request) {
+func GetPaymentMethodsHandler(w http.ResponseWriter, r *http.Request) error {
 	methods, err := repository.GetPaymentMethods(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(methods)
+	return nil
 }
@@ -3,12 +3,29 @@ package handlers
 import (
 	"context"
 	"encoding/json"
+	"errors"
 	"net/http"
+	"net/url"
+	"sort"
 	"strconv"
 	"strings"
 
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/cursor"
+	"x86trade_backend/internal/logging"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/validation"
+)
+
+// maxCharacteristicFilters — сколько характеристик можно указать в одном запросе каталога.
+const maxCharacteristicFilters = 20
+
+// Размер страницы каталога: по умолчанию и верхняя граница (больший limit урезается).
+const (
+	defaultProductsLimit = 24
+	maxProductsLimit     = 100
 )
 
 func parseIntPtr(s string) (*int, error) {
@@ -33,11 +50,10 @@ func parseFloatPtr(s string) (*float64, error) {
 	return &f, nil
 }
 
-func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
+func GetProductsHandler(w http.ResponseWriter, r *http.Request) error {
 	// если указан id — вернуть единичный ресурс
 	if idStr := r.URL.Query().Get("id"); idStr != "" {
-		GetProductHandler(w, r)
-		return
+		return GetProductHandler(w, r)
 	}
 
 	q := r.URL.Query()
@@ -71,95 +87,254 @@ func GetProductsHandler(w http.ResponseWriter, r *http.Request) {
 			filter.MaxPrice = &f
 		}
 	}
+	if mr := q.Get("min_rating"); mr != "" {
+		if f, err := strconv.ParseFloat(mr, 64); err == nil && f > 0 {
+			filter.MinRating = &f
+		}
+	}
 	if search := q.Get("q"); search != "" {
 		s := strings.TrimSpace(search)
 		if s != "" {
 			filter.Q = &s
 		}
 	}
+	filter.Limit = defaultProductsLimit
 	if l := q.Get("limit"); l != "" {
-		if v, err := strconv.Atoi(l); err == nil {
-			filter.Limit = v
+		if v, err := strconv.Atoi(l); err == nil && v > 0 {
+			filter.Limit = min(v, maxProductsLimit)
 		}
 	}
 	if o := q.Get("offset"); o != "" {
-		if v, err := strconv.Atoi(o); err == nil {
+		if v, err := strconv.Atoi(o); err == nil && v > 0 {
 			filter.Offset = v
 		}
 	}
 
-	products, err := repository.GetProducts(r.Context(), filter)
+	charFilters, errs := parseCharacteristicFilters(q)
+	// ?cursor= (пустой — первая страница) включает курсорную пагинацию вместо offset
+	if q.Has("cursor") {
+		filter.Keyset = true
+		filter.Offset = 0
+		if c := q.Get("cursor"); c != "" {
+			cur, err := cursor.Decode(c)
+			if err != nil {
+				errs.Add("cursor", validation.CodeInvalidFormat, "invalid cursor")
+			} else {
+				filter.Cursor = cur
+				// страницы нечёткого поиска продолжаем нечётким поиском
+				filter.Fuzzy = cur.Sort == repository.SortSimilarity
+			}
+		}
+	}
+	if s := q.Get("sort"); s != "" {
+		if !repository.IsProductSort(s) {
+			errs.Add("sort", validation.CodeNotAllowed, "unknown sort")
+		} else {
+			filter.Sort = s
+		}
+	}
+	if len(charFilters) > 0 {
+		keys := make([]string, len(charFilters))
+		for i, cf := range charFilters {
+			keys[i] = cf.Key
+		}
+		resolved, err := repository.ResolveCharacteristicKeys(r.Context(), keys)
+		if err != nil {
+			return err
+		}
+		for i := range charFilters {
+			ids, ok := resolved[charFilters[i].Key]
+			if !ok {
+				errs.Add("char["+charFilters[i].Key+"]", validation.CodeNotFound, "unknown characteristic")
+				continue
+			}
+			charFilters[i].TypeIDs = ids
+		}
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+	filter.Characteristics = charFilters
+
+	products, page, err := repository.GetProducts(r.Context(), filter)
+	if errors.Is(err, cursor.ErrInvalid) {
+		return validation.Errors{{Field: "cursor", Code: validation.CodeInvalidFormat, Message: "cursor does not match the query"}}
+	}
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
+	}
+	// полнотекстовый поиск ничего не нашёл — пробуем нечёткий (опечатки в названии или SKU)
+	searchMode := ""
+	if filter.Q != nil {
+		searchMode = "fulltext"
+		if filter.Fuzzy {
+			searchMode = "fuzzy"
+		} else if len(products) == 0 && filter.Offset == 0 && filter.Cursor == nil {
+			filter.Fuzzy = true
+			searchMode = "fuzzy"
+			if products, page, err = repository.GetProducts(r.Context(), filter); err != nil {
+				return err
+			}
+		}
+		w.Header().Set("X-Search-Mode", searchMode)
+	}
+	total, err := repository.CountProducts(r.Context(), filter)
+	if err != nil {
+		return err
+	}
+	if products == nil {
+		products = []models.Product{}
+	}
+	resp := map[string]interface{}{
+		"items": products,
+		"total": total,
+		"limit": filter.Limit,
 	}
+	if filter.Keyset {
+		// пустая строка — соседней страницы нет
+		resp["next_cursor"] = page.Next
+		resp["prev_cursor"] = page.Prev
+	} else {
+		resp["offset"] = filter.Offset
+	}
+	if searchMode != "" {
+		resp["search_mode"] = searchMode
+	}
+
+	// ?facets=true — дополнительно количество товаров по значениям характеристик
+	if withFacets, _ := strconv.ParseBool(q.Get("facets")); withFacets {
+		facets, err := repository.GetProductFacets(r.Context(), filter)
+		if err != nil {
+			return err
+		}
+		resp["facets"] = facets
+	}
+
 	w.Header().Set("Content-Type", "application/json")
-	json.NewEncoder(w).Encode(products)
+	json.NewEncoder(w).Encode(resp)
+	return nil
 }
 
-func GetProductHandler(w http.ResponseWriter, r *http.Request) {
+// parseCharacteristicFilters разбирает фильтры по характеристикам вида
+// char[socket]=AM5 (несколько значений — через запятую или повтором параметра, логика ИЛИ)
+// и char[cores]=8..16 (числовой диапазон, границы включительно; 8.. и ..16 — открытые).
+// Разные характеристики объединяются по И. Ключи ещё не сопоставлены с типами (TypeIDs пуст).
+func parseCharacteristicFilters(q url.Values) ([]repository.CharacteristicFilter, validation.Errors) {
+	var out []repository.CharacteristicFilter
+	var errs validation.Errors
+
+	params := make([]string, 0, len(q))
+	for param := range q {
+		params = append(params, param)
+	}
+	sort.Strings(params) // стабильный порядок — стабильный SQL
+
+	for _, param := range params {
+		if !strings.HasPrefix(param, "char[") || !strings.HasSuffix(param, "]") {
+			continue
+		}
+		key := strings.TrimSpace(param[len("char[") : len(param)-1])
+		if key == "" {
+			errs.Add(param, validation.CodeInvalidFormat, "characteristic key is empty")
+			continue
+		}
+		cf := repository.CharacteristicFilter{Key: key}
+		for _, raw := range q[param] {
+			if lo, hi, isRange := strings.Cut(raw, ".."); isRange {
+				min, err1 := parseFloatPtr(lo)
+				max, err2 := parseFloatPtr(hi)
+				if err1 != nil || err2 != nil || (min == nil && max == nil) || cf.Min != nil || cf.Max != nil {
+					errs.Add(param, validation.CodeInvalidFormat, "range must look like 8..16, 8.. or ..16")
+					continue
+				}
+				cf.Min, cf.Max = min, max
+				continue
+			}
+			for _, v := range strings.Split(raw, ",") {
+				if v = strings.TrimSpace(v); v != "" {
+					cf.Values = append(cf.Values, v)
+				}
+			}
+		}
+		if len(cf.Values) > 0 && (cf.Min != nil || cf.Max != nil) {
+			errs.Add(param, validation.CodeInvalidFormat, "use either values or a range, not both")
+			continue
+		}
+		if len(cf.Values) == 0 && cf.Min == nil && cf.Max == nil {
+			continue
+		}
+		if len(out) == maxCharacteristicFilters {
+			errs.Add(param, validation.CodeNotAllowed, "too many characteristic filters")
+			break
+		}
+		out = append(out, cf)
+	}
+	return out, errs
+}
+
+func GetProductHandler(w http.ResponseWriter, r *http.Request) error {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
 	p, err := repository.GetProductByID(context.Background(), id)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	if p == nil {
-		http.Error(w, "not found", http.StatusNotFound)
-		return
+		return apperr.NotFound("not found")
 	}
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(p)
+	return nil
 }
 
-func CreateProductHandler(w http.ResponseWriter, r *http.Request) {
+func CreateProductHandler(w http.ResponseWriter, r *http.Request) error {
 	var p models.Product
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	id, err := repository.CreateProduct(context.Background(), &p)
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
 }
 
-func UpdateProductHandler(w http.ResponseWriter, r *http.Request) {
+func UpdateProductHandler(w http.ResponseWriter, r *http.Request) error {
 	var p models.Product
 	if err := json.NewDecoder(r.Body).Decode(&p); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 	if p.ID == 0 {
-		http.Error(w, "bad request: id required", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("id required")
 	}
 	if err := repository.UpdateProduct(context.Background(), &p); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
 
-func DeleteProductHandler(w http.ResponseWriter, r *http.Request) {
+func DeleteProductHandler(w http.ResponseWriter, r *http.Request) error {
 	idStr := r.URL.Query().Get("id")
 	id, err := strconv.Atoi(idStr)
 	if err != nil || id <= 0 {
-		http.Error(w, "bad request: id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid id")
 	}
-	if err := repository.DeleteProduct(context.Background(), id); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+	fileKeys, err := repository.DeleteProduct(context.Background(), id)
+	if err != nil {
+		return err
+	}
+	for _, key := range fileKeys {
+		if err := storage.Files.Delete(r.Context(), key); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete product file", "key", key, "error", err)
+		}
 	}
 	w.WriteHeader(http.StatusNoContent)
+	return nil
 }
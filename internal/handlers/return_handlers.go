@@ -0,0 +1,97 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/middleware"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// CreateReturnHandler — POST /api/orders/{id}/returns: покупатель открывает возврат по позиции доставленного заказа.
+func CreateReturnHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	orderID, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || orderID <= 0 {
+		return apperr.BadRequest("invalid order id")
+	}
+
+	var payload models.CreateReturnPayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	payload.Reason = strings.TrimSpace(payload.Reason)
+	if payload.OrderItemID <= 0 || payload.Quantity <= 0 || payload.Reason == "" {
+		return apperr.BadRequest("order_item_id, positive quantity and reason required")
+	}
+
+	id, err := repository.CreateReturn(r.Context(), userID, orderID, &payload)
+	if err != nil {
+		switch {
+		case errors.Is(err, repository.ErrOrderNotFound):
+			return apperr.NotFound("order not found").WithCause(err)
+		case errors.Is(err, repository.ErrReturnOrderNotAllowed):
+			return apperr.BadRequest("order is not eligible for return").WithCause(err)
+		case errors.Is(err, repository.ErrOrderItemNotFound):
+			return apperr.NotFound("order item not found").WithCause(err)
+		case errors.Is(err, repository.ErrReturnQuantity):
+			return apperr.BadRequest("quantity exceeds returnable quantity").WithCause(err)
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]int{"id": id})
+	return nil
+}
+
+// GetMyReturnsHandler — GET /api/returns: заявки текущего пользователя.
+func GetMyReturnsHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	list, err := repository.GetReturns(r.Context(), &userID, r.URL.Query().Get("status"))
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(list)
+	return nil
+}
+
+// GetMyReturnHandler — GET /api/returns/{id}: заявка с историей статусов.
+func GetMyReturnHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, err := strconv.Atoi(chi.URLParam(r, "id"))
+	if err != nil || id <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+	rr, err := repository.GetReturnByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if rr == nil {
+		return apperr.NotFound("not found")
+	}
+	if rr.UserID != userID {
+		return apperr.Forbidden("forbidden")
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(rr)
+	return nil
+}
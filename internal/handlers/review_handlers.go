@@ -1,44 +1,78 @@
 package handlers
 
 import (
+	"bytes"
+	"database/sql"
 	"encoding/json"
+	"errors"
+	"io"
 	"net/http"
+	"strconv"
+	"unicode/utf8"
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/imaging"
+	"x86trade_backend/internal/logging"
 	"x86trade_backend/internal/middleware"
 	"x86trade_backend/internal/models"
 	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/validation"
+
+	"github.com/go-chi/chi/v5"
 )
 
-func CreateReviewHandler(w http.ResponseWriter, r *http.Request) {
-	userID, ok := middleware.UserIDFromContext(r.Context())
-	if !ok {
-		http.Error(w, "unauthorized", http.StatusUnauthorized)
-		return
+// Страница отзывов товара: по умолчанию и верхняя граница limit.
+const (
+	defaultReviewsLimit = 10
+	maxReviewsLimit     = 50
+)
+
+// Лимиты фотографий к отзывам; задаются при старте (ConfigureReviewImages).
+var (
+	maxReviewImages       = 5
+	maxImageSize    int64 = 5 << 20
+)
+
+// ConfigureReviewImages задаёт, сколько фотографий можно прикрепить к отзыву и их предельный размер.
+func ConfigureReviewImages(maxImages int, maxImageBytes int64) {
+	maxReviewImages = maxImages
+	maxImageSize = maxImageBytes
+}
+
+// reviewRequest — тело создания и правки отзыва.
+type reviewRequest struct {
+	ProductID int    `json:"product_id"`
+	Rating    int    `json:"rating"`
+	Comment   string `json:"comment"`
+}
+
+func (req *reviewRequest) validate() error {
+	if req.Rating < 1 || req.Rating > 5 {
+		return apperr.BadRequest("rating must be between 1 and 5")
 	}
+	if utf8.RuneCountInString(req.Comment) < 10 {
+		return apperr.BadRequest("comment must be at least 10 characters")
+	}
+	return nil
+}
 
-	var req struct {
-		ProductID int    `json:"product_id"`
-		Rating    int    `json:"rating"`
-		Comment   string `json:"comment"`
+// CreateReviewHandler — отзыв уходит на модерацию; на товар можно оставить один отзыв.
+func CreateReviewHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
 	}
 
+	var req reviewRequest
 	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-		http.Error(w, "bad request: invalid json", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid request body")
 	}
 
 	if req.ProductID <= 0 {
-		http.Error(w, "bad request: invalid product id", http.StatusBadRequest)
-		return
+		return apperr.BadRequest("invalid product id")
 	}
-
-	if req.Rating < 1 || req.Rating > 5 {
-		http.Error(w, "bad request: rating must be between 1 and 5", http.StatusBadRequest)
-		return
-	}
-
-	if len(req.Comment) < 10 {
-		http.Error(w, "bad request: comment must be at least 10 characters", http.StatusBadRequest)
-		return
+	if err := req.validate(); err != nil {
+		return err
 	}
 
 	review := &models.Review{
@@ -49,12 +83,375 @@ func CreateReviewHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	if err := repository.CreateReview(r.Context(), review); err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		if errors.Is(err, repository.ErrReviewExists) {
+			return apperr.Conflict("you have already reviewed this product, edit your review instead")
+		}
+		return err
 	}
 
+	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusCreated)
-	json.NewEncoder(w).Encode(map[string]string{
-		"message": "Review created successfully",
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"message": "Review submitted for moderation",
+		"review":  review,
 	})
+	return nil
+}
+
+// GetMyReviewsHandler — отзывы текущего пользователя со статусами модерации.
+func GetMyReviewsHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	reviews, err := repository.GetUserReviews(r.Context(), userID)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(reviews)
+	return nil
+}
+
+// UpdateMyReviewHandler — правка своего отзыва; после правки отзыв снова проходит модерацию.
+func UpdateMyReviewHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid review id")
+	}
+
+	var req reviewRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if err := req.validate(); err != nil {
+		return err
+	}
+
+	existing, err := repository.GetReviewByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if existing == nil || existing.UserID != userID {
+		return apperr.NotFound("review not found")
+	}
+
+	existing.Rating = req.Rating
+	existing.Comment = req.Comment
+	if err := repository.UpdateReview(r.Context(), existing); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("review not found")
+		}
+		if errors.Is(err, repository.ErrReviewExists) {
+			return apperr.Conflict("you already have another review of this product")
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(existing)
+	return nil
+}
+
+// DeleteMyReviewHandler — удаление своего отзыва.
+func DeleteMyReviewHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid review id")
+	}
+
+	imageKeys, err := repository.DeleteReview(r.Context(), id, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("review not found")
+		}
+		return err
+	}
+	for _, key := range imageKeys {
+		if err := storage.Files.Delete(r.Context(), key); err != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete review image", "key", key, "error", err)
+		}
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+// GetProductReviewsHandler — GET /api/products/{id}/reviews
+// Опубликованные отзывы с пагинацией: ?sort=newest|helpful|rating_desc|rating_asc,
+// ?rating=1..5, ?limit=, ?offset=.
+func GetProductReviewsHandler(w http.ResponseWriter, r *http.Request) error {
+	productID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if productID <= 0 {
+		return apperr.BadRequest("invalid product id")
+	}
+	q := r.URL.Query()
+
+	f := repository.ReviewFilter{ProductID: productID, Sort: repository.ReviewSortNewest, Limit: defaultReviewsLimit}
+	if l, err := strconv.Atoi(q.Get("limit")); err == nil && l > 0 {
+		f.Limit = min(l, maxReviewsLimit)
+	}
+	if o, err := strconv.Atoi(q.Get("offset")); err == nil && o > 0 {
+		f.Offset = o
+	}
+
+	var errs validation.Errors
+	if s := q.Get("sort"); s != "" {
+		if !repository.IsReviewSort(s) {
+			errs.Add("sort", validation.CodeNotAllowed, "sort must be newest, helpful, rating_desc or rating_asc")
+		}
+		f.Sort = s
+	}
+	if s := q.Get("rating"); s != "" {
+		v, err := strconv.Atoi(s)
+		if err != nil || v < 1 || v > 5 {
+			errs.Add("rating", validation.CodeInvalidFormat, "rating must be between 1 and 5")
+		}
+		f.Rating = &v
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	product, err := repository.GetProductByID(r.Context(), productID)
+	if err != nil {
+		return err
+	}
+	if product == nil {
+		return apperr.NotFound("product not found")
+	}
+
+	reviews, err := repository.GetProductReviews(r.Context(), f)
+	if err != nil {
+		return err
+	}
+	total, err := repository.CountProductReviews(r.Context(), f)
+	if err != nil {
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"items":  reviews,
+		"total":  total,
+		"limit":  f.Limit,
+		"offset": f.Offset,
+	})
+	return nil
+}
+
+// VoteReviewHandler — PUT /api/reviews/{id}/vote {"helpful": true|false}.
+// Один голос на отзыв: повторный заменяет прежний. За свой отзыв голосовать нельзя.
+func VoteReviewHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid review id")
+	}
+
+	var payload models.ReviewVotePayload
+	if err := json.NewDecoder(r.Body).Decode(&payload); err != nil {
+		return apperr.BadRequest("invalid request body")
+	}
+	if payload.Helpful == nil {
+		return validation.Errors{{Field: "helpful", Code: validation.CodeRequired, Message: "helpful is required"}}
+	}
+
+	review, err := repository.VoteReview(r.Context(), id, userID, *payload.Helpful)
+	if err != nil {
+		return reviewVoteError(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+	return nil
+}
+
+// DeleteReviewVoteHandler — DELETE /api/reviews/{id}/vote: отозвать свой голос.
+func DeleteReviewVoteHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid review id")
+	}
+
+	review, err := repository.DeleteReviewVote(r.Context(), id, userID)
+	if err != nil {
+		return reviewVoteError(err)
+	}
+	w.Header().Set("Content-Type", "application/json")
+	json.NewEncoder(w).Encode(review)
+	return nil
+}
+
+func reviewVoteError(err error) error {
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		return apperr.NotFound("review not found")
+	case errors.Is(err, repository.ErrOwnReviewVote):
+		return apperr.Conflict("you cannot vote for your own review")
+	}
+	return err
+}
+
+// UploadReviewImagesHandler — POST /api/reviews/{id}/images, multipart/form-data с одним
+// или несколькими файлами images (JPEG, PNG или WebP). Только автор отзыва; опубликованный
+// отзыв с новыми фотографиями снова проходит модерацию.
+func UploadReviewImagesHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if id <= 0 {
+		return apperr.BadRequest("invalid review id")
+	}
+	if maxReviewImages == 0 {
+		return apperr.New(http.StatusForbidden, "review_images_disabled", "review images are disabled")
+	}
+
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxReviewImages)*maxImageSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return imageTooLarge()
+		}
+		return apperr.BadRequest("expected multipart/form-data body")
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	review, err := repository.GetReviewByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	if review == nil || review.UserID != userID {
+		return apperr.NotFound("review not found")
+	}
+
+	headers := r.MultipartForm.File["images"]
+	var errs validation.Errors
+	switch {
+	case len(headers) == 0:
+		errs.Add("images", validation.CodeRequired, "at least one image is required")
+	case len(review.Images)+len(headers) > maxReviewImages:
+		errs.Add("images", validation.CodeNotAllowed,
+			"a review can have at most "+strconv.Itoa(maxReviewImages)+" images")
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	images := make([]models.ReviewImage, 0, len(headers))
+	datas := make([][]byte, 0, len(headers))
+	for i, h := range headers {
+		if h.Size > maxImageSize {
+			return imageTooLarge()
+		}
+		f, err := h.Open()
+		if err != nil {
+			return apperr.BadRequest("invalid image file")
+		}
+		data, err := io.ReadAll(io.LimitReader(f, maxImageSize+1))
+		f.Close()
+		if err != nil {
+			return err
+		}
+		if int64(len(data)) > maxImageSize {
+			return imageTooLarge()
+		}
+		ct, ext, ok := imaging.Detect(data)
+		if !ok {
+			errs.Add("images["+strconv.Itoa(i)+"]", validation.CodeNotAllowed, "image must be JPEG, PNG or WebP")
+			continue
+		}
+		images = append(images, models.ReviewImage{
+			Key:         storage.NewKey("reviews", ext),
+			ContentType: ct,
+			Size:        int64(len(data)),
+		})
+		datas = append(datas, data)
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	// файлы кладём до записи в БД; если запись не удалась — убираем их
+	stored := 0
+	cleanup := func() {
+		for _, img := range images[:stored] {
+			if err := storage.Files.Delete(r.Context(), img.Key); err != nil {
+				logging.FromContext(r.Context()).Warn("failed to delete orphaned review image", "key", img.Key, "error", err)
+			}
+		}
+	}
+	for i, img := range images {
+		if err := storage.Files.Put(r.Context(), img.Key, bytes.NewReader(datas[i]), img.Size, img.ContentType); err != nil {
+			cleanup()
+			return err
+		}
+		stored++
+	}
+	if err := repository.AddReviewImages(r.Context(), id, userID, images, maxReviewImages); err != nil {
+		cleanup()
+		switch {
+		case errors.Is(err, sql.ErrNoRows):
+			return apperr.NotFound("review not found")
+		case errors.Is(err, repository.ErrTooManyReviewImages):
+			return validation.Errors{{Field: "images", Code: validation.CodeNotAllowed,
+				Message: "a review can have at most " + strconv.Itoa(maxReviewImages) + " images"}}
+		}
+		return err
+	}
+
+	review, err = repository.GetReviewByID(r.Context(), id)
+	if err != nil {
+		return err
+	}
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(review)
+	return nil
+}
+
+// DeleteReviewImageHandler — DELETE /api/reviews/{id}/images/{imageID}: только автор отзыва.
+func DeleteReviewImageHandler(w http.ResponseWriter, r *http.Request) error {
+	userID, ok := middleware.UserIDFromContext(r.Context())
+	if !ok {
+		return apperr.Unauthorized("unauthorized")
+	}
+	id, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	imageID, _ := strconv.Atoi(chi.URLParam(r, "imageID"))
+	if id <= 0 || imageID <= 0 {
+		return apperr.BadRequest("invalid id")
+	}
+
+	key, err := repository.DeleteReviewImage(r.Context(), id, imageID, userID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return apperr.NotFound("image not found")
+		}
+		return err
+	}
+	if err := storage.Files.Delete(r.Context(), key); err != nil {
+		logging.FromContext(r.Context()).Warn("failed to delete review image", "key", key, "error", err)
+	}
+	w.WriteHeader(http.StatusNoContent)
+	return nil
+}
+
+func imageTooLarge() error {
+	return apperr.New(http.StatusRequestEntityTooLarge, "file_too_large",
+		"each image must not exceed "+strconv.FormatInt(maxImageSize>>20, 10)+" MB")
 }
@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+)
+
+const (
+	suggestMinQueryLen     = 2
+	suggestMaxQueryLen     = 100
+	suggestDefaultPerGroup = 5
+	suggestMaxPerGroup     = 10
+	// suggestTimeout — бюджет на запрос подсказок: строка поиска дёргает endpoint на каждый ввод,
+	// медленный ответ уже никому не нужен.
+	suggestTimeout = 300 * time.Millisecond
+)
+
+// SearchSuggestHandler — GET /api/search/suggest?q=&limit=
+// Подсказки для строки поиска: товары (название, SKU, картинка), категории и производители,
+// не более limit в каждой группе. Короткий запрос или превышение бюджета — пустой список.
+func SearchSuggestHandler(w http.ResponseWriter, r *http.Request) error {
+	q := strings.Join(strings.Fields(r.URL.Query().Get("q")), " ")
+	if utf8.RuneCountInString(q) > suggestMaxQueryLen {
+		q = string([]rune(q)[:suggestMaxQueryLen])
+	}
+
+	perGroup := suggestDefaultPerGroup
+	if l, err := strconv.Atoi(r.URL.Query().Get("limit")); err == nil && l > 0 {
+		perGroup = min(l, suggestMaxPerGroup)
+	}
+
+	items := []models.SearchSuggestion{}
+	if utf8.RuneCountInString(q) >= suggestMinQueryLen {
+		ctx, cancel := context.WithTimeout(r.Context(), suggestTimeout)
+		defer cancel()
+
+		found, err := repository.GetSearchSuggestions(ctx, q, perGroup)
+		switch {
+		case err == nil:
+			items = found
+		case ctx.Err() == context.DeadlineExceeded:
+			logging.FromContext(r.Context()).Warn("search suggest timed out", "timeout", suggestTimeout)
+		default:
+			return err
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Cache-Control", "public, max-age=60")
+	json.NewEncoder(w).Encode(map[string]interface{}{"items": items})
+	return nil
+}
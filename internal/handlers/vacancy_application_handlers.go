@@ -0,0 +1,198 @@
+package handlers
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/go-chi/chi/v5"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/repository"
+	"x86trade_backend/internal/storage"
+	"x86trade_backend/internal/validation"
+)
+
+const (
+	maxApplicantNameLen  = 255
+	maxApplicantEmailLen = 255
+	maxCoverLetterLen    = 5000
+	maxCVFilenameLen     = 255
+
+	contentTypePDF  = "application/pdf"
+	contentTypeDOCX = "application/vnd.openxmlformats-officedocument.wordprocessingml.document"
+)
+
+// maxCVSize — предельный размер резюме в байтах; задаётся при старте (ConfigureVacancyApplications).
+var maxCVSize int64 = 5 << 20
+
+// ConfigureVacancyApplications задаёт предельный размер файла резюме.
+func ConfigureVacancyApplications(maxCVBytes int64) {
+	maxCVSize = maxCVBytes
+}
+
+// CreateVacancyApplicationHandler — отклик на вакансию. multipart/form-data:
+// full_name, email, phone (необязательно), cover_letter (необязательно), cv — файл PDF или DOCX.
+func CreateVacancyApplicationHandler(w http.ResponseWriter, r *http.Request) error {
+	vacancyID, _ := strconv.Atoi(chi.URLParam(r, "id"))
+	if vacancyID <= 0 {
+		return apperr.BadRequest("invalid vacancy id")
+	}
+
+	// запас сверх размера файла — на текстовые поля и служебные части multipart
+	r.Body = http.MaxBytesReader(w, r.Body, maxCVSize+64<<10)
+	if err := r.ParseMultipartForm(1 << 20); err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			return cvTooLarge()
+		}
+		return apperr.BadRequest("expected multipart/form-data body")
+	}
+	defer r.MultipartForm.RemoveAll()
+
+	vacancy, err := repository.GetVacancyByID(r.Context(), vacancyID)
+	if err != nil {
+		return err
+	}
+	if vacancy == nil {
+		return apperr.NotFound("vacancy not found")
+	}
+
+	app := &models.VacancyApplication{
+		VacancyID:   vacancyID,
+		FullName:    strings.TrimSpace(r.FormValue("full_name")),
+		Email:       strings.TrimSpace(r.FormValue("email")),
+		Phone:       strings.TrimSpace(r.FormValue("phone")),
+		CoverLetter: strings.TrimSpace(r.FormValue("cover_letter")),
+	}
+
+	var errs validation.Errors
+	switch {
+	case app.FullName == "":
+		errs.Add("full_name", validation.CodeRequired, "full name is required")
+	case utf8.RuneCountInString(app.FullName) > maxApplicantNameLen:
+		errs.Add("full_name", validation.CodeTooLong, "full name is too long")
+	}
+	switch {
+	case app.Email == "":
+		errs.Add("email", validation.CodeRequired, "email is required")
+	case len(app.Email) > maxApplicantEmailLen || !validation.IsValidEmail(app.Email):
+		errs.Add("email", validation.CodeInvalidFormat, "email is invalid")
+	}
+	if app.Phone != "" && !validation.IsValidPhone(app.Phone) {
+		errs.Add("phone", validation.CodeInvalidFormat, "phone must contain 10-15 digits")
+	}
+	if utf8.RuneCountInString(app.CoverLetter) > maxCoverLetterLen {
+		errs.Add("cover_letter", validation.CodeTooLong, "cover letter is too long")
+	}
+
+	var data []byte
+	file, header, err := r.FormFile("cv")
+	switch {
+	case errors.Is(err, http.ErrMissingFile):
+		errs.Add("cv", validation.CodeRequired, "CV file is required")
+	case err != nil:
+		return apperr.BadRequest("invalid cv file")
+	default:
+		defer file.Close()
+		if header.Size > maxCVSize {
+			return cvTooLarge()
+		}
+		if data, err = io.ReadAll(io.LimitReader(file, maxCVSize+1)); err != nil {
+			return err
+		}
+		if int64(len(data)) > maxCVSize {
+			return cvTooLarge()
+		}
+		ct, ext, ok := detectCVType(data)
+		if !ok {
+			errs.Add("cv", validation.CodeNotAllowed, "CV must be a PDF or DOCX file")
+		}
+		app.CVContentType = ct
+		app.CVFilename = cleanUploadFilename(header.Filename, ext)
+		app.CVSize = int64(len(data))
+		app.CVKey = storage.NewKey("cv", ext)
+	}
+	if errs.HasErrors() {
+		return errs
+	}
+
+	if err := storage.Files.Put(r.Context(), app.CVKey, bytes.NewReader(data), app.CVSize, app.CVContentType); err != nil {
+		return err
+	}
+	id, err := repository.CreateVacancyApplication(r.Context(), app)
+	if err != nil {
+		// отклик не сохранён (например, повторный) — файл больше никому не нужен
+		if derr := storage.Files.Delete(r.Context(), app.CVKey); derr != nil {
+			logging.FromContext(r.Context()).Warn("failed to delete orphaned CV", "key", app.CVKey, "error", derr)
+		}
+		if ae := apperr.From(err); ae.Status == http.StatusConflict {
+			return apperr.Conflict("you have already applied for this vacancy")
+		}
+		return err
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(map[string]interface{}{
+		"id":      id,
+		"message": "Ваш отклик отправлен",
+	})
+	return nil
+}
+
+func cvTooLarge() error {
+	return apperr.New(http.StatusRequestEntityTooLarge, "file_too_large",
+		"CV file must not exceed "+strconv.FormatInt(maxCVSize>>20, 10)+" MB")
+}
+
+// detectCVType определяет тип резюме по содержимому, а не по имени файла или заголовку клиента.
+// DOCX — это ZIP-архив, поэтому дополнительно проверяется наличие word/document.xml.
+func detectCVType(data []byte) (contentType, ext string, ok bool) {
+	switch {
+	case bytes.HasPrefix(data, []byte("%PDF-")):
+		return contentTypePDF, ".pdf", true
+	case bytes.HasPrefix(data, []byte("PK\x03\x04")):
+		zr, err := zip.NewReader(bytes.NewReader(data), int64(len(data)))
+		if err != nil {
+			return "", "", false
+		}
+		for _, f := range zr.File {
+			if f.Name == "word/document.xml" {
+				return contentTypeDOCX, ".docx", true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// cleanUploadFilename оставляет от присланного имени файла только безопасное отображаемое имя
+// (без пути и управляющих символов) с расширением, соответствующим реальному типу.
+func cleanUploadFilename(name, ext string) string {
+	name = filepath.Base(strings.ReplaceAll(name, "\\", "/"))
+	name = strings.TrimSuffix(name, filepath.Ext(name))
+	name = strings.Map(func(r rune) rune {
+		if unicode.IsControl(r) || r == '"' || r == '/' {
+			return -1
+		}
+		return r
+	}, name)
+	name = strings.TrimSpace(name)
+	if name == "" || name == "." {
+		name = "cv"
+	}
+	if utf8.RuneCountInString(name) > maxCVFilenameLen-len(ext) {
+		name = string([]rune(name)[:maxCVFilenameLen-len(ext)])
+	}
+	return name + ext
+}
@@ -6,13 +6,13 @@ import (
 	"x86trade_backend/internal/repository"
 )
 
-func GetVacanciesHandler(w http.ResponseWriter, r *http.Request) {
+func GetVacanciesHandler(w http.ResponseWriter, r *http.Request) error {
 	vacancies, err := repository.GetVacancies(r.Context())
 	if err != nil {
-		http.Error(w, "internal server error", http.StatusInternalServerError)
-		return
+		return err
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(vacancies)
+	return nil
 }
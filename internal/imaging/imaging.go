@@ -0,0 +1,71 @@
+// Package imaging — проверка загружаемых изображений по содержимому, уменьшение
+// и перекодирование для превью.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// Поддерживаемые типы изображений.
+const (
+	JPEG = "image/jpeg"
+	PNG  = "image/png"
+	WebP = "image/webp"
+)
+
+var extensions = map[string]string{
+	JPEG: ".jpg",
+	PNG:  ".png",
+	WebP: ".webp",
+}
+
+// MaxPixels — предельное число пикселей декодируемого изображения (40 Мп). Проверяется по
+// заголовку до декодирования, чтобы маленький файл не развернулся в гигабайты памяти.
+const MaxPixels = 40_000_000
+
+// jpegQuality — качество JPEG-превью.
+const jpegQuality = 85
+
+// ErrTooLarge — размеры изображения превышают допустимые.
+var ErrTooLarge = errors.New("imaging: image dimensions too large")
+
+// Detect определяет тип изображения по сигнатуре (а не по имени файла или заголовку клиента).
+// ok == false — данные не являются JPEG, PNG или WebP.
+func Detect(data []byte) (contentType, ext string, ok bool) {
+	contentType = http.DetectContentType(data)
+	ext, ok = extensions[contentType]
+	return contentType, ext, ok
+}
+
+// Ext возвращает расширение файла для типа изображения.
+func Ext(contentType string) string {
+	return extensions[contentType]
+}
+
+// Decode декодирует JPEG или PNG. WebP стандартная библиотека читать не умеет.
+func Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+	if cfg.Width*cfg.Height > MaxPixels {
+		return nil, ErrTooLarge
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	return img, err
+}
+
+// Encode кодирует превью в JPEG, а изображения с прозрачностью — в PNG (JPEG её теряет).
+// Возвращает тип содержимого.
+func Encode(w io.Writer, img *image.NRGBA) (contentType string, err error) {
+	if img.Opaque() {
+		return JPEG, jpeg.Encode(w, img, &jpeg.Options{Quality: jpegQuality})
+	}
+	return PNG, png.Encode(w, img)
+}
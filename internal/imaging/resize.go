@@ -0,0 +1,88 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Fit уменьшает изображение так, чтобы большая сторона была не больше maxSide
+// (усреднением по площади — без муара на мелких деталях). Меньшие изображения
+// не увеличиваются, а только приводятся к NRGBA.
+func Fit(src image.Image, maxSide int) *image.NRGBA {
+	b := src.Bounds()
+	w, h := b.Dx(), b.Dy()
+	if w <= maxSide && h <= maxSide {
+		return toNRGBA(src)
+	}
+	nw, nh := maxSide, maxSide
+	if w >= h {
+		nh = max(1, (h*maxSide+w/2)/w)
+	} else {
+		nw = max(1, (w*maxSide+h/2)/h)
+	}
+	return resizeArea(toNRGBA(src), nw, nh)
+}
+
+func toNRGBA(src image.Image) *image.NRGBA {
+	if n, ok := src.(*image.NRGBA); ok && n.Rect.Min == (image.Point{}) {
+		return n
+	}
+	b := src.Bounds()
+	dst := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(dst, dst.Rect, src, b.Min, draw.Src)
+	return dst
+}
+
+// resizeArea — уменьшение усреднением: каждый пиксель результата — среднее покрываемой
+// им области исходника с учётом дробных долей граничных пикселей. Цвет усредняется
+// с весом альфы, чтобы прозрачные пиксели не «пачкали» края.
+func resizeArea(src *image.NRGBA, nw, nh int) *image.NRGBA {
+	sw, sh := src.Rect.Dx(), src.Rect.Dy()
+	dst := image.NewNRGBA(image.Rect(0, 0, nw, nh))
+	sx := float64(sw) / float64(nw)
+	sy := float64(sh) / float64(nh)
+
+	for y := 0; y < nh; y++ {
+		y0, y1 := float64(y)*sy, float64(y+1)*sy
+		for x := 0; x < nw; x++ {
+			x0, x1 := float64(x)*sx, float64(x+1)*sx
+			var r, g, bl, a, area float64
+			for py := int(y0); float64(py) < y1 && py < sh; py++ {
+				wy := min(y1, float64(py+1)) - max(y0, float64(py))
+				row := src.Pix[py*src.Stride:]
+				for px := int(x0); float64(px) < x1 && px < sw; px++ {
+					wx := min(x1, float64(px+1)) - max(x0, float64(px))
+					wgt := wx * wy
+					p := row[px*4 : px*4+4]
+					pa := float64(p[3]) * wgt
+					r += float64(p[0]) * pa
+					g += float64(p[1]) * pa
+					bl += float64(p[2]) * pa
+					a += pa
+					area += wgt
+				}
+			}
+			d := dst.Pix[y*dst.Stride+x*4 : y*dst.Stride+x*4+4]
+			if a > 0 {
+				d[0] = clamp8(r / a)
+				d[1] = clamp8(g / a)
+				d[2] = clamp8(bl / a)
+			}
+			if area > 0 {
+				d[3] = clamp8(a / area)
+			}
+		}
+	}
+	return dst
+}
+
+func clamp8(v float64) uint8 {
+	v += 0.5
+	switch {
+	case v <= 0:
+		return 0
+	case v >= 255:
+		return 255
+	}
+	return uint8(v)
+}
@@ -0,0 +1,32 @@
+package imaging
+
+import (
+	"image"
+	"io"
+
+	"github.com/chai2010/webp"
+)
+
+// webpQuality — качество WebP-превью. С потерями: рядом с JPEG того же размера WebP-вариант
+// должен быть легче, иначе отдавать его нет смысла.
+const webpQuality = 80
+
+// webpMaxSize — предельная ширина и высота изображения в формате WebP.
+const webpMaxSize = 16383
+
+// EncodeWebP кодирует изображение в WebP с потерями (libwebp); прозрачность сохраняется.
+func EncodeWebP(w io.Writer, img *image.NRGBA) error {
+	width, height := img.Rect.Dx(), img.Rect.Dy()
+	if width < 1 || height < 1 || width > webpMaxSize || height > webpMaxSize {
+		return ErrTooLarge
+	}
+	// libwebp ждёт RGBA без предумножения альфы — то есть ровно пиксели NRGBA. webp.Encode
+	// перевёл бы *image.NRGBA в предумноженный *image.RGBA и затемнил полупрозрачные края,
+	// поэтому буфер передаётся как есть.
+	data, err := webp.EncodeRGBA(&image.RGBA{Pix: img.Pix, Stride: img.Stride, Rect: img.Rect}, webpQuality)
+	if err != nil {
+		return err
+	}
+	_, err = w.Write(data)
+	return err
+}
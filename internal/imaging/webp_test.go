@@ -0,0 +1,105 @@
+package imaging
+
+import (
+	"bytes"
+	"image"
+	"math"
+	"math/rand/v2"
+	"testing"
+
+	"golang.org/x/image/webp"
+)
+
+// photo — «фотография»: плавные градиенты с небольшим шумом, как у типичного снимка товара.
+func photo(w, h int) *image.NRGBA {
+	rnd := rand.New(rand.NewPCG(1, 2))
+	img := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			n := rnd.IntN(9) - 4
+			fx, fy := float64(x)/float64(w), float64(y)/float64(h)
+			c := [4]uint8{
+				clamp8(128 + 100*math.Sin(fx*6) + float64(n)),
+				clamp8(60 + 150*fy + float64(n)),
+				clamp8(200 - 120*fx*fy + float64(n)),
+				255,
+			}
+			copy(img.Pix[img.PixOffset(x, y):], c[:])
+		}
+	}
+	return img
+}
+
+// meanDiff — средняя разница каналов двух изображений одного размера.
+func meanDiff(t *testing.T, want *image.NRGBA, got image.Image) float64 {
+	t.Helper()
+	if got.Bounds().Dx() != want.Rect.Dx() || got.Bounds().Dy() != want.Rect.Dy() {
+		t.Fatalf("size = %v, want %v", got.Bounds(), want.Rect)
+	}
+	have := toNRGBA(got)
+	var sum float64
+	for i := range want.Pix {
+		sum += math.Abs(float64(want.Pix[i]) - float64(have.Pix[i]))
+	}
+	return sum / float64(len(want.Pix))
+}
+
+func TestEncodeWebPPhoto(t *testing.T) {
+	src := photo(640, 480)
+
+	var webpBuf, jpegBuf bytes.Buffer
+	if err := EncodeWebP(&webpBuf, src); err != nil {
+		t.Fatalf("EncodeWebP: %v", err)
+	}
+	if ct, _, ok := Detect(webpBuf.Bytes()); !ok || ct != WebP {
+		t.Fatalf("Detect = %q, %v", ct, ok)
+	}
+	img, err := webp.Decode(bytes.NewReader(webpBuf.Bytes()))
+	if err != nil {
+		t.Fatalf("webp.Decode: %v", err)
+	}
+	// сжатие с потерями сглаживает шум ±4, но не искажает сам снимок
+	if d := meanDiff(t, src, img); d > 8 {
+		t.Errorf("mean channel difference = %.2f, want <= 8", d)
+	}
+
+	// ради этого WebP-варианты и генерируются: рядом с JPEG превью они должны быть легче
+	if ct, err := Encode(&jpegBuf, src); err != nil || ct != JPEG {
+		t.Fatalf("Encode = %q, %v", ct, err)
+	}
+	if webpBuf.Len() >= jpegBuf.Len() {
+		t.Errorf("WebP %d bytes, JPEG %d bytes: WebP variant is not smaller", webpBuf.Len(), jpegBuf.Len())
+	}
+}
+
+// Полупрозрачные пиксели не должны темнеть: libwebp получает цвета без предумножения альфы.
+func TestEncodeWebPAlpha(t *testing.T) {
+	src := image.NewNRGBA(image.Rect(0, 0, 64, 64))
+	for i := 0; i < len(src.Pix); i += 4 {
+		copy(src.Pix[i:], []uint8{220, 40, 40, 128})
+	}
+
+	var buf bytes.Buffer
+	if err := EncodeWebP(&buf, src); err != nil {
+		t.Fatalf("EncodeWebP: %v", err)
+	}
+	img, err := webp.Decode(bytes.NewReader(buf.Bytes()))
+	if err != nil {
+		t.Fatalf("webp.Decode: %v", err)
+	}
+	// цвет смещается только из-за прореживания цветности (4:2:0); с предумножением он упал бы вдвое
+	got := toNRGBA(img).Pix[:4]
+	want := src.Pix[:4]
+	for i, tol := range []int{16, 16, 16, 2} {
+		if d := int(got[i]) - int(want[i]); d < -tol || d > tol {
+			t.Fatalf("pixel = %v, want %v (±%d)", got, want, tol)
+		}
+	}
+}
+
+func TestEncodeWebPTooLarge(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, webpMaxSize+1, 1))
+	if err := EncodeWebP(&bytes.Buffer{}, img); err != ErrTooLarge {
+		t.Fatalf("err = %v, want ErrTooLarge", err)
+	}
+}
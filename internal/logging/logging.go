@@ -0,0 +1,70 @@
+// Package logging — структурированное логирование на log/slog.
+// Логгер с атрибутами запроса (request_id и т.п.) кладётся в context и достаётся
+// через FromContext в хендлерах и репозиториях.
+package logging
+
+import (
+	"context"
+	"io"
+	"log"
+	"log/slog"
+	"os"
+	"strings"
+
+	"x86trade_backend/internal/requestid"
+)
+
+type ctxKey struct{}
+
+// Setup настраивает slog.Default. level: debug|info|warn|error, format: json|text.
+// Стандартный log тоже перенаправляется в slog (уровень info).
+func Setup(level, format string) *slog.Logger {
+	return SetupWriter(os.Stdout, level, format)
+}
+
+// SetupWriter — то же, что Setup, но с произвольным writer.
+func SetupWriter(w io.Writer, level, format string) *slog.Logger {
+	opts := &slog.HandlerOptions{Level: ParseLevel(level)}
+	var h slog.Handler
+	if strings.EqualFold(format, "text") {
+		h = slog.NewTextHandler(w, opts)
+	} else {
+		h = slog.NewJSONHandler(w, opts)
+	}
+	logger := slog.New(h)
+	slog.SetDefault(logger)
+	log.SetFlags(0)
+	return logger
+}
+
+// ParseLevel переводит строку в slog.Level; неизвестное значение — info.
+func ParseLevel(s string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(s)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// WithContext кладёт логгер в контекст.
+func WithContext(ctx context.Context, l *slog.Logger) context.Context {
+	return context.WithValue(ctx, ctxKey{}, l)
+}
+
+// FromContext возвращает логгер запроса; если его нет — slog.Default с request_id (если он есть).
+func FromContext(ctx context.Context) *slog.Logger {
+	if ctx != nil {
+		if l, ok := ctx.Value(ctxKey{}).(*slog.Logger); ok {
+			return l
+		}
+		if id := requestid.FromContext(ctx); id != "" {
+			return slog.Default().With("request_id", id)
+		}
+	}
+	return slog.Default()
+}
@@ -0,0 +1,85 @@
+package logging
+
+import (
+	"encoding/json"
+	"net/url"
+	"regexp"
+	"strings"
+)
+
+const redacted = "[REDACTED]"
+
+// sensitiveKeys — поля, значения которых никогда не попадают в лог.
+var sensitiveKeys = map[string]bool{
+	"password":      true,
+	"password_hash": true,
+	"access_token":  true,
+	"refresh_token": true,
+	"token":         true,
+	"authorization": true,
+	"secret":        true,
+	"email":         true,
+}
+
+func isSensitiveKey(k string) bool {
+	k = strings.ToLower(k)
+	if sensitiveKeys[k] {
+		return true
+	}
+	return strings.Contains(k, "password") || strings.HasSuffix(k, "_token") || strings.Contains(k, "secret") ||
+		strings.Contains(k, "email")
+}
+
+// fallbackRe ловит "key":"value" для не-JSON или обрезанных тел.
+var fallbackRe = regexp.MustCompile(`(?i)("(?:[a-z_]*password[a-z_]*|[a-z_]*_token|token|authorization|[a-z_]*secret[a-z_]*|[a-z_]*email[a-z_]*)"\s*:\s*)"[^"]*"`)
+
+// RedactBody маскирует чувствительные поля в JSON-теле. Тело, которое не удалось разобрать
+// (например, обрезанное), маскируется регуляркой.
+func RedactBody(body []byte) string {
+	var v interface{}
+	if err := json.Unmarshal(body, &v); err != nil {
+		return fallbackRe.ReplaceAllString(string(body), `$1"`+redacted+`"`)
+	}
+	out, err := json.Marshal(redactValue(v))
+	if err != nil {
+		return redacted
+	}
+	return string(out)
+}
+
+func redactValue(v interface{}) interface{} {
+	switch t := v.(type) {
+	case map[string]interface{}:
+		for k, val := range t {
+			if isSensitiveKey(k) {
+				t[k] = redacted
+			} else {
+				t[k] = redactValue(val)
+			}
+		}
+		return t
+	case []interface{}:
+		for i := range t {
+			t[i] = redactValue(t[i])
+		}
+		return t
+	default:
+		return v
+	}
+}
+
+// RedactQuery маскирует значения чувствительных параметров строки запроса (?token=, ?email=)
+// по тем же правилам, что и поля тела. Порядок и кодирование остальных параметров сохраняются.
+func RedactQuery(rawQuery string) string {
+	parts := strings.Split(rawQuery, "&")
+	for i, part := range parts {
+		k, _, hasValue := strings.Cut(part, "=")
+		if key, err := url.QueryUnescape(k); err == nil {
+			k = key
+		}
+		if hasValue && isSensitiveKey(k) {
+			parts[i] = part[:strings.IndexByte(part, '=')+1] + redacted
+		}
+	}
+	return strings.Join(parts, "&")
+}
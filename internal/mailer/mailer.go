@@ -0,0 +1,29 @@
+// Package mailer — отправка писем. Mailer — интерфейс: в разработке письма только пишутся
+// в лог (LogMailer), в production уходят через SMTP (SMTPMailer).
+package mailer
+
+import (
+	"context"
+
+	"x86trade_backend/internal/logging"
+)
+
+// Message — текстовое письмо одному получателю.
+type Message struct {
+	To      string
+	Subject string
+	Body    string
+}
+
+// Mailer отправляет письма.
+type Mailer interface {
+	Send(ctx context.Context, m Message) error
+}
+
+// LogMailer ничего не отправляет, а пишет письмо в лог — для локальной разработки.
+type LogMailer struct{}
+
+func (LogMailer) Send(ctx context.Context, m Message) error {
+	logging.FromContext(ctx).Info("mail not sent (MAIL_BACKEND=log)", "to", m.To, "subject", m.Subject, "body", m.Body)
+	return nil
+}
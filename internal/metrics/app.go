@@ -0,0 +1,44 @@
+package metrics
+
+import "database/sql"
+
+// HTTP-метрики. route — шаблон chi (/api/products/{id}), а не сырой путь, чтобы не раздувать кардинальность.
+var (
+	HTTPRequests = NewCounterVec("http_requests_total",
+		"Total number of HTTP requests by method, route pattern and status.",
+		"method", "route", "status")
+	HTTPDuration = NewHistogramVec("http_request_duration_seconds",
+		"HTTP request latency in seconds by method, route pattern and status.",
+		DefBuckets, "method", "route", "status")
+)
+
+// Бизнес-счётчики.
+var (
+	OrdersCreated   = NewCounterVec("orders_created_total", "Orders created from cart.")
+	OrdersCancelled = NewCounterVec("orders_cancelled_total", "Orders cancelled, by who cancelled them (customer|admin).", "source")
+	LoginFailures   = NewCounterVec("auth_login_failures_total", "Failed login attempts by reason (unknown_user|bad_password).", "reason")
+	CartAdds        = NewCounterVec("cart_adds_total", "Successful add/update cart item operations.")
+	RateLimited     = NewCounterVec("rate_limited_requests_total", "Requests rejected with 429 by rate limit policy.", "policy")
+	ContactMessages = NewCounterVec("contact_messages_total", "Contact form messages stored, by anti-spam verdict (ok|spam).", "verdict")
+)
+
+// RegisterDBStats регистрирует gauge-метрики пула соединений; stats вызывается при каждом скрейпе
+// (обычно db.DB.Stats).
+func RegisterDBStats(stats func() sql.DBStats) {
+	NewGaugeFunc("db_pool_max_open_connections", "Maximum number of open connections to the database.",
+		func() float64 { return float64(stats().MaxOpenConnections) })
+	NewGaugeFunc("db_pool_open_connections", "The number of established connections both in use and idle.",
+		func() float64 { return float64(stats().OpenConnections) })
+	NewGaugeFunc("db_pool_in_use_connections", "The number of connections currently in use.",
+		func() float64 { return float64(stats().InUse) })
+	NewGaugeFunc("db_pool_idle_connections", "The number of idle connections.",
+		func() float64 { return float64(stats().Idle) })
+	NewCounterFunc("db_pool_wait_count_total", "The total number of connections waited for.",
+		func() float64 { return float64(stats().WaitCount) })
+	NewCounterFunc("db_pool_wait_duration_seconds_total", "The total time blocked waiting for a new connection.",
+		func() float64 { return stats().WaitDuration.Seconds() })
+	NewCounterFunc("db_pool_max_idle_closed_total", "The total number of connections closed due to SetMaxIdleConns.",
+		func() float64 { return float64(stats().MaxIdleClosed) })
+	NewCounterFunc("db_pool_max_lifetime_closed_total", "The total number of connections closed due to SetConnMaxLifetime.",
+		func() float64 { return float64(stats().MaxLifetimeClosed) })
+}
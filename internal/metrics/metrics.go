@@ -0,0 +1,250 @@
+// Package metrics — минимальная реализация метрик в текстовом формате Prometheus
+// (exposition format 0.0.4) без внешних зависимостей: счётчики, гистограммы и
+// gauge-функции с метками. Все метрики регистрируются в пакетном реестре и
+// отдаются через Handler.
+package metrics
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+)
+
+// collector — всё, что умеет записать себя в формате Prometheus.
+type collector interface {
+	write(w *bufio.Writer)
+}
+
+var (
+	regMu      sync.RWMutex
+	registered []collector
+)
+
+func register(c collector) {
+	regMu.Lock()
+	registered = append(registered, c)
+	regMu.Unlock()
+}
+
+// labelSep разделяет значения меток в ключе серии (в самих значениях не встречается).
+const labelSep = "\xff"
+
+func seriesKey(values []string) string {
+	return strings.Join(values, labelSep)
+}
+
+// CounterVec — монотонный счётчик с набором меток.
+type CounterVec struct {
+	name   string
+	help   string
+	labels []string
+
+	mu     sync.Mutex
+	values map[string]float64
+}
+
+// NewCounterVec создаёт и регистрирует счётчик.
+func NewCounterVec(name, help string, labels ...string) *CounterVec {
+	c := &CounterVec{name: name, help: help, labels: labels, values: map[string]float64{}}
+	if len(labels) == 0 {
+		c.values[""] = 0 // счётчик без меток виден в выдаче сразу, с нулём
+	}
+	register(c)
+	return c
+}
+
+// Inc увеличивает счётчик на 1. Количество значений должно совпадать с количеством меток.
+func (c *CounterVec) Inc(labelValues ...string) {
+	c.Add(1, labelValues...)
+}
+
+// Add увеличивает счётчик на v (v >= 0).
+func (c *CounterVec) Add(v float64, labelValues ...string) {
+	if v < 0 || len(labelValues) != len(c.labels) {
+		return
+	}
+	key := seriesKey(labelValues)
+	c.mu.Lock()
+	c.values[key] += v
+	c.mu.Unlock()
+}
+
+func (c *CounterVec) write(w *bufio.Writer) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	writeHeader(w, c.name, c.help, "counter")
+	for _, key := range sortedKeys(c.values) {
+		writeSample(w, c.name, c.labels, strings.Split(key, labelSep), c.values[key])
+	}
+}
+
+// DefBuckets — границы бакетов латентности в секундах (как в client_golang).
+var DefBuckets = []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10}
+
+type histogramSeries struct {
+	counts []uint64 // по бакетам, не накопительно
+	sum    float64
+	count  uint64
+}
+
+// HistogramVec — гистограмма с набором меток.
+type HistogramVec struct {
+	name    string
+	help    string
+	labels  []string
+	buckets []float64
+
+	mu     sync.Mutex
+	series map[string]*histogramSeries
+}
+
+// NewHistogramVec создаёт и регистрирует гистограмму. buckets должны быть отсортированы по возрастанию.
+func NewHistogramVec(name, help string, buckets []float64, labels ...string) *HistogramVec {
+	h := &HistogramVec{name: name, help: help, labels: labels, buckets: buckets, series: map[string]*histogramSeries{}}
+	register(h)
+	return h
+}
+
+// Observe добавляет наблюдение v.
+func (h *HistogramVec) Observe(v float64, labelValues ...string) {
+	if len(labelValues) != len(h.labels) {
+		return
+	}
+	key := seriesKey(labelValues)
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	s, ok := h.series[key]
+	if !ok {
+		s = &histogramSeries{counts: make([]uint64, len(h.buckets))}
+		h.series[key] = s
+	}
+	if i := sort.SearchFloat64s(h.buckets, v); i < len(h.buckets) {
+		s.counts[i]++
+	}
+	s.sum += v
+	s.count++
+}
+
+func (h *HistogramVec) write(w *bufio.Writer) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	writeHeader(w, h.name, h.help, "histogram")
+	keys := make([]string, 0, len(h.series))
+	for k := range h.series {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	bucketLabels := append(append([]string{}, h.labels...), "le")
+	for _, key := range keys {
+		s := h.series[key]
+		values := strings.Split(key, labelSep)
+		if len(h.labels) == 0 {
+			values = nil
+		}
+		var cumulative uint64
+		for i, upper := range h.buckets {
+			cumulative += s.counts[i]
+			writeSample(w, h.name+"_bucket", bucketLabels, append(append([]string{}, values...), formatFloat(upper)), float64(cumulative))
+		}
+		writeSample(w, h.name+"_bucket", bucketLabels, append(append([]string{}, values...), "+Inf"), float64(s.count))
+		writeSample(w, h.name+"_sum", h.labels, values, s.sum)
+		writeSample(w, h.name+"_count", h.labels, values, float64(s.count))
+	}
+}
+
+// valueFunc — метрика без меток, значение которой вычисляется в момент скрейпа.
+type valueFunc struct {
+	name string
+	help string
+	typ  string
+	fn   func() float64
+}
+
+// NewGaugeFunc регистрирует gauge, значение которого берётся из fn при каждом скрейпе.
+func NewGaugeFunc(name, help string, fn func() float64) {
+	register(&valueFunc{name: name, help: help, typ: "gauge", fn: fn})
+}
+
+// NewCounterFunc — то же для монотонных значений, которые считает кто-то другой (например, sql.DBStats).
+func NewCounterFunc(name, help string, fn func() float64) {
+	register(&valueFunc{name: name, help: help, typ: "counter", fn: fn})
+}
+
+func (f *valueFunc) write(w *bufio.Writer) {
+	writeHeader(w, f.name, f.help, f.typ)
+	writeSample(w, f.name, nil, nil, f.fn())
+}
+
+// Handler отдаёт все зарегистрированные метрики (GET /metrics).
+func Handler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+		bw := bufio.NewWriter(w)
+		regMu.RLock()
+		for _, c := range registered {
+			c.write(bw)
+		}
+		regMu.RUnlock()
+		_ = bw.Flush()
+	})
+}
+
+func writeHeader(w *bufio.Writer, name, help, typ string) {
+	fmt.Fprintf(w, "# HELP %s %s\n", name, escapeHelp(help))
+	fmt.Fprintf(w, "# TYPE %s %s\n", name, typ)
+}
+
+func writeSample(w *bufio.Writer, name string, labels, values []string, v float64) {
+	w.WriteString(name)
+	if len(labels) > 0 {
+		w.WriteByte('{')
+		for i, l := range labels {
+			if i > 0 {
+				w.WriteByte(',')
+			}
+			w.WriteString(l)
+			w.WriteString(`="`)
+			w.WriteString(escapeLabel(values[i]))
+			w.WriteByte('"')
+		}
+		w.WriteByte('}')
+	}
+	w.WriteByte(' ')
+	w.WriteString(formatFloat(v))
+	w.WriteByte('\n')
+}
+
+func formatFloat(v float64) string {
+	switch {
+	case math.IsInf(v, 1):
+		return "+Inf"
+	case math.IsInf(v, -1):
+		return "-Inf"
+	case math.IsNaN(v):
+		return "NaN"
+	}
+	return strconv.FormatFloat(v, 'g', -1, 64)
+}
+
+var (
+	labelEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+	helpEscaper  = strings.NewReplacer(`\`, `\\`, "\n", `\n`)
+)
+
+func escapeLabel(s string) string { return labelEscaper.Replace(s) }
+func escapeHelp(s string) string  { return helpEscaper.Replace(s) }
+
+func sortedKeys(m map[string]float64) []string {
+	keys := make([]string, 0, len(m))
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
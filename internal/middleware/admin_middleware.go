@@ -2,6 +2,8 @@ package middleware
 
 import (
 	"net/http"
+
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/repository"
 )
 
@@ -11,16 +13,16 @@ func AdminOnly(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		userID, ok := UserIDFromContext(r.Context())
 		if !ok {
-			http.Error(w, "authorization required", http.StatusUnauthorized)
+			apperr.Write(w, r, apperr.Unauthorized("authorization required"))
 			return
 		}
 		u, err := repository.GetUserByID(r.Context(), userID)
 		if err != nil {
-			http.Error(w, "internal server error", http.StatusInternalServerError)
+			apperr.Write(w, r, err)
 			return
 		}
 		if u == nil || !u.IsAdmin {
-			http.Error(w, "admin access required", http.StatusForbidden)
+			apperr.Write(w, r, apperr.Forbidden("admin access required"))
 			return
 		}
 		next.ServeHTTP(w, r)
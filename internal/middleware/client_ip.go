@@ -0,0 +1,83 @@
+package middleware
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/netip"
+	"strings"
+)
+
+// trustedProxies — сети балансировщиков/прокси, которым разрешено сообщать адрес клиента
+// в X-Forwarded-For. Задаётся один раз при старте (SetTrustedProxies).
+var trustedProxies []netip.Prefix
+
+// SetTrustedProxies задаёт доверенные прокси: CIDR ("10.0.0.0/8") или отдельные адреса.
+func SetTrustedProxies(list []string) error {
+	var out []netip.Prefix
+	for _, s := range list {
+		s = strings.TrimSpace(s)
+		if s == "" {
+			continue
+		}
+		if p, err := netip.ParsePrefix(s); err == nil {
+			out = append(out, p.Masked())
+			continue
+		}
+		a, err := netip.ParseAddr(s)
+		if err != nil {
+			return fmt.Errorf("invalid trusted proxy %q", s)
+		}
+		a = a.Unmap()
+		out = append(out, netip.PrefixFrom(a, a.BitLen()))
+	}
+	trustedProxies = out
+	return nil
+}
+
+func isTrusted(a netip.Addr) bool {
+	a = a.Unmap()
+	for _, p := range trustedProxies {
+		if p.Contains(a) {
+			return true
+		}
+	}
+	return false
+}
+
+// ClientIP возвращает адрес клиента. X-Forwarded-For учитывается, только если запрос пришёл
+// от доверенного прокси: цепочка читается справа налево, пропуская доверенные адреса, и
+// первый недоверенный считается клиентом. Так клиент не может подделать адрес, дописав
+// заголовок сам — его значения окажутся левее адреса, добавленного нашим прокси.
+func ClientIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		host = r.RemoteAddr
+	}
+	remote, err := netip.ParseAddr(host)
+	if err != nil {
+		return host
+	}
+	remote = remote.Unmap()
+	if len(trustedProxies) == 0 || !isTrusted(remote) {
+		return remote.String()
+	}
+
+	var hops []string
+	for _, h := range r.Header.Values("X-Forwarded-For") {
+		hops = append(hops, strings.Split(h, ",")...)
+	}
+	client := remote
+	for i := len(hops) - 1; i >= 0; i-- {
+		a, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+		if err != nil {
+			// мусор в цепочке — дальше доверять нельзя, останавливаемся на последнем известном
+			break
+		}
+		client = a.Unmap()
+		if !isTrusted(client) {
+			break
+		}
+	}
+	return client.String()
+}
@@ -2,86 +2,130 @@ package middleware
 
 import (
 	"bytes"
-	"log"
+	"context"
+	"log/slog"
 	"net/http"
-	"os"
-	"strings"
 	"time"
+
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/requestid"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/otel/trace"
 )
 
-// respWriter обёртка для ResponseWriter — захватываем статус и тело ответа.
+// maxLoggedBody — сколько байт тела ответа логируем, когда это явно включено.
+const maxLoggedBody = 8 * 1024
+
+// respWriter обёртка для ResponseWriter — захватываем статус, размер и (опционально) тело ответа.
 type respWriter struct {
 	http.ResponseWriter
-	status int
-	buf    *bytes.Buffer
+	status      int
+	bytes       int
+	wroteHeader bool
+	buf         *bytes.Buffer // nil, если тела не логируются
 }
 
 func (rw *respWriter) WriteHeader(status int) {
-	rw.status = status
+	if !rw.wroteHeader {
+		rw.status = status
+		rw.wroteHeader = true
+	}
 	rw.ResponseWriter.WriteHeader(status)
 }
 
 func (rw *respWriter) Write(b []byte) (int, error) {
-	// копируем в буфер (ограничиваем размер логируемого тела до, например, 8KB)
-	if rw.buf.Len() < 8*1024 {
-		remaining := 8*1024 - rw.buf.Len()
+	if !rw.wroteHeader {
+		rw.wroteHeader = true
+	}
+	if rw.buf != nil && rw.buf.Len() < maxLoggedBody {
+		remaining := maxLoggedBody - rw.buf.Len()
 		if len(b) > remaining {
 			rw.buf.Write(b[:remaining])
 		} else {
 			rw.buf.Write(b)
 		}
 	}
-	return rw.ResponseWriter.Write(b)
+	n, err := rw.ResponseWriter.Write(b)
+	rw.bytes += n
+	return n, err
 }
 
-// LoggingMiddleware возвращает middleware, который логгирует каждый запрос/ответ,
-// если debug == true. В лог уходит: method, path, status, duration и первые 8KB тела ответа.
-func LoggingMiddleware(debug bool) func(next http.Handler) http.Handler {
-	return func(next http.Handler) http.Handler {
-		if !debug {
-			// Возвращаем прозрачный middleware (ничего не делаем)
-			return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-				next.ServeHTTP(w, r)
-			})
-		}
+// Unwrap нужен http.ResponseController (Flush и т.п.).
+func (rw *respWriter) Unwrap() http.ResponseWriter {
+	return rw.ResponseWriter
+}
+
+// requestState — изменяемые данные запроса, которые заполняются глубже по цепочке
+// (например, user_id из AuthMiddleware) и нужны access-логу снаружи.
+type requestState struct {
+	userID int
+}
+
+type stateKey struct{}
+
+func setRequestUser(ctx context.Context, userID int) {
+	if st, ok := ctx.Value(stateKey{}).(*requestState); ok {
+		st.userID = userID
+	}
+}
 
+// LoggingMiddleware пишет access-лог каждого запроса через slog: метод, путь, шаблон роута,
+// статус, длительность, размер ответа, user_id и request_id. Логгер с request_id кладётся в
+// контекст (logging.FromContext). Тело ответа логируется только при logBodies == true,
+// с маскировкой токенов и паролей.
+func LoggingMiddleware(logBodies bool) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 			start := time.Now()
 
-			rw := &respWriter{
-				ResponseWriter: w,
-				status:         200,
-				buf:            &bytes.Buffer{},
+			logger := slog.Default().With("request_id", requestid.FromContext(r.Context()))
+			// корреляция с трассой: по trace_id лог запроса находится рядом со спанами
+			if sc := trace.SpanContextFromContext(r.Context()); sc.IsValid() {
+				logger = logger.With("trace_id", sc.TraceID().String(), "span_id", sc.SpanID().String())
 			}
+			st := &requestState{}
+			ctx := logging.WithContext(r.Context(), logger)
+			ctx = context.WithValue(ctx, stateKey{}, st)
 
-			next.ServeHTTP(rw, r)
+			rw := &respWriter{ResponseWriter: w, status: http.StatusOK}
+			if logBodies {
+				rw.buf = &bytes.Buffer{}
+			}
 
-			duration := time.Since(start)
+			next.ServeHTTP(rw, r.WithContext(ctx))
 
-			// метод путь query
-			path := r.URL.Path
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rw.status),
+				slog.Float64("latency_ms", float64(time.Since(start).Microseconds())/1000),
+				slog.Int("bytes", rw.bytes),
+				slog.String("remote_ip", ClientIP(r)),
+			}
+			if rc := chi.RouteContext(r.Context()); rc != nil {
+				if pattern := rc.RoutePattern(); pattern != "" {
+					attrs = append(attrs, slog.String("route", pattern))
+				}
+			}
 			if r.URL.RawQuery != "" {
-				path = path + "?" + r.URL.RawQuery
+				attrs = append(attrs, slog.String("query", logging.RedactQuery(r.URL.RawQuery)))
 			}
-
-			// Короче, логируем результат. Ограничиваем тело, переводим новые строки
-			body := strings.ReplaceAll(rw.buf.String(), "\n", "\\n")
-			if len(body) > 1024 {
-				body = body[:1024] + "...(truncated)"
+			if st.userID > 0 {
+				attrs = append(attrs, slog.Int("user_id", st.userID))
+			}
+			if rw.buf != nil && rw.buf.Len() > 0 {
+				attrs = append(attrs, slog.String("resp_body", logging.RedactBody(rw.buf.Bytes())))
 			}
 
-			log.Printf("[DEBUG] %s %s -> %d (%s) resp_body=[%s]\n", r.Method, path, rw.status, duration, body)
+			level := slog.LevelInfo
+			switch {
+			case rw.status >= 500:
+				level = slog.LevelError
+			case rw.status >= 400:
+				level = slog.LevelWarn
+			}
+			logger.LogAttrs(r.Context(), level, "http request", attrs...)
 		})
 	}
 }
-
-// GetDebugFromEnv удобно читать DEBUG из окружения. Возвращает true если
-// переменная равна "1" или "true" (регистронезависимо).
-func GetDebugFromEnv() bool {
-	v := os.Getenv("DEBUG")
-	if v == "" {
-		return false
-	}
-	v = strings.ToLower(strings.TrimSpace(v))
-	return v == "1" || v == "true"
-}
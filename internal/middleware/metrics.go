@@ -0,0 +1,33 @@
+package middleware
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"x86trade_backend/internal/metrics"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Metrics считает запросы и латентность по методу, шаблону роута chi и статусу.
+// Запросы, не попавшие ни в один роут, идут с route="unmatched", чтобы сканеры
+// с произвольными путями не раздували число серий.
+func Metrics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rw := &respWriter{ResponseWriter: w, status: http.StatusOK}
+
+		next.ServeHTTP(rw, r)
+
+		route := "unmatched"
+		if rc := chi.RouteContext(r.Context()); rc != nil {
+			if pattern := rc.RoutePattern(); pattern != "" {
+				route = pattern
+			}
+		}
+		status := strconv.Itoa(rw.status)
+		metrics.HTTPRequests.Inc(r.Method, route, status)
+		metrics.HTTPDuration.Observe(time.Since(start).Seconds(), r.Method, route, status)
+	})
+}
@@ -5,6 +5,7 @@ import (
 	"net/http"
 	"strings"
 
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/utils"
 )
 
@@ -16,21 +17,22 @@ func AuthMiddleware(next http.Handler) http.Handler {
 	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		auth := r.Header.Get("Authorization")
 		if auth == "" {
-			http.Error(w, "authorization required", http.StatusUnauthorized)
+			apperr.Write(w, r, apperr.Unauthorized("authorization required"))
 			return
 		}
 		parts := strings.SplitN(auth, " ", 2)
 		if len(parts) != 2 || strings.ToLower(parts[0]) != "bearer" {
-			http.Error(w, "invalid authorization header", http.StatusUnauthorized)
+			apperr.Write(w, r, apperr.Unauthorized("invalid authorization header"))
 			return
 		}
 		token := parts[1]
 		claims, err := utils.ParseAccessToken(token)
 		if err != nil {
-			http.Error(w, "invalid token", http.StatusUnauthorized)
+			apperr.Write(w, r, apperr.Unauthorized("invalid token"))
 			return
 		}
-		// put user id into context
+		// put user id into context (и в access-лог)
+		setRequestUser(r.Context(), claims.UserID)
 		ctx := context.WithValue(r.Context(), ctxUserIDKey, claims.UserID)
 		next.ServeHTTP(w, r.WithContext(ctx))
 	})
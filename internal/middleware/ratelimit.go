@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+	"x86trade_backend/internal/metrics"
+	"x86trade_backend/internal/ratelimit"
+)
+
+// Имена групп лимитов (по одной политике на группу роутов).
+const (
+	LimitAuth         = "auth"         // регистрация, логин, refresh
+	LimitContact      = "contact"      // форма обратной связи
+	LimitCatalog      = "catalog"      // каталог и поиск товаров
+	LimitUser         = "user"         // всё, что под авторизацией
+	LimitApplications = "applications" // отклики на вакансии
+	LimitSuggest      = "suggest"      // подсказки поиска (запрос на каждый ввод символа)
+)
+
+var (
+	limitStore    ratelimit.Store
+	limitPolicies map[string]ratelimit.Policy
+)
+
+// ConfigureRateLimits задаёт хранилище и политики групп; вызывается в main до регистрации роутов.
+// store == nil выключает ограничение целиком.
+func ConfigureRateLimits(store ratelimit.Store, policies map[string]ratelimit.Policy) {
+	limitStore = store
+	limitPolicies = policies
+}
+
+// KeyFunc выбирает, по чему считать лимит.
+type KeyFunc func(r *http.Request) string
+
+// KeyByIP — по адресу клиента (с учётом доверенных прокси).
+func KeyByIP(r *http.Request) string {
+	return "ip:" + ClientIP(r)
+}
+
+// KeyByUser — по авторизованному пользователю; без авторизации — по IP.
+// Должен стоять после AuthMiddleware.
+func KeyByUser(r *http.Request) string {
+	if id, ok := UserIDFromContext(r.Context()); ok {
+		return "user:" + strconv.Itoa(id)
+	}
+	return KeyByIP(r)
+}
+
+// RateLimit ограничивает частоту запросов по политике группы name. Отдаёт заголовки
+// RateLimit-Limit/Remaining/Reset/Policy, а при превышении — 429 с Retry-After.
+// Если хранилище недоступно, запрос пропускается: лимитер не должен ронять магазин.
+func RateLimit(name string, key KeyFunc) func(http.Handler) http.Handler {
+	policy := limitPolicies[name]
+	store := limitStore
+	return func(next http.Handler) http.Handler {
+		if store == nil || !policy.Enabled() {
+			return next
+		}
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			res, err := store.Take(r.Context(), name+":"+key(r), policy)
+			if err != nil {
+				logging.FromContext(r.Context()).Warn("rate limiter unavailable, request allowed", "policy", name, "error", err)
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			h := w.Header()
+			h.Set("RateLimit-Limit", strconv.Itoa(res.Limit))
+			h.Set("RateLimit-Remaining", strconv.Itoa(res.Remaining))
+			h.Set("RateLimit-Reset", strconv.Itoa(ceilSeconds(res.Reset)))
+			h.Set("RateLimit-Policy", strconv.Itoa(policy.Limit)+";w="+strconv.Itoa(ceilSeconds(policy.Period)))
+
+			if !res.Allowed {
+				metrics.RateLimited.Inc(name)
+				h.Set("Retry-After", strconv.Itoa(max(1, ceilSeconds(res.RetryAfter))))
+				apperr.Write(w, r, apperr.New(http.StatusTooManyRequests, "rate_limited", "too many requests, try again later"))
+				return
+			}
+			next.ServeHTTP(w, r)
+		})
+	}
+}
+
+func ceilSeconds(d time.Duration) int {
+	return int(math.Ceil(d.Seconds()))
+}
@@ -0,0 +1,36 @@
+package middleware
+
+import (
+	"fmt"
+	"net/http"
+	"runtime/debug"
+
+	"x86trade_backend/internal/apperr"
+	"x86trade_backend/internal/logging"
+)
+
+// Recoverer перехватывает панику в хендлере и отвечает 500 в едином JSON-формате ошибок.
+func Recoverer(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		defer func() {
+			if rec := recover(); rec != nil {
+				if rec == http.ErrAbortHandler {
+					panic(rec)
+				}
+				logging.FromContext(r.Context()).Error("panic recovered",
+					"method", r.Method, "path", r.URL.Path, "panic", fmt.Sprint(rec), "stack", string(debug.Stack()))
+				apperr.Write(w, r, apperr.Internal(fmt.Errorf("panic: %v", rec)))
+			}
+		}()
+		next.ServeHTTP(w, r)
+	})
+}
+
+// NotFound и MethodNotAllowed — ответы роутера в том же JSON-формате.
+func NotFound(w http.ResponseWriter, r *http.Request) {
+	apperr.Write(w, r, apperr.NotFound("route not found"))
+}
+
+func MethodNotAllowed(w http.ResponseWriter, r *http.Request) {
+	apperr.Write(w, r, apperr.New(http.StatusMethodNotAllowed, apperr.CodeMethodNotAllowed, "method not allowed"))
+}
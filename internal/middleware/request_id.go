@@ -0,0 +1,20 @@
+package middleware
+
+import (
+	"net/http"
+
+	"x86trade_backend/internal/requestid"
+)
+
+// RequestID берёт X-Request-ID из запроса (если прислал прокси/клиент и значение допустимо,
+// см. requestid.Valid) или генерирует новый, кладёт его в контекст и возвращает в заголовке ответа.
+func RequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.WithContext(r.Context(), id)))
+	})
+}
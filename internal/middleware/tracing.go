@@ -0,0 +1,43 @@
+package middleware
+
+import (
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// Tracing открывает серверный спан на каждый запрос через otelhttp (с учётом входящего traceparent).
+// Имя спана — "METHOD /шаблон/роута/{id}"; шаблон chi известен только после маршрутизации,
+// поэтому спан переименовывается в конце. Статус и ошибки >= 500 проставляет otelhttp.
+func Tracing(next http.Handler) http.Handler {
+	named := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		next.ServeHTTP(w, r)
+
+		if pattern := routePattern(r); pattern != "" {
+			span := trace.SpanFromContext(r.Context())
+			span.SetName(spanName(r))
+			span.SetAttributes(semconv.HTTPRoute(pattern))
+		}
+	})
+	return otelhttp.NewHandler(named, "http.server",
+		otelhttp.WithSpanNameFormatter(func(_ string, r *http.Request) string { return spanName(r) }),
+	)
+}
+
+// spanName — "GET /api/products/{id}" после маршрутизации и "HTTP GET" до неё.
+func spanName(r *http.Request) string {
+	if pattern := routePattern(r); pattern != "" {
+		return r.Method + " " + pattern
+	}
+	return "HTTP " + r.Method
+}
+
+func routePattern(r *http.Request) string {
+	if rc := chi.RouteContext(r.Context()); rc != nil {
+		return rc.RoutePattern()
+	}
+	return ""
+}
@@ -0,0 +1,23 @@
+package models
+
+import "time"
+
+type UserAddress struct {
+	ID             int       `json:"id"`
+	UserID         int       `json:"user_id"`
+	Label          string    `json:"label,omitempty"`
+	Address        string    `json:"address"`
+	RecipientName  string    `json:"recipient_name"`
+	RecipientPhone string    `json:"recipient_phone"`
+	IsDefault      bool      `json:"is_default"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+}
+
+type UserAddressPayload struct {
+	Label          string `json:"label,omitempty"`
+	Address        string `json:"address"`
+	RecipientName  string `json:"recipient_name"`
+	RecipientPhone string `json:"recipient_phone"`
+	IsDefault      bool   `json:"is_default"`
+}
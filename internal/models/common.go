@@ -6,10 +6,15 @@ type DeliveryPayload struct {
 	BaseCost      float64  `json:"base_cost"`
 	FreeThreshold *float64 `json:"free_threshold,omitempty"`
 	EstimatedDays *int64   `json:"estimated_days,omitempty"`
+	// RequiresAddress по умолчанию true.
+	RequiresAddress *bool `json:"requires_address,omitempty"`
 }
 
+// CreateOrderPayload — данные доставки передаются либо явно (address, recipient_*),
+// либо ссылкой на сохранённый адрес пользователя (address_id).
 type CreateOrderPayload struct {
 	DeliveryMethodID *int   `json:"delivery_method_id,omitempty"`
+	AddressID        *int   `json:"address_id,omitempty"`
 	Address          string `json:"address,omitempty"`
 	RecipientName    string `json:"recipient_name,omitempty"`
 	RecipientPhone   string `json:"recipient_phone,omitempty"`
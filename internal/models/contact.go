@@ -11,4 +11,43 @@ type ContactMessage struct {
 	IsProcessed     bool       `json:"is_processed"`
 	ResponseMessage string     `json:"response_message,omitempty"`
 	ResponseAt      *time.Time `json:"response_at,omitempty"`
+	// Результат антиспам-проверки: помеченные сообщения сохраняются для ручного просмотра.
+	IsSpam      bool     `json:"is_spam"`
+	SpamScore   int      `json:"spam_score"`
+	SpamReasons []string `json:"spam_reasons,omitempty"`
+	ClientIP    string   `json:"client_ip,omitempty"`
+	// Работа с обращением в админке.
+	AssignedTo  *int       `json:"assigned_to,omitempty"`
+	AssignedAt  *time.Time `json:"assigned_at,omitempty"`
+	RespondedBy *int       `json:"responded_by,omitempty"`
+}
+
+// ContactMessageFilter — фильтры списка обращений в админке; nil — без фильтра.
+type ContactMessageFilter struct {
+	Processed  *bool
+	Spam       *bool
+	AssignedTo *int
+	From       *time.Time // created_at >= From
+	To         *time.Time // created_at < To
+	Limit      int
+	Offset     int
+}
+
+// ContactReplyPayload — ответ администратора на обращение.
+type ContactReplyPayload struct {
+	Subject string `json:"subject"`
+	Message string `json:"message"`
+}
+
+// Виды записей стоп-листа антиспама.
+const (
+	SpamBlockTerm  = "term"  // слово или фраза в тексте сообщения
+	SpamBlockEmail = "email" // адрес ("spam@example.com") или домен ("@example.com")
+)
+
+type SpamBlocklistEntry struct {
+	ID        int       `json:"id"`
+	Kind      string    `json:"kind"`
+	Value     string    `json:"value"`
+	CreatedAt time.Time `json:"created_at"`
 }
@@ -9,4 +9,6 @@ type DeliveryMethod struct {
 	BaseCost      float64         `json:"base_cost"`
 	FreeThreshold sql.NullFloat64 `json:"free_threshold,omitempty"`
 	EstimatedDays sql.NullInt64   `json:"estimated_days,omitempty"`
+	// RequiresAddress — false для самовывоза: адрес при оформлении не нужен.
+	RequiresAddress bool `json:"requires_address"`
 }
@@ -23,6 +23,14 @@ type OrderItem struct {
 	ImagePath    string  `json:"image_path,omitempty"`
 }
 
+// Статусы доставки заказа (order_deliveries.status) — свои, не совпадают со статусами заказа:
+// доставка ждёт отгрузки, едет (целиком или частями) или вручена.
+const (
+	DeliveryPending   = "pending"
+	DeliveryInTransit = "in_transit"
+	DeliveryDelivered = "delivered"
+)
+
 type OrderDelivery struct {
 	Address        string  `json:"address"`
 	RecipientName  string  `json:"recipient_name"`
@@ -31,3 +39,24 @@ type OrderDelivery struct {
 	MethodName     string  `json:"method_name"`
 	BaseCost       float64 `json:"base_cost"`
 }
+
+// ReorderItem — результат переноса одной позиции заказа в корзину.
+// Status: "added" (перенесено полностью), "adjusted" (количество уменьшено
+// до остатка на складе) или "dropped" (товар снят с продажи / нет в наличии).
+type ReorderItem struct {
+	ProductID         int     `json:"product_id"`
+	ProductName       string  `json:"product_name,omitempty"`
+	RequestedQuantity int     `json:"requested_quantity"`
+	AddedQuantity     int     `json:"added_quantity"`
+	OldPrice          float64 `json:"old_price"`
+	CurrentPrice      float64 `json:"current_price,omitempty"`
+	Status            string  `json:"status"`
+	Reason            string  `json:"reason,omitempty"`
+}
+
+type ReorderSummary struct {
+	OrderID  int           `json:"order_id"`
+	Added    []ReorderItem `json:"added"`
+	Adjusted []ReorderItem `json:"adjusted"`
+	Dropped  []ReorderItem `json:"dropped"`
+}
@@ -16,9 +16,65 @@ type ProductCharacteristicInput struct {
 	Value                string `json:"value"`
 }
 
+// Типы данных характеристик.
+const (
+	CharString  = "string"
+	CharNumber  = "number"
+	CharInteger = "integer"
+	CharBoolean = "boolean"
+	CharEnum    = "enum"
+)
+
 type CharacteristicType struct {
-	ID         int    `json:"id"`
-	Name       string `json:"name"`
+	ID   int    `json:"id"`
+	Name string `json:"name"`
+	// Code — ключ для фильтра каталога (?char[socket]=AM5): латиница, цифры, подчёркивание.
+	Code       string `json:"code,omitempty"`
 	Unit       string `json:"unit,omitempty"`
 	CategoryID int    `json:"category_id,omitempty"`
+	// DataType — string (по умолчанию), number, integer, boolean или enum.
+	DataType      string   `json:"data_type"`
+	AllowedValues []string `json:"allowed_values,omitempty"` // для enum
+	MinValue      *float64 `json:"min_value,omitempty"`      // для number/integer
+	MaxValue      *float64 `json:"max_value,omitempty"`
+}
+
+// CharacteristicMigrationReport — результат проверки (или нормализации) сохранённых значений
+// характеристики под её тип данных.
+type CharacteristicMigrationReport struct {
+	TypeID   int    `json:"type_id"`
+	Name     string `json:"name"`
+	DataType string `json:"data_type"`
+	Total    int    `json:"total"`
+	// Changed — сколько значений при нормализации меняют запись ("65 W" -> "65").
+	Changed int                          `json:"changed"`
+	Failed  []CharacteristicValueFailure `json:"failed"`
+	Applied bool                         `json:"applied"`
+}
+
+// CharacteristicValueFailure — значение, которое не удалось разобрать.
+type CharacteristicValueFailure struct {
+	ID          int    `json:"id"`
+	ProductID   int    `json:"product_id"`
+	ProductName string `json:"product_name,omitempty"`
+	Value       string `json:"value"`
+	Error       string `json:"error"`
+}
+
+// CharacteristicFacet — значения характеристики среди найденных товаров с количеством товаров.
+// Min/Max заполняются, если значения числовые (для фильтра-диапазона).
+type CharacteristicFacet struct {
+	Key    string       `json:"key"`
+	Name   string       `json:"name"`
+	Unit   string       `json:"unit,omitempty"`
+	Type   string       `json:"type"`
+	Values []FacetValue `json:"values"`
+	Min    *float64     `json:"min,omitempty"`
+	Max    *float64     `json:"max,omitempty"`
+}
+
+type FacetValue struct {
+	Value string   `json:"value"`
+	Count int      `json:"count"`
+	Num   *float64 `json:"-"`
 }
@@ -16,11 +16,79 @@ type Product struct {
 	SKU              string    `json:"sku,omitempty"`
 	CreatedAt        time.Time `json:"created_at,omitempty"`
 	UpdatedAt        time.Time `json:"updated_at,omitempty"`
+	// Rating заполняется в списке каталога и в карточке товара
+	Rating *ProductRating `json:"rating,omitempty"`
+	// Highlight заполняется только в результатах поиска по ?q=
+	Highlight *ProductHighlight `json:"highlight,omitempty"`
+}
+
+// ProductHighlight — фрагменты с подсвеченными совпадениями (<mark>…</mark>), остальной текст HTML-экранирован.
+type ProductHighlight struct {
+	Name        string `json:"name"`
+	Description string `json:"description,omitempty"`
 }
 
 type ProductDetail struct {
 	Product         Product                 `json:"product"`
+	Images          []ProductImage          `json:"images"`
 	Characteristics []ProductCharacteristic `json:"characteristics"`
 	Reviews         []Review                `json:"reviews"`
 	AverageRating   float64                 `json:"average_rating"`
 }
+
+// Варианты изображения товара.
+const (
+	ImageVariantThumb  = "thumb"
+	ImageVariantMedium = "medium"
+)
+
+// ProductImage — изображение товара; оригинал и уменьшенные копии лежат в storage.Files.
+type ProductImage struct {
+	ID          int                   `json:"id"`
+	ProductID   int                   `json:"product_id"`
+	Key         string                `json:"-"`
+	URL         string                `json:"url"`
+	ContentType string                `json:"content_type"`
+	Size        int64                 `json:"size"`
+	Width       int                   `json:"width"`
+	Height      int                   `json:"height"`
+	Position    int                   `json:"position"`
+	IsPrimary   bool                  `json:"is_primary"`
+	Variants    []ProductImageVariant `json:"variants"`
+	CreatedAt   time.Time             `json:"created_at"`
+}
+
+// ProductImageVariant — уменьшенная копия изображения (Name — thumb или medium).
+// Каждый вариант есть в исходном формате (JPEG или PNG) и в WebP.
+type ProductImageVariant struct {
+	Name        string `json:"name"`
+	Key         string `json:"-"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Width       int    `json:"width"`
+	Height      int    `json:"height"`
+}
+
+// ProductImageOrderPayload — новый порядок изображений товара (все id, от первого к последнему).
+type ProductImageOrderPayload struct {
+	ImageIDs []int `json:"image_ids"`
+}
+
+// Типы подсказок поиска.
+const (
+	SuggestProduct      = "product"
+	SuggestCategory     = "category"
+	SuggestManufacturer = "manufacturer"
+)
+
+// SearchSuggestion — подсказка для строки поиска: товар, категория или производитель.
+// SKU и ImagePath заполняются только у товаров.
+type SearchSuggestion struct {
+	Type      string  `json:"type"`
+	ID        int     `json:"id"`
+	Name      string  `json:"name"`
+	SKU       string  `json:"sku,omitempty"`
+	ImagePath string  `json:"image_path,omitempty"`
+	Score     float64 `json:"-"`
+}
@@ -0,0 +1,50 @@
+package models
+
+import "time"
+
+// Статусы заявки на возврат (RMA).
+const (
+	ReturnRequested = "requested"
+	ReturnApproved  = "approved"
+	ReturnRejected  = "rejected"
+	ReturnReceived  = "received"
+	ReturnRefunded  = "refunded"
+)
+
+type ReturnRequest struct {
+	ID           int                   `json:"id"`
+	OrderID      int                   `json:"order_id"`
+	OrderItemID  int                   `json:"order_item_id"`
+	UserID       int                   `json:"user_id"`
+	ProductID    int                   `json:"product_id"`
+	ProductName  string                `json:"product_name,omitempty"`
+	Quantity     int                   `json:"quantity"`
+	Reason       string                `json:"reason"`
+	Status       string                `json:"status"`
+	RefundAmount *float64              `json:"refund_amount,omitempty"`
+	AdminComment string                `json:"admin_comment,omitempty"`
+	CreatedAt    time.Time             `json:"created_at"`
+	UpdatedAt    time.Time             `json:"updated_at"`
+	History      []ReturnStatusHistory `json:"history,omitempty"`
+}
+
+type ReturnStatusHistory struct {
+	ID        int       `json:"id"`
+	ReturnID  int       `json:"return_id"`
+	Status    string    `json:"status"`
+	Comment   string    `json:"comment,omitempty"`
+	ChangedBy *int      `json:"changed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type CreateReturnPayload struct {
+	OrderItemID int    `json:"order_item_id"`
+	Quantity    int    `json:"quantity"`
+	Reason      string `json:"reason"`
+}
+
+type ReturnStatusPayload struct {
+	Status       string   `json:"status"`
+	Comment      string   `json:"comment,omitempty"`
+	RefundAmount *float64 `json:"refund_amount,omitempty"`
+}
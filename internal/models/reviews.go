@@ -2,12 +2,59 @@ package models
 
 import "time"
 
+// Статусы модерации отзыва. В рейтинг и на витрину попадают только одобренные.
+const (
+	ReviewPending  = "pending"
+	ReviewApproved = "approved"
+	ReviewRejected = "rejected"
+)
+
 type Review struct {
-	ID        int       `json:"id"`
-	ProductID int       `json:"product_id"`
-	UserID    int       `json:"user_id"`
-	UserName  string    `json:"user_name"`
-	Rating    int       `json:"rating"`
-	Comment   string    `json:"comment"`
-	CreatedAt time.Time `json:"created_at"`
+	ID               int           `json:"id"`
+	ProductID        int           `json:"product_id"`
+	ProductName      string        `json:"product_name,omitempty"`
+	UserID           int           `json:"user_id"`
+	UserName         string        `json:"user_name"`
+	Rating           int           `json:"rating"`
+	Comment          string        `json:"comment"`
+	Status           string        `json:"status"`
+	VerifiedPurchase bool          `json:"verified_purchase"`
+	HelpfulCount     int           `json:"helpful_count"`
+	UnhelpfulCount   int           `json:"unhelpful_count"`
+	Images           []ReviewImage `json:"images"`
+	ModerationNote   string        `json:"moderation_note,omitempty"`
+	ModeratedBy      *int          `json:"moderated_by,omitempty"`
+	ModeratedAt      *time.Time    `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time     `json:"created_at"`
+	UpdatedAt        *time.Time    `json:"updated_at,omitempty"`
+}
+
+// ReviewImage — фотография к отзыву; файл лежит в storage.Files под ключом Key.
+type ReviewImage struct {
+	ID          int    `json:"id"`
+	ReviewID    int    `json:"review_id"`
+	Key         string `json:"-"`
+	URL         string `json:"url"`
+	ContentType string `json:"content_type"`
+	Size        int64  `json:"size"`
+	Position    int    `json:"position"`
+}
+
+// ReviewVotePayload — голос за полезность отзыва.
+type ReviewVotePayload struct {
+	Helpful *bool `json:"helpful"`
+}
+
+// ReviewModerationPayload — решение модератора по отзыву.
+type ReviewModerationPayload struct {
+	Status string `json:"status"`
+	Note   string `json:"note"`
+}
+
+// ProductRating — агрегированный рейтинг товара. Distribution (оценка → количество отзывов)
+// заполняется только в карточке товара.
+type ProductRating struct {
+	Average      float64     `json:"average"`
+	Count        int         `json:"count"`
+	Distribution map[int]int `json:"distribution,omitempty"`
 }
@@ -0,0 +1,54 @@
+package models
+
+import "time"
+
+// Статусы отправления.
+const (
+	ShipmentPending        = "pending"
+	ShipmentShipped        = "shipped"
+	ShipmentInTransit      = "in_transit"
+	ShipmentOutForDelivery = "out_for_delivery"
+	ShipmentDelivered      = "delivered"
+	ShipmentFailed         = "failed"
+)
+
+type Shipment struct {
+	ID             int             `json:"id"`
+	OrderID        int             `json:"order_id"`
+	Carrier        string          `json:"carrier"`
+	TrackingNumber string          `json:"tracking_number"`
+	Status         string          `json:"status"`
+	ShippedAt      *time.Time      `json:"shipped_at,omitempty"`
+	DeliveredAt    *time.Time      `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time       `json:"created_at"`
+	UpdatedAt      time.Time       `json:"updated_at"`
+	Items          []ShipmentItem  `json:"items"`
+	Events         []ShipmentEvent `json:"events"`
+}
+
+type ShipmentItem struct {
+	OrderItemID int `json:"order_item_id"`
+	ProductID   int `json:"product_id,omitempty"`
+	Quantity    int `json:"quantity"`
+}
+
+type ShipmentEvent struct {
+	ID        int       `json:"id"`
+	Status    string    `json:"status"`
+	Location  string    `json:"location,omitempty"`
+	Comment   string    `json:"comment,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CreateShipmentPayload — если Items пуст, в отправление попадает весь неотгруженный остаток заказа.
+type CreateShipmentPayload struct {
+	Carrier        string         `json:"carrier"`
+	TrackingNumber string         `json:"tracking_number"`
+	Items          []ShipmentItem `json:"items,omitempty"`
+}
+
+type ShipmentStatusPayload struct {
+	Status   string `json:"status"`
+	Location string `json:"location,omitempty"`
+	Comment  string `json:"comment,omitempty"`
+}
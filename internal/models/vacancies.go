@@ -20,3 +20,44 @@ type VacancyPayload struct {
 	Conditions   string `json:"conditions"`
 	ContactEmail string `json:"contact_email"`
 }
+
+// Статусы отклика на вакансию.
+const (
+	ApplicationNew       = "new"
+	ApplicationScreening = "screening"
+	ApplicationInterview = "interview"
+	ApplicationRejected  = "rejected"
+	ApplicationHired     = "hired"
+)
+
+type VacancyApplication struct {
+	ID            int                              `json:"id"`
+	VacancyID     int                              `json:"vacancy_id"`
+	VacancyTitle  string                           `json:"vacancy_title,omitempty"`
+	FullName      string                           `json:"full_name"`
+	Email         string                           `json:"email"`
+	Phone         string                           `json:"phone,omitempty"`
+	CoverLetter   string                           `json:"cover_letter,omitempty"`
+	CVKey         string                           `json:"-"`
+	CVFilename    string                           `json:"cv_filename"`
+	CVContentType string                           `json:"cv_content_type"`
+	CVSize        int64                            `json:"cv_size"`
+	Status        string                           `json:"status"`
+	HRComment     string                           `json:"hr_comment,omitempty"`
+	CreatedAt     time.Time                        `json:"created_at"`
+	UpdatedAt     time.Time                        `json:"updated_at"`
+	History       []VacancyApplicationStatusChange `json:"history,omitempty"`
+}
+
+type VacancyApplicationStatusChange struct {
+	ID        int       `json:"id"`
+	Status    string    `json:"status"`
+	Comment   string    `json:"comment,omitempty"`
+	ChangedBy *int      `json:"changed_by,omitempty"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+type VacancyApplicationStatusPayload struct {
+	Status  string `json:"status"`
+	Comment string `json:"comment,omitempty"`
+}
@@ -0,0 +1,66 @@
+package ratelimit
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// sweepInterval — как часто MemoryStore выбрасывает заполнившиеся (неактивные) вёдра.
+const sweepInterval = time.Minute
+
+type bucket struct {
+	tokens float64
+	last   time.Time
+	policy Policy
+}
+
+// MemoryStore хранит вёдра в памяти процесса. Подходит для одного инстанса:
+// при нескольких репликах каждая считает свой лимит.
+type MemoryStore struct {
+	mu        sync.Mutex
+	buckets   map[string]*bucket
+	lastSweep time.Time
+	now       func() time.Time
+}
+
+// NewMemoryStore создаёт пустое хранилище.
+func NewMemoryStore() *MemoryStore {
+	return &MemoryStore{buckets: map[string]*bucket{}, now: time.Now, lastSweep: time.Now()}
+}
+
+func (s *MemoryStore) Take(_ context.Context, key string, p Policy) (Result, error) {
+	now := s.now()
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	if now.Sub(s.lastSweep) >= sweepInterval {
+		s.sweep(now)
+	}
+
+	b, ok := s.buckets[key]
+	if !ok {
+		b = &bucket{tokens: float64(p.Limit), last: now, policy: p}
+		s.buckets[key] = b
+	}
+	b.policy = p
+	b.tokens = min(float64(p.Limit), b.tokens+now.Sub(b.last).Seconds()*p.perSecond())
+	b.last = now
+
+	allowed := b.tokens >= 1
+	if allowed {
+		b.tokens--
+	}
+	return result(p, b.tokens, allowed), nil
+}
+
+// sweep удаляет вёдра, которые за время простоя уже заполнились бы целиком —
+// их состояние не отличается от нового ведра.
+func (s *MemoryStore) sweep(now time.Time) {
+	for k, b := range s.buckets {
+		if now.Sub(b.last) >= b.policy.Period {
+			delete(s.buckets, k)
+		}
+	}
+	s.lastSweep = now
+}
@@ -0,0 +1,67 @@
+package ratelimit
+
+import (
+	"context"
+	"database/sql"
+	"log/slog"
+	"time"
+)
+
+// PostgresStore хранит вёдра в таблице rate_limit_buckets, поэтому лимит общий для всех
+// инстансов приложения. Пополнение и списание токена выполняются одним UPSERT'ом
+// под блокировкой строки, так что параллельные запросы не обходят лимит.
+type PostgresStore struct {
+	db *sql.DB
+}
+
+// NewPostgresStore создаёт хранилище поверх пула db.
+func NewPostgresStore(db *sql.DB) *PostgresStore {
+	return &PostgresStore{db: db}
+}
+
+// takeSQL: $1 — ключ, $2 — ёмкость ведра, $3 — токенов в секунду.
+// refill — сколько токенов стало бы в ведре с учётом прошедшего времени.
+const takeSQL = `
+INSERT INTO rate_limit_buckets AS b (key, tokens, allowed, updated_at)
+VALUES ($1, $2::float8 - 1, TRUE, NOW())
+ON CONFLICT (key) DO UPDATE SET
+	allowed = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) >= 1,
+	tokens = LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8)
+		- CASE WHEN LEAST($2::float8, b.tokens + EXTRACT(EPOCH FROM NOW() - b.updated_at) * $3::float8) >= 1 THEN 1 ELSE 0 END,
+	updated_at = NOW()
+RETURNING tokens, allowed`
+
+func (s *PostgresStore) Take(ctx context.Context, key string, p Policy) (Result, error) {
+	var (
+		tokens  float64
+		allowed bool
+	)
+	if err := s.db.QueryRowContext(ctx, takeSQL, key, float64(p.Limit), p.perSecond()).Scan(&tokens, &allowed); err != nil {
+		return Result{}, err
+	}
+	return result(p, tokens, allowed), nil
+}
+
+// RunCleanup периодически удаляет вёдра, не использовавшиеся дольше idle. Блокируется до отмены ctx.
+func (s *PostgresStore) RunCleanup(ctx context.Context, every, idle time.Duration) {
+	ticker := time.NewTicker(every)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			res, err := s.db.ExecContext(ctx,
+				`DELETE FROM rate_limit_buckets WHERE updated_at < NOW() - make_interval(secs => $1)`, idle.Seconds())
+			if err != nil {
+				if ctx.Err() == nil {
+					slog.Warn("rate limit cleanup failed", "error", err)
+				}
+				continue
+			}
+			if n, _ := res.RowsAffected(); n > 0 {
+				slog.Debug("rate limit buckets cleaned up", "deleted", n)
+			}
+		}
+	}
+}
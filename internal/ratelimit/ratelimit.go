@@ -0,0 +1,111 @@
+// Package ratelimit — ограничение частоты запросов по алгоритму token bucket.
+// Ведро ёмкостью Policy.Limit пополняется равномерно: Limit токенов за Policy.Period.
+// Каждый запрос забирает токен; пустое ведро — отказ до накопления следующего токена.
+//
+// Хранилища: MemoryStore (один инстанс) и PostgresStore (общий счётчик для нескольких инстансов).
+package ratelimit
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Policy — лимит: Limit запросов за Period (с возможностью отправить их все подряд).
+type Policy struct {
+	Limit  int
+	Period time.Duration
+}
+
+// ParsePolicy разбирает запись вида "5/10m", "100/1m", "10/s". Пустая строка и "off" — лимит выключен.
+func ParsePolicy(s string) (Policy, error) {
+	s = strings.TrimSpace(s)
+	if s == "" || strings.EqualFold(s, "off") {
+		return Policy{}, nil
+	}
+	n, per, ok := strings.Cut(s, "/")
+	if !ok {
+		return Policy{}, fmt.Errorf("invalid rate %q (expected N/period, e.g. 5/10m)", s)
+	}
+	limit, err := strconv.Atoi(strings.TrimSpace(n))
+	if err != nil || limit <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate %q: limit must be a positive integer", s)
+	}
+	per = strings.TrimSpace(per)
+	// "10/s", "60/m", "1000/h" — без числа перед единицей
+	if per != "" && per[0] >= 'a' && per[0] <= 'z' {
+		per = "1" + per
+	}
+	period, err := time.ParseDuration(per)
+	if err != nil || period <= 0 {
+		return Policy{}, fmt.Errorf("invalid rate %q: bad period", s)
+	}
+	return Policy{Limit: limit, Period: period}, nil
+}
+
+// Enabled сообщает, что лимит задан.
+func (p Policy) Enabled() bool {
+	return p.Limit > 0 && p.Period > 0
+}
+
+// String — запись в том же формате, что принимает ParsePolicy.
+func (p Policy) String() string {
+	if !p.Enabled() {
+		return "off"
+	}
+	return strconv.Itoa(p.Limit) + "/" + p.Period.String()
+}
+
+// UnmarshalText позволяет хранить Policy прямо в конфиге.
+func (p *Policy) UnmarshalText(b []byte) error {
+	v, err := ParsePolicy(string(b))
+	if err != nil {
+		return err
+	}
+	*p = v
+	return nil
+}
+
+// perSecond — скорость пополнения ведра.
+func (p Policy) perSecond() float64 {
+	return float64(p.Limit) / p.Period.Seconds()
+}
+
+// Result — итог попытки взять токен.
+type Result struct {
+	Allowed    bool
+	Limit      int
+	Remaining  int
+	Reset      time.Duration // через сколько ведро снова будет полным
+	RetryAfter time.Duration // через сколько появится токен (только при отказе)
+}
+
+// Store — хранилище вёдер.
+type Store interface {
+	Take(ctx context.Context, key string, p Policy) (Result, error)
+}
+
+// result считает заголовочные величины по числу токенов после попытки.
+func result(p Policy, tokens float64, allowed bool) Result {
+	rate := p.perSecond()
+	r := Result{
+		Allowed:   allowed,
+		Limit:     p.Limit,
+		Remaining: max(0, int(math.Floor(tokens))),
+		Reset:     secondsToDuration((float64(p.Limit) - tokens) / rate),
+	}
+	if !allowed {
+		r.RetryAfter = secondsToDuration((1 - tokens) / rate)
+	}
+	return r
+}
+
+func secondsToDuration(s float64) time.Duration {
+	if s <= 0 {
+		return 0
+	}
+	return time.Duration(s * float64(time.Second))
+}
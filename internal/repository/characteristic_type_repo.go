@@ -3,72 +3,143 @@ package repository
 import (
 	"context"
 	"database/sql"
+	"strings"
 
 	"x86trade_backend/internal/db"
 	"x86trade_backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 func CreateCharacteristicType(ctx context.Context, t *models.CharacteristicType) (int, error) {
-	q := `INSERT INTO characteristic_types (name, unit, category_id) VALUES ($1, $2, $3) RETURNING id`
+	q := `INSERT INTO characteristic_types (name, code, unit, category_id, data_type, allowed_values, min_value, max_value)
+	      VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id`
 	var id int
-	err := db.DB.QueryRowContext(ctx, q, t.Name, nullableString(t.Unit), t.CategoryID).Scan(&id)
+	err := db.DB.QueryRowContext(ctx, q, t.Name, nullableString(t.Code), nullableString(t.Unit), t.CategoryID,
+		t.DataType, allowedValuesArg(t.AllowedValues), t.MinValue, t.MaxValue).Scan(&id)
 	return id, err
 }
 
-func GetCharacteristicTypeByID(ctx context.Context, id int) (*models.CharacteristicType, error) {
-	q := `SELECT id, name, unit, category_id FROM characteristic_types WHERE id=$1`
+const characteristicTypeSelect = `SELECT id, name, code, unit, category_id, data_type, allowed_values, min_value, max_value
+	FROM characteristic_types`
+
+func scanCharacteristicType(sc interface{ Scan(...interface{}) error }) (*models.CharacteristicType, error) {
 	var t models.CharacteristicType
-	var unit sql.NullString
+	var code, unit sql.NullString
 	var categoryID sql.NullInt64
-	err := db.DB.QueryRowContext(ctx, q, id).Scan(&t.ID, &t.Name, &unit, &categoryID)
-	if err == sql.ErrNoRows {
-		return nil, nil
-	}
-	if err != nil {
+	var minValue, maxValue sql.NullFloat64
+	if err := sc.Scan(&t.ID, &t.Name, &code, &unit, &categoryID, &t.DataType,
+		pq.Array(&t.AllowedValues), &minValue, &maxValue); err != nil {
 		return nil, err
 	}
+	t.Code = code.String
 	if unit.Valid {
 		t.Unit = unit.String
 	}
 	if categoryID.Valid {
 		t.CategoryID = int(categoryID.Int64)
 	}
+	if minValue.Valid {
+		t.MinValue = &minValue.Float64
+	}
+	if maxValue.Valid {
+		t.MaxValue = &maxValue.Float64
+	}
 	return &t, nil
 }
 
+func GetCharacteristicTypeByID(ctx context.Context, id int) (*models.CharacteristicType, error) {
+	t, err := scanCharacteristicType(db.DB.QueryRowContext(ctx, characteristicTypeSelect+` WHERE id=$1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return t, nil
+}
+
 func GetAllCharacteristicTypes(ctx context.Context) ([]models.CharacteristicType, error) {
-	q := `SELECT id, name, unit, category_id FROM characteristic_types ORDER BY name`
-	rows, err := db.DB.QueryContext(ctx, q)
+	rows, err := db.DB.QueryContext(ctx, characteristicTypeSelect+` ORDER BY name`)
 	if err != nil {
 		return nil, err
 	}
 	defer rows.Close()
 	var out []models.CharacteristicType
 	for rows.Next() {
-		var t models.CharacteristicType
-		var unit sql.NullString
-		var categoryID sql.NullInt64
-		if err := rows.Scan(&t.ID, &t.Name, &unit, &categoryID); err != nil {
+		t, err := scanCharacteristicType(rows)
+		if err != nil {
 			return nil, err
 		}
-		if unit.Valid {
-			t.Unit = unit.String
-		}
-		if categoryID.Valid {
-			t.CategoryID = int(categoryID.Int64)
-		}
-		out = append(out, t)
+		out = append(out, *t)
 	}
 	return out, rows.Err()
 }
 
+// UpdateCharacteristicType обновляет описание типа. Сохранённые значения при смене типа данных
+// не переписываются — для этого есть NormalizeCharacteristicValues.
 func UpdateCharacteristicType(ctx context.Context, t *models.CharacteristicType) error {
-	q := `UPDATE characteristic_types SET name=$1, unit=$2, category_id=$3 WHERE id=$4`
-	_, err := db.DB.ExecContext(ctx, q, t.Name, nullableString(t.Unit), t.CategoryID, t.ID)
+	q := `UPDATE characteristic_types
+	      SET name=$1, code=$2, unit=$3, category_id=$4, data_type=$5, allowed_values=$6, min_value=$7, max_value=$8
+	      WHERE id=$9`
+	_, err := db.DB.ExecContext(ctx, q, t.Name, nullableString(t.Code), nullableString(t.Unit), t.CategoryID,
+		t.DataType, allowedValuesArg(t.AllowedValues), t.MinValue, t.MaxValue, t.ID)
 	return err
 }
 
+// allowedValuesArg — пустой список хранится как NULL.
+func allowedValuesArg(v []string) interface{} {
+	if len(v) == 0 {
+		return nil
+	}
+	return pq.Array(v)
+}
+
 func DeleteCharacteristicType(ctx context.Context, id int) error {
 	_, err := db.DB.ExecContext(ctx, `DELETE FROM characteristic_types WHERE id=$1`, id)
 	return err
 }
+
+// ResolveCharacteristicKeys сопоставляет ключи фильтра каталога с типами характеристик:
+// ключ — это code, id или название типа (без учёта регистра). Одно название может быть
+// у нескольких типов (в разных категориях), поэтому на ключ приходится список id.
+// Ключей, которым ничего не соответствует, в результате нет.
+func ResolveCharacteristicKeys(ctx context.Context, keys []string) (map[string][]int, error) {
+	out := map[string][]int{}
+	if len(keys) == 0 {
+		return out, nil
+	}
+	lower := make([]string, len(keys))
+	for i, k := range keys {
+		lower[i] = strings.ToLower(k)
+	}
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT k.key, ct.id
+		FROM unnest($1::text[]) AS k(key)
+		JOIN characteristic_types ct
+		  ON lower(ct.code) = k.key OR ct.id::text = k.key OR lower(ct.name) = k.key
+		ORDER BY ct.id
+	`, pq.Array(lower))
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	byLower := map[string][]int{}
+	for rows.Next() {
+		var k string
+		var id int
+		if err := rows.Scan(&k, &id); err != nil {
+			return nil, err
+		}
+		byLower[k] = append(byLower[k], id)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	for i, k := range keys {
+		if ids := byLower[lower[i]]; len(ids) > 0 {
+			out[k] = ids
+		}
+	}
+	return out, nil
+}
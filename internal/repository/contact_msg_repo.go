@@ -2,18 +2,212 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
 	"time"
 	"x86trade_backend/internal/db"
 	"x86trade_backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 func CreateContactMessage(ctx context.Context, msg *models.ContactMessage) (int, error) {
 	var id int
+	reasons := msg.SpamReasons
+	if reasons == nil {
+		reasons = []string{}
+	}
 	err := db.DB.QueryRowContext(ctx, `
-		INSERT INTO contact_messages (full_name, contact_info, message, created_at, is_processed)
-		VALUES ($1, $2, $3, $4, $5)
+		INSERT INTO contact_messages (full_name, contact_info, message, created_at, is_processed,
+			is_spam, spam_score, spam_reasons, client_ip)
+		VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9)
 		RETURNING id
-	`, msg.FullName, msg.ContactInfo, msg.Message, time.Now(), false).Scan(&id)
+	`, msg.FullName, msg.ContactInfo, msg.Message, time.Now(), false,
+		msg.IsSpam, msg.SpamScore, pq.Array(reasons), nullableString(msg.ClientIP)).Scan(&id)
 
 	return id, err
 }
+
+// ErrContactAlreadyAnswered — на обращение уже ответили.
+var ErrContactAlreadyAnswered = errors.New("contact message already answered")
+
+const contactMessageSelect = `
+	SELECT id, full_name, contact_info, message, created_at, is_processed, response_message, response_at,
+	       is_spam, spam_score, spam_reasons, client_ip, assigned_to, assigned_at, responded_by
+	FROM contact_messages`
+
+func scanContactMessage(sc interface{ Scan(...interface{}) error }) (*models.ContactMessage, error) {
+	var m models.ContactMessage
+	var response, ip sql.NullString
+	var responseAt, assignedAt sql.NullTime
+	var assignedTo, respondedBy sql.NullInt64
+	if err := sc.Scan(&m.ID, &m.FullName, &m.ContactInfo, &m.Message, &m.CreatedAt, &m.IsProcessed,
+		&response, &responseAt, &m.IsSpam, &m.SpamScore, pq.Array(&m.SpamReasons), &ip,
+		&assignedTo, &assignedAt, &respondedBy); err != nil {
+		return nil, err
+	}
+	m.ResponseMessage = response.String
+	m.ClientIP = ip.String
+	if responseAt.Valid {
+		m.ResponseAt = &responseAt.Time
+	}
+	if assignedAt.Valid {
+		m.AssignedAt = &assignedAt.Time
+	}
+	if assignedTo.Valid {
+		v := int(assignedTo.Int64)
+		m.AssignedTo = &v
+	}
+	if respondedBy.Valid {
+		v := int(respondedBy.Int64)
+		m.RespondedBy = &v
+	}
+	return &m, nil
+}
+
+// contactMessageWhere строит WHERE по фильтру (пустая строка — без условий).
+func contactMessageWhere(f models.ContactMessageFilter) (string, []interface{}) {
+	conds := []string{}
+	args := []interface{}{}
+	if f.Processed != nil {
+		args = append(args, *f.Processed)
+		conds = append(conds, fmt.Sprintf("is_processed=$%d", len(args)))
+	}
+	if f.Spam != nil {
+		args = append(args, *f.Spam)
+		conds = append(conds, fmt.Sprintf("is_spam=$%d", len(args)))
+	}
+	if f.AssignedTo != nil {
+		args = append(args, *f.AssignedTo)
+		conds = append(conds, fmt.Sprintf("assigned_to=$%d", len(args)))
+	}
+	if f.From != nil {
+		args = append(args, *f.From)
+		conds = append(conds, fmt.Sprintf("created_at>=$%d", len(args)))
+	}
+	if f.To != nil {
+		args = append(args, *f.To)
+		conds = append(conds, fmt.Sprintf("created_at<$%d", len(args)))
+	}
+	if len(conds) == 0 {
+		return "", args
+	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
+
+// GetContactMessages возвращает обращения по фильтру, новые первыми.
+func GetContactMessages(ctx context.Context, f models.ContactMessageFilter) ([]models.ContactMessage, error) {
+	where, args := contactMessageWhere(f)
+	args = append(args, f.Limit, f.Offset)
+	q := contactMessageSelect + where +
+		fmt.Sprintf(" ORDER BY created_at DESC, id DESC LIMIT $%d OFFSET $%d", len(args)-1, len(args))
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.ContactMessage{}
+	for rows.Next() {
+		m, err := scanContactMessage(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *m)
+	}
+	return out, rows.Err()
+}
+
+// CountContactMessages — количество обращений по тому же фильтру (Limit/Offset не учитываются).
+func CountContactMessages(ctx context.Context, f models.ContactMessageFilter) (int, error) {
+	where, args := contactMessageWhere(f)
+	var count int
+	err := db.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM contact_messages`+where, args...).Scan(&count)
+	return count, err
+}
+
+// GetContactMessageByID возвращает обращение (nil, nil если не найдено).
+func GetContactMessageByID(ctx context.Context, id int) (*models.ContactMessage, error) {
+	m, err := scanContactMessage(db.DB.QueryRowContext(ctx, contactMessageSelect+" WHERE id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return m, err
+}
+
+// SetContactMessageProcessed отмечает обращение обработанным (или снимает отметку).
+// sql.ErrNoRows — обращения нет.
+func SetContactMessageProcessed(ctx context.Context, id int, processed bool) error {
+	res, err := db.DB.ExecContext(ctx, `UPDATE contact_messages SET is_processed=$1 WHERE id=$2`, processed, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// AssignContactMessage назначает обращение администратору; adminID == nil снимает назначение.
+// sql.ErrNoRows — обращения нет.
+func AssignContactMessage(ctx context.Context, id int, adminID *int) error {
+	res, err := db.DB.ExecContext(ctx, `
+		UPDATE contact_messages
+		SET assigned_to=$1, assigned_at=CASE WHEN $1::int IS NULL THEN NULL ELSE NOW() END
+		WHERE id=$2
+	`, adminID, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
+
+// SaveContactReply записывает ответ администратора и закрывает обращение. Ответ сначала
+// «захватывается» в транзакции (UPDATE ... WHERE response_at IS NULL), затем вызывается send
+// (отправка письма): параллельный ответ другого администратора ждёт на блокировке строки и
+// получает ErrContactAlreadyAnswered, не отправив письма. Если send вернул ошибку, транзакция
+// откатывается — обращение остаётся неотвеченным, а ошибка send возвращается как есть.
+// sql.ErrNoRows — обращения нет; ErrContactAlreadyAnswered — ответ уже был.
+func SaveContactReply(ctx context.Context, id, adminID int, reply string, send func() error) (at time.Time, err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return at, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE contact_messages
+		SET response_message=$1, response_at=NOW(), responded_by=$2, is_processed=TRUE
+		WHERE id=$3 AND response_at IS NULL
+		RETURNING response_at
+	`, reply, adminID, id).Scan(&at)
+	if errors.Is(err, sql.ErrNoRows) {
+		var exists bool
+		if err = tx.QueryRowContext(ctx, `SELECT EXISTS (SELECT 1 FROM contact_messages WHERE id=$1)`, id).Scan(&exists); err != nil {
+			return at, err
+		}
+		if exists {
+			err = ErrContactAlreadyAnswered
+		} else {
+			err = sql.ErrNoRows
+		}
+		return at, err
+	}
+	if err != nil {
+		return at, err
+	}
+
+	if err = send(); err != nil {
+		return at, err
+	}
+	return at, tx.Commit()
+}
@@ -2,6 +2,7 @@ package repository
 
 import (
 	"context"
+	"database/sql"
 	"time"
 
 	"x86trade_backend/internal/db"
@@ -9,7 +10,7 @@ import (
 )
 
 func GetDeliveryMethods(ctx context.Context) ([]models.DeliveryMethod, error) {
-	rows, err := db.DB.QueryContext(ctx, `SELECT id, name, description, base_cost, free_threshold, estimated_days FROM delivery_methods ORDER BY id`)
+	rows, err := db.DB.QueryContext(ctx, `SELECT id, name, description, base_cost, free_threshold, estimated_days, requires_address FROM delivery_methods ORDER BY id`)
 	if err != nil {
 		return nil, err
 	}
@@ -17,7 +18,7 @@ func GetDeliveryMethods(ctx context.Context) ([]models.DeliveryMethod, error) {
 	var out []models.DeliveryMethod
 	for rows.Next() {
 		var d models.DeliveryMethod
-		if err := rows.Scan(&d.ID, &d.Name, &d.Description, &d.BaseCost, &d.FreeThreshold, &d.EstimatedDays); err != nil {
+		if err := rows.Scan(&d.ID, &d.Name, &d.Description, &d.BaseCost, &d.FreeThreshold, &d.EstimatedDays, &d.RequiresAddress); err != nil {
 			return nil, err
 		}
 		out = append(out, d)
@@ -25,15 +26,30 @@ func GetDeliveryMethods(ctx context.Context) ([]models.DeliveryMethod, error) {
 	return out, nil
 }
 
+// GetDeliveryMethodByID возвращает метод доставки по id (nil, nil если не найден).
+func GetDeliveryMethodByID(ctx context.Context, id int) (*models.DeliveryMethod, error) {
+	var d models.DeliveryMethod
+	err := db.DB.QueryRowContext(ctx,
+		`SELECT id, name, description, base_cost, free_threshold, estimated_days, requires_address FROM delivery_methods WHERE id=$1`, id).
+		Scan(&d.ID, &d.Name, &d.Description, &d.BaseCost, &d.FreeThreshold, &d.EstimatedDays, &d.RequiresAddress)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &d, nil
+}
+
 // CreateDeliveryMethod вставляет метод доставки и возвращает id.
 func CreateDeliveryMethod(ctx context.Context, d *models.DeliveryMethod) (int, error) {
 	q := `INSERT INTO delivery_methods
-	       (name, description, base_cost, free_threshold, estimated_days, created_at)
-	      VALUES ($1,$2,$3,$4,$5,$6) RETURNING id`
+	       (name, description, base_cost, free_threshold, estimated_days, requires_address, created_at)
+	      VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id`
 	var id int
 	now := time.Now().UTC()
 	err := db.DB.QueryRowContext(ctx, q,
-		d.Name, d.Description, d.BaseCost, d.FreeThreshold, d.EstimatedDays, now).Scan(&id)
+		d.Name, d.Description, d.BaseCost, d.FreeThreshold, d.EstimatedDays, d.RequiresAddress, now).Scan(&id)
 	return id, err
 }
 
@@ -44,10 +60,11 @@ func UpdateDeliveryMethod(ctx context.Context, d *models.DeliveryMethod) error {
 	        description = $2,
 	        base_cost = $3,
 	        free_threshold = $4,
-	        estimated_days = $5
-	      WHERE id = $6`
+	        estimated_days = $5,
+	        requires_address = $6
+	      WHERE id = $7`
 	_, err := db.DB.ExecContext(ctx, q,
-		d.Name, d.Description, d.BaseCost, d.FreeThreshold, d.EstimatedDays, d.ID)
+		d.Name, d.Description, d.BaseCost, d.FreeThreshold, d.EstimatedDays, d.RequiresAddress, d.ID)
 	return err
 }
 
@@ -0,0 +1,108 @@
+package repository
+
+import (
+	"fmt"
+	"strings"
+
+	"x86trade_backend/internal/cursor"
+)
+
+// CursorPage — курсоры соседних страниц при keyset-пагинации (пусто — страницы нет).
+type CursorPage struct {
+	Next string
+	Prev string
+}
+
+// keyset — ключи сортировки списка. Все ключи в одном направлении: тогда «строки после
+// курсора» — одно сравнение строк (k1, k2) < ($1, $2), которое умеет использовать индекс.
+// Последний ключ должен быть уникальным (обычно id), иначе порядок неоднозначен.
+type keyset struct {
+	exprs []string
+	desc  bool
+}
+
+// orderBy возвращает ORDER BY; для обратного прохода (предыдущая страница) — в обратном порядке.
+func (k keyset) orderBy(backward bool) string {
+	dir := "ASC"
+	if k.desc != backward {
+		dir = "DESC"
+	}
+	parts := make([]string, len(k.exprs))
+	for i, e := range k.exprs {
+		parts[i] = e + " " + dir
+	}
+	return " ORDER BY " + strings.Join(parts, ", ")
+}
+
+// columns — ключи сортировки в виде text для SELECT (из них строятся курсоры).
+func (k keyset) columns() string {
+	parts := make([]string, len(k.exprs))
+	for i, e := range k.exprs {
+		parts[i] = "(" + e + ")::text"
+	}
+	return strings.Join(parts, ", ")
+}
+
+// after — условие «строки за курсором» с параметрами, добавленными в args.
+func (k keyset) after(c *cursor.Cursor, args *[]interface{}) (string, error) {
+	if len(c.Keys) != len(k.exprs) {
+		return "", cursor.ErrInvalid
+	}
+	params := make([]string, len(c.Keys))
+	for i, v := range c.Keys {
+		*args = append(*args, v)
+		params[i] = fmt.Sprintf("$%d", len(*args))
+	}
+	op := ">"
+	if k.desc != c.Backward {
+		op = "<"
+	}
+	return fmt.Sprintf("(%s) %s (%s)", strings.Join(k.exprs, ", "), op, strings.Join(params, ", ")), nil
+}
+
+// cutPage доводит до страницы выборку из limit+1 строк: отрезает лишнюю строку, разворачивает
+// выборку обратного прохода и строит курсоры соседних страниц. keys[i] — ключи строки items[i].
+func cutPage[T any](items []T, keys [][]string, limit int, c *cursor.Cursor, sort string) ([]T, CursorPage) {
+	backward := c != nil && c.Backward
+	more := len(items) > limit
+	if more {
+		items, keys = items[:limit], keys[:limit]
+	}
+	if backward {
+		for i, j := 0, len(items)-1; i < j; i, j = i+1, j-1 {
+			items[i], items[j] = items[j], items[i]
+			keys[i], keys[j] = keys[j], keys[i]
+		}
+	}
+
+	var page CursorPage
+	if len(items) == 0 {
+		// за курсором пусто — можно только вернуться назад от той же позиции
+		if c != nil {
+			back := cursor.Encode(cursor.Cursor{Sort: sort, Keys: c.Keys, Backward: !backward})
+			if backward {
+				page.Next = back
+			} else {
+				page.Prev = back
+			}
+		}
+		return items, page
+	}
+
+	first := cursor.Cursor{Sort: sort, Keys: keys[0], Backward: true}
+	last := cursor.Cursor{Sort: sort, Keys: keys[len(keys)-1]}
+	if backward {
+		page.Next = cursor.Encode(last)
+		if more {
+			page.Prev = cursor.Encode(first)
+		}
+	} else {
+		if more {
+			page.Next = cursor.Encode(last)
+		}
+		if c != nil {
+			page.Prev = cursor.Encode(first)
+		}
+	}
+	return items, page
+}
@@ -4,22 +4,43 @@ import (
 	"context"
 	"database/sql"
 	"errors"
+	"fmt"
 	"time"
 
+	"x86trade_backend/internal/cursor"
 	"x86trade_backend/internal/db"
 	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/tracing"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
 )
 
+// ErrCartEmpty — попытка оформить заказ с пустой корзиной.
+var ErrCartEmpty = errors.New("cart empty")
+
 // Helper: get cart items for user (reuse existing GetCartByUserID)...
 // Создание заказа в транзакции.
-func CreateOrderFromCart(ctx context.Context, userID int, deliveryMethodID *int, address, recipientName, recipientPhone, comment string) (int, error) {
+func CreateOrderFromCart(ctx context.Context, userID int, deliveryMethodID *int, address, recipientName, recipientPhone, comment string) (id int, err error) {
+	// отдельный спан, чтобы в трассе было видно, сколько занимает весь оформляющий заказ блок
+	ctx, span := tracing.Tracer().Start(ctx, "repository.CreateOrderFromCart",
+		trace.WithAttributes(attribute.Int("user.id", userID)))
+	defer func() {
+		if err != nil && !errors.Is(err, ErrCartEmpty) {
+			span.RecordError(err)
+			span.SetStatus(codes.Error, err.Error())
+		}
+		span.End()
+	}()
+
 	// 1) достать корзину
 	cartItems, err := GetCartByUserID(ctx, userID)
 	if err != nil {
 		return 0, err
 	}
 	if len(cartItems) == 0 {
-		return 0, errors.New("cart empty")
+		return 0, ErrCartEmpty
 	}
 
 	tx, err := db.DB.BeginTx(ctx, nil)
@@ -79,9 +100,14 @@ func CreateOrderFromCart(ctx context.Context, userID int, deliveryMethodID *int,
 			return 0, err
 		}
 	}
+	if err = adjustUnitsSold(ctx, tx, orderID, 1); err != nil {
+		tx.Rollback()
+		return 0, err
+	}
 
-	// вставка данных доставки (если переданы)
-	if deliveryMethodID != nil && address != "" {
+	// вставка данных доставки: строка нужна при любом способе доставки, в том числе
+	// при самовывозе (requires_address = false) — тогда адрес пустой
+	if deliveryMethodID != nil {
 		_, err = tx.ExecContext(ctx,
 			`INSERT INTO order_deliveries (order_id, delivery_method_id, address, recipient_name, recipient_phone, status) VALUES ($1,$2,$3,$4,$5,$6)`,
 			orderID, *deliveryMethodID, address, recipientName, recipientPhone, "pending")
@@ -187,9 +213,12 @@ func GetOrderDelivery(ctx context.Context, orderID int) (*models.OrderDelivery,
 }
 
 // GetOrdersWithPagination возвращает список заказов с пагинацией
+// orderKeys — порядок списка заказов в админке: новые сверху.
+var orderKeys = keyset{exprs: []string{"COALESCE(created_at, '-infinity')", "id"}, desc: true}
+
 func GetOrdersWithPagination(ctx context.Context, limit, offset int) ([]models.Order, error) {
 	q := `SELECT id, user_id, status, total_amount, created_at, updated_at, comment 
-		  FROM orders ORDER BY created_at DESC LIMIT $1 OFFSET $2`
+		  FROM orders` + orderKeys.orderBy(false) + ` LIMIT $1 OFFSET $2`
 
 	rows, err := db.DB.QueryContext(ctx, q, limit, offset)
 	if err != nil {
@@ -199,24 +228,81 @@ func GetOrdersWithPagination(ctx context.Context, limit, offset int) ([]models.O
 
 	var orders []models.Order
 	for rows.Next() {
-		var o models.Order
-		var created, updated sql.NullTime
-
-		if err := rows.Scan(&o.ID, &o.UserID, &o.Status, &o.TotalAmount, &created, &updated, &o.Comment); err != nil {
+		o, _, err := scanOrderRow(rows, false)
+		if err != nil {
 			return nil, err
 		}
+		orders = append(orders, *o)
+	}
 
-		if created.Valid {
-			o.CreatedAt = created.Time
+	return orders, nil
+}
+
+// GetOrdersByCursor — страница списка заказов при курсорной пагинации (c == nil — первая).
+func GetOrdersByCursor(ctx context.Context, limit int, c *cursor.Cursor) ([]models.Order, CursorPage, error) {
+	args := []interface{}{}
+	where := ""
+	if c != nil {
+		if c.Sort != "" {
+			return nil, CursorPage{}, cursor.ErrInvalid
 		}
-		if updated.Valid {
-			o.UpdatedAt = updated.Time
+		cond, err := orderKeys.after(c, &args)
+		if err != nil {
+			return nil, CursorPage{}, err
 		}
+		where = " WHERE " + cond
+	}
+	args = append(args, limit+1)
+	q := `SELECT id, user_id, status, total_amount, created_at, updated_at, comment, ` + orderKeys.columns() + `
+		  FROM orders` + where + orderKeys.orderBy(c != nil && c.Backward) + fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, CursorPage{}, err
+	}
+	defer rows.Close()
 
-		orders = append(orders, o)
+	var orders []models.Order
+	var keys [][]string
+	for rows.Next() {
+		o, key, err := scanOrderRow(rows, true)
+		if err != nil {
+			return nil, CursorPage{}, err
+		}
+		orders = append(orders, *o)
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, CursorPage{}, err
 	}
 
-	return orders, nil
+	orders, page := cutPage(orders, keys, limit, c, "")
+	return orders, page, nil
+}
+
+// scanOrderRow читает строку списка заказов; withKeys — за колонками заказа идут ключи orderKeys.
+func scanOrderRow(rows *sql.Rows, withKeys bool) (*models.Order, []string, error) {
+	var o models.Order
+	var created, updated sql.NullTime
+	dest := []interface{}{&o.ID, &o.UserID, &o.Status, &o.TotalAmount, &created, &updated, &o.Comment}
+	var key []string
+	if withKeys {
+		key = make([]string, len(orderKeys.exprs))
+		for i := range key {
+			dest = append(dest, &key[i])
+		}
+	}
+	if err := rows.Scan(dest...); err != nil {
+		return nil, nil, err
+	}
+
+	if created.Valid {
+		o.CreatedAt = created.Time
+	}
+	if updated.Valid {
+		o.UpdatedAt = updated.Time
+	}
+	return &o, key, nil
 }
 
 // CountOrders возвращает общее количество заказов
@@ -227,12 +313,70 @@ func CountOrders(ctx context.Context) (int, error) {
 }
 
 // UpdateOrderStatus обновляет статус заказа
-func UpdateOrderStatus(ctx context.Context, orderID int, status string) error {
-	_, err := db.DB.ExecContext(ctx, `
+func UpdateOrderStatus(ctx context.Context, orderID int, status string) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// прежний статус под блокировкой: от него зависит, менять ли units_sold
+	var prev sql.NullString
+	err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id = $1 FOR UPDATE`, orderID).Scan(&prev)
+	if errors.Is(err, sql.ErrNoRows) {
+		// заказа нет — обновлять нечего
+		return tx.Rollback()
+	}
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx, `
 		UPDATE orders 
 		SET status = $1, updated_at = NOW() 
 		WHERE id = $2
-	`, status, orderID)
+	`, status, orderID); err != nil {
+		return err
+	}
 
-	return err
+	// отменённый заказ не считается в популярности товаров, восстановленный — снова считается
+	wasCancelled, isCancelled := prev.String == "cancelled", status == "cancelled"
+	switch {
+	case isCancelled && !wasCancelled:
+		err = adjustUnitsSold(ctx, tx, orderID, -1)
+	case wasCancelled && !isCancelled:
+		err = adjustUnitsSold(ctx, tx, orderID, 1)
+	}
+	if err != nil {
+		return err
+	}
+
+	if status == "delivered" {
+		// доставленный заказ подтверждает покупку для отзывов на его товары
+		if err = markVerifiedReviews(ctx, tx, orderID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// adjustUnitsSold прибавляет (sign = 1) или вычитает (sign = -1) количества товаров заказа
+// из products.units_sold. Инкремент идёт под блокировкой строки товара, поэтому параллельные
+// заказы одного товара не теряют друг друга.
+func adjustUnitsSold(ctx context.Context, q txQuerier, orderID, sign int) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE products p SET units_sold = GREATEST(p.units_sold + $2 * s.units, 0)
+		  FROM (SELECT product_id, SUM(quantity) AS units
+		          FROM order_items
+		         WHERE order_id = $1 AND product_id IS NOT NULL
+		         GROUP BY product_id) s
+		 WHERE p.id = s.product_id`, orderID, sign)
+	if err != nil {
+		return fmt.Errorf("adjust units sold: %w", err)
+	}
+	return nil
 }
@@ -2,22 +2,94 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
 
+	"x86trade_backend/internal/charvalue"
+	"x86trade_backend/internal/cursor"
 	"x86trade_backend/internal/db"
 	"x86trade_backend/internal/models"
 )
 
-// CreateProductCharacteristic вставляет одну запись и возвращает id.
+// CharacteristicValueError — значение не подходит под тип характеристики.
+// Index — позиция во входном списке ReplaceProductCharacteristics (0 для одиночной записи).
+type CharacteristicValueError struct {
+	Index  int
+	TypeID int
+	Err    error
+}
+
+func (e CharacteristicValueError) Error() string {
+	return fmt.Sprintf("characteristic %d: %v", e.TypeID, e.Err)
+}
+
+// CharacteristicValueErrors — все непрошедшие проверку значения запроса.
+type CharacteristicValueErrors []CharacteristicValueError
+
+func (e CharacteristicValueErrors) Error() string {
+	parts := make([]string, len(e))
+	for i, ve := range e {
+		parts[i] = ve.Error()
+	}
+	return "invalid characteristic values: " + strings.Join(parts, "; ")
+}
+
+var ErrUnknownCharacteristicType = errors.New("characteristic type does not exist")
+
+// normalizeInputs загружает типы и нормализует значения; ошибки по всем позициям собираются разом.
+func normalizeInputs(ctx context.Context, inputs []models.ProductCharacteristicInput) ([]charvalue.Value, error) {
+	types := map[int]*models.CharacteristicType{}
+	out := make([]charvalue.Value, len(inputs))
+	var errs CharacteristicValueErrors
+	for i, in := range inputs {
+		t, ok := types[in.CharacteristicTypeID]
+		if !ok {
+			var err error
+			if t, err = GetCharacteristicTypeByID(ctx, in.CharacteristicTypeID); err != nil {
+				return nil, err
+			}
+			types[in.CharacteristicTypeID] = t
+		}
+		if t == nil {
+			errs = append(errs, CharacteristicValueError{Index: i, TypeID: in.CharacteristicTypeID, Err: ErrUnknownCharacteristicType})
+			continue
+		}
+		v, err := charvalue.Normalize(t, in.Value)
+		if err != nil {
+			errs = append(errs, CharacteristicValueError{Index: i, TypeID: in.CharacteristicTypeID, Err: err})
+			continue
+		}
+		out[i] = v
+	}
+	if len(errs) > 0 {
+		return nil, errs
+	}
+	return out, nil
+}
+
+// CreateProductCharacteristic проверяет и нормализует значение по типу характеристики,
+// вставляет запись и возвращает id. Неподходящее значение — CharacteristicValueErrors.
 func CreateProductCharacteristic(ctx context.Context, in *models.ProductCharacteristicInput) (int, error) {
-	q := `INSERT INTO product_characteristics (product_id, characteristic_type_id, value) VALUES ($1,$2,$3) RETURNING id`
+	vals, err := normalizeInputs(ctx, []models.ProductCharacteristicInput{*in})
+	if err != nil {
+		return 0, err
+	}
+	q := `INSERT INTO product_characteristics (product_id, characteristic_type_id, value, value_num) VALUES ($1,$2,$3,$4) RETURNING id`
 	var id int
-	err := db.DB.QueryRowContext(ctx, q, in.ProductID, in.CharacteristicTypeID, in.Value).Scan(&id)
+	err = db.DB.QueryRowContext(ctx, q, in.ProductID, in.CharacteristicTypeID, vals[0].Text, vals[0].Num).Scan(&id)
 	return id, err
 }
 
 func UpdateProductCharacteristic(ctx context.Context, in *models.ProductCharacteristicInput) error {
-	q := `UPDATE product_characteristics SET characteristic_type_id=$1, value=$2 WHERE id=$3`
-	_, err := db.DB.ExecContext(ctx, q, in.CharacteristicTypeID, in.Value, in.ID)
+	vals, err := normalizeInputs(ctx, []models.ProductCharacteristicInput{*in})
+	if err != nil {
+		return err
+	}
+	q := `UPDATE product_characteristics SET characteristic_type_id=$1, value=$2, value_num=$3 WHERE id=$4`
+	_, err = db.DB.ExecContext(ctx, q, in.CharacteristicTypeID, vals[0].Text, vals[0].Num, in.ID)
 	return err
 }
 
@@ -28,7 +100,13 @@ func DeleteProductCharacteristic(ctx context.Context, id int) error {
 
 // ReplaceProductCharacteristics — транзакционно заменяет все характеристики товара:
 // удаляет старые и вставляет новые (useful when editing product details form).
+// Значения проверяются до начала транзакции: при любой ошибке ничего не меняется.
 func ReplaceProductCharacteristics(ctx context.Context, productID int, inputs []models.ProductCharacteristicInput) error {
+	vals, err := normalizeInputs(ctx, inputs)
+	if err != nil {
+		return err
+	}
+
 	tx, err := db.DB.BeginTx(ctx, nil)
 	if err != nil {
 		return err
@@ -45,14 +123,14 @@ func ReplaceProductCharacteristics(ctx context.Context, productID int, inputs []
 	}
 
 	// вставляем новые
-	stmt, err := tx.PrepareContext(ctx, `INSERT INTO product_characteristics (product_id, characteristic_type_id, value) VALUES ($1,$2,$3)`)
+	stmt, err := tx.PrepareContext(ctx, `INSERT INTO product_characteristics (product_id, characteristic_type_id, value, value_num) VALUES ($1,$2,$3,$4)`)
 	if err != nil {
 		return err
 	}
 	defer stmt.Close()
 
-	for _, in := range inputs {
-		if _, err := stmt.ExecContext(ctx, productID, in.CharacteristicTypeID, in.Value); err != nil {
+	for i, in := range inputs {
+		if _, err = stmt.ExecContext(ctx, productID, in.CharacteristicTypeID, vals[i].Text, vals[i].Num); err != nil {
 			return err
 		}
 	}
@@ -63,8 +141,87 @@ func ReplaceProductCharacteristics(ctx context.Context, productID int, inputs []
 	return nil
 }
 
-func GetAllProductCharacteristicsWithPagination(ctx context.Context, limit, offset int) ([]models.ProductCharacteristic, error) {
+// NormalizeCharacteristicValues проверяет сохранённые значения характеристики t под её тип данных.
+// apply=false — только отчёт; apply=true — распознанные значения переписываются в каноническом
+// виде (и с value_num), нераспознанные остаются как есть и попадают в отчёт.
+func NormalizeCharacteristicValues(ctx context.Context, t *models.CharacteristicType, apply bool) (*models.CharacteristicMigrationReport, error) {
 	rows, err := db.DB.QueryContext(ctx, `
+		SELECT pc.id, pc.product_id, COALESCE(p.name, ''), pc.value, pc.value_num
+		FROM product_characteristics pc
+		LEFT JOIN products p ON p.id = pc.product_id
+		WHERE pc.characteristic_type_id = $1
+		ORDER BY pc.id
+	`, t.ID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	type update struct {
+		id  int
+		val charvalue.Value
+	}
+	report := &models.CharacteristicMigrationReport{
+		TypeID: t.ID, Name: t.Name, DataType: t.DataType,
+		Failed: []models.CharacteristicValueFailure{},
+	}
+	var updates []update
+	for rows.Next() {
+		var id, productID int
+		var productName, value string
+		var num sql.NullFloat64
+		if err := rows.Scan(&id, &productID, &productName, &value, &num); err != nil {
+			return nil, err
+		}
+		report.Total++
+		v, err := charvalue.Normalize(t, value)
+		if err != nil {
+			report.Failed = append(report.Failed, models.CharacteristicValueFailure{
+				ID: id, ProductID: productID, ProductName: productName, Value: value, Error: err.Error(),
+			})
+			continue
+		}
+		if v.Text != value || num.Valid != (v.Num != nil) || (v.Num != nil && *v.Num != num.Float64) {
+			report.Changed++
+			updates = append(updates, update{id: id, val: v})
+		}
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if !apply || len(updates) == 0 {
+		report.Applied = apply
+		return report, nil
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+	stmt, err := tx.PrepareContext(ctx, `UPDATE product_characteristics SET value=$1, value_num=$2 WHERE id=$3`)
+	if err != nil {
+		return nil, err
+	}
+	defer stmt.Close()
+	for _, u := range updates {
+		if _, err = stmt.ExecContext(ctx, u.val.Text, u.val.Num, u.id); err != nil {
+			return nil, err
+		}
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	report.Applied = true
+	return report, nil
+}
+
+// productCharacteristicListQuery — общий список значений характеристик для админки.
+const productCharacteristicListQuery = `
         SELECT pc.id, pc.product_id,
                COALESCE(p.name, '') as product_name,
                COALESCE(ct.name, '') as characteristic_name,
@@ -72,10 +229,14 @@ func GetAllProductCharacteristicsWithPagination(ctx context.Context, limit, offs
                pc.value
         FROM product_characteristics pc
         LEFT JOIN products p ON pc.product_id = p.id
-        LEFT JOIN characteristic_types ct ON pc.characteristic_type_id = ct.id
-        ORDER BY pc.id
-        LIMIT $1 OFFSET $2
-    `, limit, offset)
+        LEFT JOIN characteristic_types ct ON pc.characteristic_type_id = ct.id`
+
+// productCharacteristicKeys — порядок общего списка: по id значения.
+var productCharacteristicKeys = keyset{exprs: []string{"pc.id"}}
+
+func GetAllProductCharacteristicsWithPagination(ctx context.Context, limit, offset int) ([]models.ProductCharacteristic, error) {
+	rows, err := db.DB.QueryContext(ctx, productCharacteristicListQuery+
+		productCharacteristicKeys.orderBy(false)+` LIMIT $1 OFFSET $2`, limit, offset)
 	if err != nil {
 		return nil, err
 	}
@@ -95,6 +256,48 @@ func GetAllProductCharacteristicsWithPagination(ctx context.Context, limit, offs
 	return out, nil
 }
 
+// GetAllProductCharacteristicsByCursor — страница общего списка при курсорной пагинации (c == nil — первая).
+func GetAllProductCharacteristicsByCursor(ctx context.Context, limit int, c *cursor.Cursor) ([]models.ProductCharacteristic, CursorPage, error) {
+	args := []interface{}{}
+	where := ""
+	if c != nil {
+		if c.Sort != "" {
+			return nil, CursorPage{}, cursor.ErrInvalid
+		}
+		cond, err := productCharacteristicKeys.after(c, &args)
+		if err != nil {
+			return nil, CursorPage{}, err
+		}
+		where = " WHERE " + cond
+	}
+	args = append(args, limit+1)
+	q := productCharacteristicListQuery + where +
+		productCharacteristicKeys.orderBy(c != nil && c.Backward) + fmt.Sprintf(" LIMIT $%d", len(args))
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, CursorPage{}, err
+	}
+	defer rows.Close()
+
+	var out []models.ProductCharacteristic
+	var keys [][]string
+	for rows.Next() {
+		var c models.ProductCharacteristic
+		if err := rows.Scan(&c.ID, &c.ProductID, &c.ProductName, &c.CharacteristicName, &c.CharacteristicUnit, &c.Value); err != nil {
+			return nil, CursorPage{}, err
+		}
+		out = append(out, c)
+		keys = append(keys, []string{strconv.Itoa(c.ID)})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, CursorPage{}, err
+	}
+
+	out, page := cutPage(out, keys, limit, c, "")
+	return out, page, nil
+}
+
 func CountProductCharacteristics(ctx context.Context) (int, error) {
 	var count int
 	err := db.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM product_characteristics`).Scan(&count)
@@ -0,0 +1,151 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"sort"
+	"strconv"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+// maxFacetValues — сколько самых частых значений отдаём на одну характеристику.
+const maxFacetValues = 100
+
+// GetProductFacets считает значения характеристик среди товаров, подходящих под фильтр
+// (без учёта Limit/Offset). Для характеристики, по которой уже фильтруют, счётчики считаются
+// без её собственного условия — так видно, сколько товаров даст выбор другого значения.
+func GetProductFacets(ctx context.Context, f *ProductFilter) ([]models.CharacteristicFacet, error) {
+	facets := map[int]*models.CharacteristicFacet{}
+
+	var filtered []int
+	if f != nil {
+		for _, cf := range f.Characteristics {
+			filtered = append(filtered, cf.TypeIDs...)
+		}
+	}
+
+	// все характеристики, по которым не фильтруют, — по полному набору условий
+	where, args := productWhere(f, -1)
+	extra := ""
+	if len(filtered) > 0 {
+		args = append(args, pq.Array(filtered))
+		extra = fmt.Sprintf("pc.characteristic_type_id <> ALL($%d)", len(args))
+	}
+	if err := collectFacets(ctx, facets, where, extra, args); err != nil {
+		return nil, err
+	}
+
+	// по каждой выбранной характеристике — без её условия
+	if f != nil {
+		for n, cf := range f.Characteristics {
+			where, args := productWhere(f, n)
+			args = append(args, pq.Array(cf.TypeIDs))
+			extra := fmt.Sprintf("pc.characteristic_type_id = ANY($%d)", len(args))
+			if err := collectFacets(ctx, facets, where, extra, args); err != nil {
+				return nil, err
+			}
+		}
+	}
+
+	out := make([]models.CharacteristicFacet, 0, len(facets))
+	for _, fc := range facets {
+		finishFacet(fc)
+		out = append(out, *fc)
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if out[i].Name != out[j].Name {
+			return out[i].Name < out[j].Name
+		}
+		return out[i].Key < out[j].Key
+	})
+	return out, nil
+}
+
+func collectFacets(ctx context.Context, facets map[int]*models.CharacteristicFacet, where, extra string, args []interface{}) error {
+	if extra != "" {
+		if where == "" {
+			where = " WHERE " + extra
+		} else {
+			where += " AND " + extra
+		}
+	}
+	q := `SELECT ct.id, COALESCE(ct.code, ''), ct.name, COALESCE(ct.unit, ''), ct.data_type,
+                    min(pc.value), min(pc.value_num), COUNT(DISTINCT p.id)` +
+		productFrom + `
+             JOIN product_characteristics pc ON pc.product_id = p.id
+             JOIN characteristic_types ct ON ct.id = pc.characteristic_type_id` +
+		where + `
+             GROUP BY ct.id, ct.code, ct.name, ct.unit, ct.data_type, lower(pc.value)`
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var id, count int
+		var code, name, unit, dataType, value string
+		var num sql.NullFloat64
+		if err := rows.Scan(&id, &code, &name, &unit, &dataType, &value, &num, &count); err != nil {
+			return err
+		}
+		fc, ok := facets[id]
+		if !ok {
+			key := code
+			if key == "" {
+				key = strconv.Itoa(id)
+			}
+			fc = &models.CharacteristicFacet{Key: key, Name: name, Unit: unit, Type: dataType}
+			facets[id] = fc
+		}
+		fv := models.FacetValue{Value: value, Count: count}
+		if num.Valid {
+			fv.Num = &num.Float64
+		}
+		fc.Values = append(fc.Values, fv)
+	}
+	return rows.Err()
+}
+
+// finishFacet упорядочивает значения (числовые — по возрастанию, остальные — самые частые
+// первыми), обрезает хвост и для числовых характеристик заполняет Min/Max.
+func finishFacet(fc *models.CharacteristicFacet) {
+	numeric := fc.Type == models.CharNumber || fc.Type == models.CharInteger
+	if numeric {
+		for _, v := range fc.Values {
+			if v.Num == nil {
+				continue // ещё не нормализованное значение
+			}
+			if fc.Min == nil || *v.Num < *fc.Min {
+				fc.Min = v.Num
+			}
+			if fc.Max == nil || *v.Num > *fc.Max {
+				fc.Max = v.Num
+			}
+		}
+	}
+
+	sort.Slice(fc.Values, func(i, j int) bool {
+		a, b := fc.Values[i], fc.Values[j]
+		if numeric {
+			if (a.Num == nil) != (b.Num == nil) {
+				return a.Num != nil
+			}
+			if a.Num != nil && *a.Num != *b.Num {
+				return *a.Num < *b.Num
+			}
+		}
+		if a.Count != b.Count {
+			return a.Count > b.Count
+		}
+		return a.Value < b.Value
+	})
+	if len(fc.Values) > maxFacetValues {
+		fc.Values = fc.Values[:maxFacetValues]
+	}
+}
@@ -0,0 +1,336 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/imaging"
+	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrTooManyProductImages — превышен лимит изображений товара.
+	ErrTooManyProductImages = errors.New("too many product images")
+	// ErrImageOrderMismatch — в новом порядке перечислены не все изображения товара или есть лишние.
+	ErrImageOrderMismatch = errors.New("image order must list every product image exactly once")
+)
+
+// GetProductImages возвращает изображения товара по порядку, с вариантами.
+func GetProductImages(ctx context.Context, productID int) ([]models.ProductImage, error) {
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT id, product_id, storage_key, content_type, size_bytes, width, height, position, is_primary, created_at
+		  FROM product_images WHERE product_id = $1
+		 ORDER BY position, id`, productID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	images := []models.ProductImage{}
+	for rows.Next() {
+		var img models.ProductImage
+		if err := rows.Scan(&img.ID, &img.ProductID, &img.Key, &img.ContentType, &img.Size,
+			&img.Width, &img.Height, &img.Position, &img.IsPrimary, &img.CreatedAt); err != nil {
+			return nil, err
+		}
+		img.URL = storage.PublicURL(img.Key)
+		img.Variants = []models.ProductImageVariant{}
+		images = append(images, img)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	return images, attachImageVariants(ctx, images)
+}
+
+func attachImageVariants(ctx context.Context, images []models.ProductImage) error {
+	if len(images) == 0 {
+		return nil
+	}
+	ids := make([]int, len(images))
+	byID := make(map[int]*models.ProductImage, len(images))
+	for i := range images {
+		ids[i] = images[i].ID
+		byID[images[i].ID] = &images[i]
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT image_id, name, storage_key, content_type, size_bytes, width, height
+		  FROM product_image_variants WHERE image_id = ANY($1)
+		 ORDER BY image_id, width, content_type`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var imageID int
+		var v models.ProductImageVariant
+		if err := rows.Scan(&imageID, &v.Name, &v.Key, &v.ContentType, &v.Size, &v.Width, &v.Height); err != nil {
+			return err
+		}
+		v.URL = storage.PublicURL(v.Key)
+		if img := byID[imageID]; img != nil {
+			img.Variants = append(img.Variants, v)
+		}
+	}
+	return rows.Err()
+}
+
+// AddProductImages добавляет изображения в конец списка (файлы уже в хранилище).
+// Всего у товара может быть не больше maxImages изображений (ErrTooManyProductImages).
+// Если главного изображения ещё нет, им становится первое из добавленных.
+// sql.ErrNoRows — товар не найден.
+func AddProductImages(ctx context.Context, productID int, images []models.ProductImage, maxImages int) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = tx.QueryRowContext(ctx, `SELECT id FROM products WHERE id = $1 FOR UPDATE`, productID).Scan(&productID); err != nil {
+		return err
+	}
+	var count, lastPos int
+	var hasPrimary bool
+	if err = tx.QueryRowContext(ctx, `
+		SELECT COUNT(*), COALESCE(MAX(position), 0), COALESCE(BOOL_OR(is_primary), FALSE)
+		  FROM product_images WHERE product_id = $1`, productID).
+		Scan(&count, &lastPos, &hasPrimary); err != nil {
+		return err
+	}
+	if count+len(images) > maxImages {
+		err = ErrTooManyProductImages
+		return err
+	}
+
+	for i := range images {
+		img := &images[i]
+		img.ProductID = productID
+		img.Position = lastPos + i + 1
+		img.IsPrimary = !hasPrimary && i == 0
+		if err = tx.QueryRowContext(ctx, `
+			INSERT INTO product_images (product_id, storage_key, content_type, size_bytes, width, height, position, is_primary)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id, created_at`,
+			productID, img.Key, img.ContentType, img.Size, img.Width, img.Height, img.Position, img.IsPrimary).
+			Scan(&img.ID, &img.CreatedAt); err != nil {
+			return err
+		}
+		img.URL = storage.PublicURL(img.Key)
+		for j := range img.Variants {
+			v := &img.Variants[j]
+			if _, err = tx.ExecContext(ctx, `
+				INSERT INTO product_image_variants (image_id, name, storage_key, content_type, size_bytes, width, height)
+				VALUES ($1, $2, $3, $4, $5, $6, $7)`,
+				img.ID, v.Name, v.Key, v.ContentType, v.Size, v.Width, v.Height); err != nil {
+				return err
+			}
+			v.URL = storage.PublicURL(v.Key)
+		}
+	}
+
+	if !hasPrimary {
+		if err = syncProductImagePath(ctx, tx, productID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+// SetPrimaryProductImage делает изображение главным. sql.ErrNoRows — изображение не найдено.
+func SetPrimaryProductImage(ctx context.Context, productID, imageID int) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var isPrimary bool
+	if err = tx.QueryRowContext(ctx,
+		`SELECT is_primary FROM product_images WHERE id = $1 AND product_id = $2 FOR UPDATE`, imageID, productID).
+		Scan(&isPrimary); err != nil {
+		return err
+	}
+	if isPrimary {
+		return tx.Commit()
+	}
+	// два запроса, а не один: уникальный индекс проверяется построчно
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE product_images SET is_primary = FALSE WHERE product_id = $1 AND is_primary`, productID); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE product_images SET is_primary = TRUE WHERE id = $1`, imageID); err != nil {
+		return err
+	}
+	if err = syncProductImagePath(ctx, tx, productID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// ReorderProductImages расставляет изображения в порядке ids; перечислены должны быть
+// все изображения товара ровно по одному разу (ErrImageOrderMismatch).
+func ReorderProductImages(ctx context.Context, productID int, ids []int) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	rows, err := tx.QueryContext(ctx,
+		`SELECT id FROM product_images WHERE product_id = $1 FOR UPDATE`, productID)
+	if err != nil {
+		return err
+	}
+	current := map[int]bool{}
+	for rows.Next() {
+		var id int
+		if err = rows.Scan(&id); err != nil {
+			rows.Close()
+			return err
+		}
+		current[id] = true
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+
+	if len(ids) != len(current) {
+		err = ErrImageOrderMismatch
+		return err
+	}
+	for _, id := range ids {
+		if !current[id] {
+			err = ErrImageOrderMismatch
+			return err
+		}
+		delete(current, id) // повтор id тоже ошибка
+	}
+
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE product_images pi SET position = o.pos
+		  FROM unnest($2::int[]) WITH ORDINALITY AS o(id, pos)
+		 WHERE pi.id = o.id AND pi.product_id = $1`, productID, pq.Array(ids)); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteProductImage удаляет изображение и возвращает ключи его файлов (оригинал и варианты;
+// удаляет их вызывающий). Если изображение было главным, главным становится первое из оставшихся.
+// sql.ErrNoRows — изображение не найдено.
+func DeleteProductImage(ctx context.Context, productID, imageID int) (keys []string, err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var isPrimary bool
+	if err = tx.QueryRowContext(ctx,
+		`SELECT is_primary FROM product_images WHERE id = $1 AND product_id = $2 FOR UPDATE`, imageID, productID).
+		Scan(&isPrimary); err != nil {
+		return nil, err
+	}
+	if keys, err = queryStrings(ctx, tx, `
+		SELECT storage_key FROM product_images WHERE id = $1
+		UNION ALL
+		SELECT storage_key FROM product_image_variants WHERE image_id = $1`, imageID); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM product_images WHERE id = $1`, imageID); err != nil {
+		return nil, err
+	}
+
+	if isPrimary {
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE product_images SET is_primary = TRUE
+			 WHERE id = (SELECT id FROM product_images WHERE product_id = $1 ORDER BY position, id LIMIT 1)`,
+			productID); err != nil {
+			return nil, err
+		}
+		if err = syncProductImagePath(ctx, tx, productID); err != nil {
+			return nil, err
+		}
+	}
+	return keys, tx.Commit()
+}
+
+// productFileKeys — ключи всех файлов товара: изображения, их варианты и фотографии к отзывам.
+func productFileKeys(ctx context.Context, q txQuerier, productID int) ([]string, error) {
+	return queryStrings(ctx, q, `
+		SELECT pi.storage_key FROM product_images pi WHERE pi.product_id = $1
+		UNION ALL
+		SELECT v.storage_key FROM product_image_variants v
+		  JOIN product_images pi ON pi.id = v.image_id
+		 WHERE pi.product_id = $1
+		UNION ALL
+		SELECT ri.storage_key FROM review_images ri
+		  JOIN reviews r ON r.id = ri.review_id
+		 WHERE r.product_id = $1`, productID)
+}
+
+// syncProductImagePath записывает в products.image_path ссылку на превью главного изображения
+// (medium в исходном формате), чтобы карточки в каталоге не грузили оригиналы.
+// Если изображений не осталось, image_path очищается — но только если он указывал на
+// загруженный файл, а не был задан вручную.
+func syncProductImagePath(ctx context.Context, tx *sql.Tx, productID int) error {
+	var key string
+	err := tx.QueryRowContext(ctx, `
+		SELECT COALESCE(
+		         (SELECT v.storage_key FROM product_image_variants v
+		           WHERE v.image_id = pi.id AND v.name = $2 AND v.content_type <> $3),
+		         pi.storage_key)
+		  FROM product_images pi
+		 WHERE pi.product_id = $1 AND pi.is_primary`,
+		productID, models.ImageVariantMedium, imaging.WebP).Scan(&key)
+	if errors.Is(err, sql.ErrNoRows) {
+		_, err = tx.ExecContext(ctx, `UPDATE products SET image_path = NULL WHERE id = $1 AND image_path LIKE $2`,
+			productID, escapeLike(storage.PublicURL("products/"))+"%")
+		return err
+	}
+	if err != nil {
+		return err
+	}
+	_, err = tx.ExecContext(ctx, `UPDATE products SET image_path = $2 WHERE id = $1`, productID, storage.PublicURL(key))
+	return err
+}
+
+func queryStrings(ctx context.Context, q txQuerier, query string, args ...interface{}) ([]string, error) {
+	rows, err := q.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var out []string
+	for rows.Next() {
+		var s string
+		if err := rows.Scan(&s); err != nil {
+			return nil, err
+		}
+		out = append(out, s)
+	}
+	return out, rows.Err()
+}
@@ -0,0 +1,83 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+// ratingLockClass — первый ключ advisory-блокировки пересчёта рейтинга (второй — id товара).
+const ratingLockClass = 4701
+
+// productRatingUpsert пересчитывает рейтинг по одобренным отзывам. %s — условие на товары
+// (для одного товара — p.id = $1). Товары без отзывов получают нулевую строку.
+const productRatingUpsert = `
+INSERT INTO product_ratings (product_id, rating_count, rating_sum, rating_avg,
+                             count_1, count_2, count_3, count_4, count_5, updated_at)
+SELECT p.id, COUNT(r.id), COALESCE(SUM(r.rating), 0), ROUND(AVG(r.rating), 2),
+       COUNT(r.id) FILTER (WHERE r.rating = 1), COUNT(r.id) FILTER (WHERE r.rating = 2),
+       COUNT(r.id) FILTER (WHERE r.rating = 3), COUNT(r.id) FILTER (WHERE r.rating = 4),
+       COUNT(r.id) FILTER (WHERE r.rating = 5), NOW()
+  FROM products p
+  LEFT JOIN reviews r ON r.product_id = p.id AND r.status = 'approved'
+ WHERE %s
+ GROUP BY p.id
+ON CONFLICT (product_id) DO UPDATE SET
+    rating_count = EXCLUDED.rating_count,
+    rating_sum   = EXCLUDED.rating_sum,
+    rating_avg   = EXCLUDED.rating_avg,
+    count_1      = EXCLUDED.count_1,
+    count_2      = EXCLUDED.count_2,
+    count_3      = EXCLUDED.count_3,
+    count_4      = EXCLUDED.count_4,
+    count_5      = EXCLUDED.count_5,
+    updated_at   = EXCLUDED.updated_at`
+
+// refreshProductRating пересчитывает рейтинг товара внутри транзакции, изменившей его отзывы.
+// Блокировка на товар сериализует параллельные пересчёты: следующий начнётся после коммита
+// предыдущего и увидит его отзыв.
+func refreshProductRating(ctx context.Context, tx *sql.Tx, productID int) error {
+	if _, err := tx.ExecContext(ctx, `SELECT pg_advisory_xact_lock($1, $2)`, ratingLockClass, productID); err != nil {
+		return fmt.Errorf("lock product rating: %w", err)
+	}
+	if _, err := tx.ExecContext(ctx, fmt.Sprintf(productRatingUpsert, "p.id = $1"), productID); err != nil {
+		return fmt.Errorf("refresh product rating: %w", err)
+	}
+	return nil
+}
+
+// RecomputeProductRatings пересчитывает рейтинги из reviews: одного товара (productID > 0)
+// или всех. Возвращает число обновлённых товаров.
+func RecomputeProductRatings(ctx context.Context, productID int) (int64, error) {
+	var res sql.Result
+	var err error
+	if productID > 0 {
+		res, err = db.DB.ExecContext(ctx, fmt.Sprintf(productRatingUpsert, "p.id = $1"), productID)
+	} else {
+		res, err = db.DB.ExecContext(ctx, fmt.Sprintf(productRatingUpsert, "TRUE"))
+	}
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetProductRating возвращает рейтинг товара с распределением оценок (нулевой, если отзывов нет).
+func GetProductRating(ctx context.Context, productID int) (models.ProductRating, error) {
+	var avg sql.NullFloat64
+	var c [5]int
+	rating := models.ProductRating{}
+	err := db.DB.QueryRowContext(ctx, `
+		SELECT rating_count, rating_avg, count_1, count_2, count_3, count_4, count_5
+		  FROM product_ratings WHERE product_id = $1`, productID).
+		Scan(&rating.Count, &avg, &c[0], &c[1], &c[2], &c[3], &c[4])
+	if err != nil && err != sql.ErrNoRows {
+		return rating, err
+	}
+	rating.Average = avg.Float64
+	rating.Distribution = map[int]int{1: c[0], 2: c[1], 3: c[2], 4: c[3], 5: c[4]}
+	return rating, nil
+}
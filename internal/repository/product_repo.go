@@ -4,12 +4,16 @@ import (
 	"context"
 	"database/sql"
 	"fmt"
-	"log"
+	"html"
 	"strings"
 	"time"
 
+	"x86trade_backend/internal/cursor"
 	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/logging"
 	"x86trade_backend/internal/models"
+
+	"github.com/lib/pq"
 )
 
 type ProductFilter struct {
@@ -19,21 +23,94 @@ type ProductFilter struct {
 	ManufacturerName *string
 	MinPrice         *float64
 	MaxPrice         *float64
+	MinRating        *float64
 	Q                *string
-	Limit            int
-	Offset           int
+	// Fuzzy — искать Q по триграммам названия и SKU вместо полнотекстового поиска
+	// (запасной режим, когда из-за опечатки полнотекстовый поиск ничего не нашёл).
+	Fuzzy           bool
+	Characteristics []CharacteristicFilter
+	// Sort — один из вариантов productSorts; пусто — по релевантности при поиске, иначе новые сверху.
+	Sort   string
+	Limit  int
+	Offset int
+	// Keyset — курсорная пагинация вместо Offset; Cursor — позиция (nil — первая страница).
+	Keyset bool
+	Cursor *cursor.Cursor
 }
 
-func GetProducts(ctx context.Context, f *ProductFilter) ([]models.Product, error) {
-	base := `SELECT p.id, p.name, p.description, p.price, p.category_id, 
-                    COALESCE(c.name,'') AS category_name, 
-                    p.manufacturer_id, 
-                    COALESCE(m.name,'') AS manufacturer_name, 
-                    p.image_path, p.stock_quantity, p.sku, 
-                    p.created_at, p.updated_at
+// Варианты сортировки каталога (?sort=).
+const (
+	SortPriceAsc   = "price_asc"
+	SortPriceDesc  = "price_desc"
+	SortNewest     = "newest"
+	SortRating     = "rating"
+	SortPopularity = "popularity"
+	SortName       = "name"
+)
+
+// Сортировки поиска без явного ?sort=: попадают только в курсор, через ?sort= их не выбрать.
+const (
+	SortRelevance  = "relevance"  // полнотекстовый поиск
+	SortSimilarity = "similarity" // нечёткий поиск (Fuzzy)
+)
+
+// productSorts — ключи сортировки для каждого варианта; p.id в конце делает порядок однозначным,
+// иначе страницы при равных ключах могут пересекаться. NULL заменены значением «в конец
+// списка»: сравнение строк с NULL в курсоре не работает.
+var productSorts = map[string]keyset{
+	SortPriceAsc:  {exprs: []string{"p.price", "p.id"}},
+	SortPriceDesc: {exprs: []string{"p.price", "p.id"}, desc: true},
+	SortNewest:    {exprs: []string{"COALESCE(p.created_at, '-infinity')", "p.id"}, desc: true},
+	// при равном среднем выше товар с большим числом отзывов
+	SortRating: {exprs: []string{"COALESCE(pr.rating_avg, 0)", "COALESCE(pr.rating_count, 0)", "p.id"}, desc: true},
+	// популярность — продано штук по неотменённым заказам (products.units_sold)
+	SortPopularity: {exprs: []string{"p.units_sold", "p.id"}, desc: true},
+	SortName:       {exprs: []string{"p.name", "p.id"}},
+}
+
+// IsProductSort проверяет, что s — известный вариант сортировки.
+func IsProductSort(s string) bool {
+	_, ok := productSorts[s]
+	return ok
+}
+
+// CharacteristicFilter — условие по характеристике: значение из списка Values (без учёта
+// регистра) или число в диапазоне [Min, Max]. TypeIDs — типы, на которые указал ключ фильтра.
+type CharacteristicFilter struct {
+	Key     string
+	TypeIDs []int
+	Values  []string
+	Min     *float64
+	Max     *float64
+}
+
+// charNumericExpr — числовое значение характеристики (заполняется при записи для number/integer/boolean).
+const charNumericExpr = `pc.value_num`
+
+// productSearchQuery — tsquery по пользовательскому вводу в параметре $n: совпадение
+// в русской или английской морфологии.
+func productSearchQuery(n int) string {
+	return fmt.Sprintf("(websearch_to_tsquery('russian', $%d) || websearch_to_tsquery('english', $%d))", n, n)
+}
+
+// searchText возвращает непустой поисковый запрос фильтра.
+func (f *ProductFilter) searchText() (string, bool) {
+	if f == nil || f.Q == nil {
+		return "", false
+	}
+	q := strings.TrimSpace(*f.Q)
+	return q, q != ""
+}
+
+const productFrom = `
              FROM products p
              LEFT JOIN categories c ON p.category_id = c.id
-             LEFT JOIN manufacturers m ON p.manufacturer_id = m.id`
+             LEFT JOIN manufacturers m ON p.manufacturer_id = m.id
+             LEFT JOIN product_ratings pr ON pr.product_id = p.id`
+
+// productWhere строит WHERE по фильтру. skipChar — индекс условия по характеристике,
+// которое нужно пропустить (для подсчёта фасета этой же характеристики), -1 — не пропускать.
+func productWhere(f *ProductFilter, skipChar int) (string, []interface{}) {
 	conds := []string{}
 	args := []interface{}{}
 	i := 1
@@ -69,52 +146,175 @@ func GetProducts(ctx context.Context, f *ProductFilter) ([]models.Product, error
 			args = append(args, *f.MaxPrice)
 			i++
 		}
-		if f.Q != nil && strings.TrimSpace(*f.Q) != "" {
-			search := "%" + strings.TrimSpace(*f.Q) + "%"
-			conds = append(conds, fmt.Sprintf("(p.name ILIKE $%d OR p.description ILIKE $%d)", i, i+1))
-			args = append(args, search, search)
-			i += 2
+		if f.MinRating != nil {
+			conds = append(conds, fmt.Sprintf("pr.rating_avg >= $%d", i))
+			args = append(args, *f.MinRating)
+			i++
+		}
+		if search, ok := f.searchText(); ok {
+			if f.Fuzzy {
+				conds = append(conds, fmt.Sprintf("($%d <%% p.name OR $%d <%% p.sku)", i, i))
+			} else {
+				conds = append(conds, "p.search_vector @@ "+productSearchQuery(i))
+			}
+			args = append(args, search)
+			i++
+		}
+		for n, cf := range f.Characteristics {
+			if n == skipChar {
+				continue
+			}
+			sub := []string{fmt.Sprintf("pc.characteristic_type_id = ANY($%d)", i)}
+			args = append(args, pq.Array(cf.TypeIDs))
+			i++
+			if len(cf.Values) > 0 {
+				lower := make([]string, len(cf.Values))
+				for k, v := range cf.Values {
+					lower[k] = strings.ToLower(v)
+				}
+				sub = append(sub, fmt.Sprintf("lower(pc.value) = ANY($%d)", i))
+				args = append(args, pq.Array(lower))
+				i++
+			}
+			if cf.Min != nil {
+				sub = append(sub, fmt.Sprintf("%s >= $%d", charNumericExpr, i))
+				args = append(args, *cf.Min)
+				i++
+			}
+			if cf.Max != nil {
+				sub = append(sub, fmt.Sprintf("%s <= $%d", charNumericExpr, i))
+				args = append(args, *cf.Max)
+				i++
+			}
+			conds = append(conds, "EXISTS (SELECT 1 FROM product_characteristics pc WHERE pc.product_id = p.id AND "+
+				strings.Join(sub, " AND ")+")")
 		}
 	}
 
-	if len(conds) > 0 {
-		base = base + " WHERE " + strings.Join(conds, " AND ")
+	if len(conds) == 0 {
+		return "", args
 	}
+	return " WHERE " + strings.Join(conds, " AND "), args
+}
 
-	base = base + " ORDER BY p.id DESC"
+// GetProducts возвращает страницу каталога. В режиме f.Keyset страница задаётся курсором
+// f.Cursor (nil — первая), и в CursorPage возвращаются курсоры соседних страниц; иначе — Limit/Offset.
+func GetProducts(ctx context.Context, f *ProductFilter) ([]models.Product, CursorPage, error) {
+	if f == nil {
+		f = &ProductFilter{}
+	}
+	base := `SELECT p.id, p.name, p.description, p.price, p.category_id, 
+                    COALESCE(c.name,'') AS category_name, 
+                    p.manufacturer_id, 
+                    COALESCE(m.name,'') AS manufacturer_name, 
+                    p.image_path, p.stock_quantity, p.sku, 
+                    p.created_at, p.updated_at,
+                    COALESCE(pr.rating_avg, 0), COALESCE(pr.rating_count, 0)`
+	where, args := productWhere(f, -1)
+
+	// при поиске — релевантность и подсветка; запрос передаём отдельным параметром
+	search, searching := f.searchText()
+	sortName := f.Sort
+	keys := keyset{exprs: []string{"p.id"}, desc: true}
+	if f.Sort != "" {
+		keys = productSorts[f.Sort]
+	}
+	if searching {
+		if f.Fuzzy {
+			base += `,
+                    NULL, NULL`
+			if f.Sort == "" {
+				args = append(args, search)
+				n := len(args)
+				sortName = SortSimilarity
+				keys = keyset{exprs: []string{
+					fmt.Sprintf("GREATEST(word_similarity($%d, p.name), word_similarity($%d, COALESCE(p.sku,'')))", n, n), "p.id"}, desc: true}
+			}
+		} else {
+			args = append(args, search)
+			tsq := productSearchQuery(len(args))
+			base += fmt.Sprintf(`,
+                    ts_headline('russian', p.name, %[1]s, 'StartSel=<mark>, StopSel=</mark>, HighlightAll=true'),
+                    ts_headline('russian', COALESCE(p.description,''), %[1]s, 'StartSel=<mark>, StopSel=</mark>, MaxWords=30, MinWords=10, MaxFragments=2')`, tsq)
+			if f.Sort == "" {
+				sortName = SortRelevance
+				keys = keyset{exprs: []string{fmt.Sprintf("ts_rank_cd(p.search_vector, %s)", tsq), "p.id"}, desc: true}
+			}
+		}
+	}
+	if f.Keyset {
+		base += ",\n                    " + keys.columns()
+	}
+	if f.Keyset && f.Cursor != nil {
+		if f.Cursor.Sort != sortName {
+			return nil, CursorPage{}, cursor.ErrInvalid
+		}
+		cond, err := keys.after(f.Cursor, &args)
+		if err != nil {
+			return nil, CursorPage{}, err
+		}
+		if where == "" {
+			where = " WHERE " + cond
+		} else {
+			where += " AND " + cond
+		}
+	}
+	base += productFrom + where + keys.orderBy(f.Keyset && f.Cursor != nil && f.Cursor.Backward)
 
-	if f != nil && f.Limit > 0 {
-		base = base + fmt.Sprintf(" LIMIT %d", f.Limit)
+	if f.Limit > 0 {
+		limit := f.Limit
+		if f.Keyset {
+			limit++ // лишняя строка — признак следующей страницы
+		}
+		args = append(args, limit)
+		base += fmt.Sprintf(" LIMIT $%d", len(args))
 	}
-	if f != nil && f.Offset > 0 {
-		base = base + fmt.Sprintf(" OFFSET %d", f.Offset)
+	if f.Offset > 0 && !f.Keyset {
+		args = append(args, f.Offset)
+		base += fmt.Sprintf(" OFFSET $%d", len(args))
 	}
 
-	// Добавляем логирование для отладки
-	log.Printf("Executing query: %s", base)
-	log.Printf("With args: %v", args)
+	// SQL логируем только на уровне debug и без значений параметров (в них пользовательский ввод)
+	logger := logging.FromContext(ctx)
+	logger.Debug("products query", "sql", base, "args_count", len(args))
 
 	rows, err := db.DB.QueryContext(ctx, base, args...)
 	if err != nil {
-		log.Printf("Error executing query: %v", err)
-		return nil, fmt.Errorf("database query error: %w", err)
+		logger.Error("products query failed", "error", err)
+		return nil, CursorPage{}, fmt.Errorf("database query error: %w", err)
 	}
 	defer rows.Close()
 
 	var out []models.Product
+	var rowKeys [][]string
 	for rows.Next() {
 		var p models.Product
 		var created, updated sql.NullTime
 		var categoryName, manufacturerName, description, imagePath, sku sql.NullString
 		var stockQuantity sql.NullInt64
+		var nameHL, descriptionHL sql.NullString
+		rating := &models.ProductRating{}
+		p.Rating = rating
 
-		err := rows.Scan(&p.ID, &p.Name, &description, &p.Price, &p.CategoryID,
+		dest := []interface{}{&p.ID, &p.Name, &description, &p.Price, &p.CategoryID,
 			&categoryName, &p.ManufacturerID, &manufacturerName,
-			&imagePath, &stockQuantity, &sku, &created, &updated)
+			&imagePath, &stockQuantity, &sku, &created, &updated, &rating.Average, &rating.Count}
+		if searching {
+			dest = append(dest, &nameHL, &descriptionHL)
+		}
+		var key []string
+		if f.Keyset {
+			key = make([]string, len(keys.exprs))
+			for i := range key {
+				dest = append(dest, &key[i])
+			}
+		}
+		err := rows.Scan(dest...)
 		if err != nil {
-			log.Printf("Error scanning row: %v", err)
-			return nil, fmt.Errorf("row scan error: %w", err)
+			logger.Error("products row scan failed", "error", err)
+			return nil, CursorPage{}, fmt.Errorf("row scan error: %w", err)
 		}
+		rowKeys = append(rowKeys, key)
 
 		// Обработка NULL значений
 		if description.Valid {
@@ -141,16 +341,43 @@ func GetProducts(ctx context.Context, f *ProductFilter) ([]models.Product, error
 		if updated.Valid {
 			p.UpdatedAt = updated.Time
 		}
+		if nameHL.Valid {
+			p.Highlight = &models.ProductHighlight{Name: safeHeadline(nameHL.String)}
+			if strings.Contains(descriptionHL.String, "<mark>") {
+				p.Highlight.Description = safeHeadline(descriptionHL.String)
+			}
+		}
 		out = append(out, p)
 	}
 
 	// Проверяем ошибки после цикла
 	if err := rows.Err(); err != nil {
-		log.Printf("Error iterating rows: %v", err)
-		return nil, fmt.Errorf("rows iteration error: %w", err)
+		logger.Error("products rows iteration failed", "error", err)
+		return nil, CursorPage{}, fmt.Errorf("rows iteration error: %w", err)
 	}
 
-	return out, nil
+	if !f.Keyset {
+		return out, CursorPage{}, nil
+	}
+	out, page := cutPage(out, rowKeys, f.Limit, f.Cursor, sortName)
+	return out, page, nil
+}
+
+// CountProducts возвращает число товаров, подходящих под фильтр (без учёта Limit/Offset).
+func CountProducts(ctx context.Context, f *ProductFilter) (int, error) {
+	where, args := productWhere(f, -1)
+	var total int
+	if err := db.DB.QueryRowContext(ctx, `SELECT COUNT(*)`+productFrom+where, args...).Scan(&total); err != nil {
+		return 0, fmt.Errorf("count products: %w", err)
+	}
+	return total, nil
+}
+
+// safeHeadline экранирует результат ts_headline, оставляя только теги подсветки:
+// название и описание товара — произвольный текст и не должны попадать в разметку как есть.
+func safeHeadline(s string) string {
+	s = html.EscapeString(s)
+	return strings.NewReplacer("&lt;mark&gt;", "<mark>", "&lt;/mark&gt;", "</mark>").Replace(s)
 }
 
 // GetProductByID возвращает продукт по id (nil, nil если не найден).
@@ -257,10 +484,27 @@ func UpdateProduct(ctx context.Context, p *models.Product) error {
 	return err
 }
 
-// DeleteProduct удаляет продукт по id.
-func DeleteProduct(ctx context.Context, id int) error {
-	_, err := db.DB.ExecContext(ctx, `DELETE FROM products WHERE id=$1`, id)
-	return err
+// DeleteProduct удаляет продукт по id и возвращает ключи его файлов в хранилище
+// (изображения с вариантами и фотографии к отзывам) — удаляет их вызывающий,
+// после того как строки уже удалены.
+func DeleteProduct(ctx context.Context, id int) (fileKeys []string, err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if fileKeys, err = productFileKeys(ctx, tx, id); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `DELETE FROM products WHERE id=$1`, id); err != nil {
+		return nil, err
+	}
+	return fileKeys, tx.Commit()
 }
 
 // helper: чтобы передавать NULL для 0 (если в вашей модели 0 означает пусто)
@@ -271,54 +515,57 @@ func nullableInt(v int) interface{} {
 	return v
 }
 
+// productDetailReviews — сколько отзывов отдаётся вместе с карточкой товара.
+const productDetailReviews = 10
+
 func GetProductDetails(ctx context.Context, productID int) (*models.ProductDetail, error) {
+	logger := logging.FromContext(ctx).With("product_id", productID)
+
 	// Получаем основную информацию о товаре
 	product, err := GetProductByID(ctx, productID)
 	if err != nil {
-		log.Printf("Error getting product %d: %v", productID, err)
+		logger.Error("get product failed", "error", err)
 		return nil, err
 	}
 	if product == nil {
-		log.Printf("Product with ID %d not found", productID)
+		logger.Debug("product not found")
 		return nil, nil
 	}
 
-	log.Printf("Found product: %s (ID: %d)", product.Name, productID)
+	// Изображения с превью (главное — с is_primary)
+	images, err := GetProductImages(ctx, productID)
+	if err != nil {
+		logger.Warn("get images failed, continuing without them", "error", err)
+		images = []models.ProductImage{}
+	}
 
 	// Получаем характеристики товара с обработкой ошибок
 	characteristics, err := GetProductCharacteristics(ctx, productID)
 	if err != nil {
-		log.Printf("Error getting characteristics (continuing without them): %v", err)
+		logger.Warn("get characteristics failed, continuing without them", "error", err)
 		characteristics = []models.ProductCharacteristic{}
 	}
 
-	log.Printf("Characteristics count: %d", len(characteristics))
-
-	// Получаем отзывы о товаре с обработкой ошибок
-	reviews, err := GetProductReviews(ctx, productID)
+	// Первая страница отзывов (новые сверху); остальные — через /api/products/{id}/reviews
+	reviews, err := GetProductReviews(ctx, ReviewFilter{ProductID: productID, Limit: productDetailReviews})
 	if err != nil {
-		log.Printf("Error getting reviews (continuing without them): %v", err)
+		logger.Warn("get reviews failed, continuing without them", "error", err)
 		reviews = []models.Review{}
 	}
 
-	log.Printf("Reviews count: %d", len(reviews))
-
-	// Вычисляем средний рейтинг
-	var averageRating float64
-	if len(reviews) > 0 {
-		var totalRating int
-		for _, r := range reviews {
-			totalRating += r.Rating
-		}
-		averageRating = float64(totalRating) / float64(len(reviews))
-		log.Printf("Average rating calculated: %.2f", averageRating)
+	// Рейтинг — из агрегата product_ratings
+	rating, err := GetProductRating(ctx, productID)
+	if err != nil {
+		logger.Warn("get rating failed, continuing without it", "error", err)
 	}
+	product.Rating = &rating
 
 	detail := &models.ProductDetail{
 		Product:         *product,
+		Images:          images,
 		Characteristics: characteristics,
 		Reviews:         reviews,
-		AverageRating:   averageRating,
+		AverageRating:   rating.Average,
 	}
 
 	return detail, nil
@@ -333,7 +580,6 @@ func GetProductCharacteristics(ctx context.Context, productID int) ([]models.Pro
         ORDER BY ct.name
     `, productID)
 	if err != nil {
-		log.Printf("Error getting characteristics for product %d: %v", productID, err)
 		return nil, err
 	}
 	defer rows.Close()
@@ -343,7 +589,7 @@ func GetProductCharacteristics(ctx context.Context, productID int) ([]models.Pro
 		var c models.ProductCharacteristic
 		err := rows.Scan(&c.ID, &c.ProductID, &c.CharacteristicName, &c.CharacteristicUnit, &c.Value)
 		if err != nil {
-			log.Printf("Error scanning characteristic row: %v", err)
+			logging.FromContext(ctx).Warn("characteristic row scan failed", "product_id", productID, "error", err)
 			continue // Продолжаем даже при ошибке сканирования одной строки
 		}
 		characteristics = append(characteristics, c)
@@ -351,59 +597,8 @@ func GetProductCharacteristics(ctx context.Context, productID int) ([]models.Pro
 
 	// Проверяем ошибки после цикла
 	if err := rows.Err(); err != nil {
-		log.Printf("Error after scanning characteristics: %v", err)
 		return nil, err
 	}
 
-	log.Printf("Found %d characteristics for product %d", len(characteristics), productID)
 	return characteristics, nil
 }
-
-func GetProductReviews(ctx context.Context, productID int) ([]models.Review, error) {
-	rows, err := db.DB.QueryContext(ctx, `
-        SELECT r.id, r.product_id, r.user_id, u.first_name || ' ' || u.last_name as user_name, 
-               r.rating, r.comment, r.created_at
-        FROM reviews r
-        LEFT JOIN users u ON r.user_id = u.id
-        WHERE r.product_id = $1
-        ORDER BY r.created_at DESC
-    `, productID)
-	if err != nil {
-		log.Printf("Error getting reviews for product %d: %v", productID, err)
-		return nil, err
-	}
-	defer rows.Close()
-
-	var reviews []models.Review
-	for rows.Next() {
-		var r models.Review
-		var userName sql.NullString
-		var createdAt sql.NullTime
-
-		if err := rows.Scan(&r.ID, &r.ProductID, &r.UserID, &userName, &r.Rating, &r.Comment, &createdAt); err != nil {
-			log.Printf("Error scanning review row: %v", err)
-			return nil, err
-		}
-
-		if userName.Valid {
-			r.UserName = userName.String
-		} else {
-			r.UserName = "Аноним"
-		}
-
-		if createdAt.Valid {
-			r.CreatedAt = createdAt.Time
-		}
-
-		reviews = append(reviews, r)
-	}
-
-	// Проверка ошибки после цикла
-	if err := rows.Err(); err != nil {
-		log.Printf("Error after scanning reviews: %v", err)
-		return nil, err
-	}
-
-	log.Printf("Found %d reviews for product %d", len(reviews), productID)
-	return reviews, nil
-}
@@ -0,0 +1,143 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+// ReorderToCart переносит позиции заказа orderID в корзину пользователя userID.
+// Цены берутся текущие (корзина хранит только количество, цена фиксируется при
+// оформлении заказа). Количество ограничивается остатком на складе с учётом того,
+// что уже лежит в корзине. Товары, которых больше нет в каталоге или нет в наличии,
+// пропускаются и попадают в Dropped.
+func ReorderToCart(ctx context.Context, userID, orderID int) (*models.ReorderSummary, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	// позиции заказа вместе с текущим состоянием товара (LEFT JOIN — товар мог быть удалён)
+	rows, err := tx.QueryContext(ctx, `
+		SELECT oi.product_id, oi.quantity, oi.price_per_unit,
+		       p.id, COALESCE(p.name, ''), p.price, p.stock_quantity
+		FROM order_items oi
+		LEFT JOIN products p ON p.id = oi.product_id
+		WHERE oi.order_id = $1
+		ORDER BY oi.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+
+	type line struct {
+		productID int
+		quantity  int
+		oldPrice  float64
+		exists    bool
+		name      string
+		price     float64
+		stock     int
+	}
+	var lines []line
+	for rows.Next() {
+		var l line
+		var orderProductID, productID, stock sql.NullInt64
+		var price sql.NullFloat64
+		if err = rows.Scan(&orderProductID, &l.quantity, &l.oldPrice, &productID, &l.name, &price, &stock); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		l.productID = int(orderProductID.Int64)
+		l.exists = productID.Valid
+		if price.Valid {
+			l.price = price.Float64
+		}
+		if stock.Valid {
+			l.stock = int(stock.Int64)
+		}
+		lines = append(lines, l)
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return nil, err
+	}
+
+	summary := &models.ReorderSummary{
+		OrderID:  orderID,
+		Added:    []models.ReorderItem{},
+		Adjusted: []models.ReorderItem{},
+		Dropped:  []models.ReorderItem{},
+	}
+
+	for _, l := range lines {
+		item := models.ReorderItem{
+			ProductID:         l.productID,
+			ProductName:       l.name,
+			RequestedQuantity: l.quantity,
+			OldPrice:          l.oldPrice,
+			CurrentPrice:      l.price,
+		}
+
+		if !l.exists {
+			item.Status = "dropped"
+			item.Reason = "discontinued"
+			summary.Dropped = append(summary.Dropped, item)
+			continue
+		}
+
+		// сколько этого товара уже лежит в корзине
+		var inCart int
+		err = tx.QueryRowContext(ctx,
+			`SELECT COALESCE(SUM(quantity), 0) FROM cart_items WHERE user_id=$1 AND product_id=$2`,
+			userID, l.productID).Scan(&inCart)
+		if err != nil {
+			return nil, err
+		}
+
+		available := l.stock - inCart
+		if available <= 0 {
+			item.Status = "dropped"
+			item.Reason = "out_of_stock"
+			summary.Dropped = append(summary.Dropped, item)
+			continue
+		}
+
+		qty := l.quantity
+		if qty > available {
+			qty = available
+		}
+
+		_, err = tx.ExecContext(ctx, `
+			INSERT INTO cart_items (user_id, product_id, quantity)
+			VALUES ($1, $2, $3)
+			ON CONFLICT (user_id, product_id)
+			DO UPDATE SET quantity = cart_items.quantity + EXCLUDED.quantity
+		`, userID, l.productID, qty)
+		if err != nil {
+			return nil, err
+		}
+
+		item.AddedQuantity = qty
+		if qty < l.quantity {
+			item.Status = "adjusted"
+			item.Reason = "insufficient_stock"
+			summary.Adjusted = append(summary.Adjusted, item)
+		} else {
+			item.Status = "added"
+			summary.Added = append(summary.Added, item)
+		}
+	}
+
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return summary, nil
+}
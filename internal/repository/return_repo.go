@@ -0,0 +1,274 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"strings"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+var (
+	ErrOrderNotFound         = errors.New("order not found")
+	ErrOrderItemNotFound     = errors.New("order item not found")
+	ErrReturnQuantity        = errors.New("return quantity exceeds returnable quantity")
+	ErrReturnTransition      = errors.New("invalid return status transition")
+	ErrReturnOrderNotAllowed = errors.New("order is not eligible for return")
+)
+
+// returnTransitions — допустимые переходы статусов RMA.
+var returnTransitions = map[string][]string{
+	models.ReturnRequested: {models.ReturnApproved, models.ReturnRejected},
+	models.ReturnApproved:  {models.ReturnReceived, models.ReturnRejected},
+	models.ReturnReceived:  {models.ReturnRefunded},
+}
+
+func canTransitionReturn(from, to string) bool {
+	for _, s := range returnTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// CreateReturn создаёт заявку на возврат позиции заказа. Заказ должен принадлежать
+// пользователю и быть доставлен; суммарное количество по незакрытым отказом заявкам
+// не может превышать количество в позиции. ErrOrderNotFound — заказа нет или он чужой.
+func CreateReturn(ctx context.Context, userID, orderID int, p *models.CreateReturnPayload) (int, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var ownerID int
+	var status string
+	err = tx.QueryRowContext(ctx, `SELECT user_id, status FROM orders WHERE id=$1`, orderID).Scan(&ownerID, &status)
+	// чужой заказ неотличим от несуществующего; условия возврата проверяются только для своего
+	if errors.Is(err, sql.ErrNoRows) || (err == nil && ownerID != userID) {
+		err = ErrOrderNotFound
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+	if status != "delivered" {
+		err = ErrReturnOrderNotAllowed
+		return 0, err
+	}
+
+	// блокируем позицию, чтобы параллельные заявки не превысили количество
+	var productID, ordered int
+	err = tx.QueryRowContext(ctx,
+		`SELECT product_id, quantity FROM order_items WHERE id=$1 AND order_id=$2 FOR UPDATE`,
+		p.OrderItemID, orderID).Scan(&productID, &ordered)
+	if errors.Is(err, sql.ErrNoRows) {
+		err = ErrOrderItemNotFound
+		return 0, err
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	var alreadyReturned int
+	err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(SUM(quantity), 0) FROM returns WHERE order_item_id=$1 AND status <> $2`,
+		p.OrderItemID, models.ReturnRejected).Scan(&alreadyReturned)
+	if err != nil {
+		return 0, err
+	}
+	if p.Quantity > ordered-alreadyReturned {
+		err = ErrReturnQuantity
+		return 0, err
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO returns (order_id, order_item_id, user_id, product_id, quantity, reason, status)
+		VALUES ($1,$2,$3,$4,$5,$6,$7) RETURNING id
+	`, orderID, p.OrderItemID, userID, productID, p.Quantity, p.Reason, models.ReturnRequested).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO return_status_history (return_id, status, comment, changed_by) VALUES ($1,$2,$3,$4)`,
+		id, models.ReturnRequested, nullableString(p.Reason), userID); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// TransitionReturn переводит заявку в новый статус (действие администратора adminID).
+// При переходе в received товар возвращается на склад, при refunded фиксируется сумма
+// возврата (по умолчанию — цена позиции × количество).
+func TransitionReturn(ctx context.Context, returnID, adminID int, p *models.ReturnStatusPayload) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var current string
+	var productID, quantity, orderItemID int
+	err = tx.QueryRowContext(ctx,
+		`SELECT status, product_id, quantity, order_item_id FROM returns WHERE id=$1 FOR UPDATE`,
+		returnID).Scan(&current, &productID, &quantity, &orderItemID)
+	if err != nil {
+		// sql.ErrNoRows пробрасывается как есть — хендлер отвечает 404
+		return err
+	}
+	if !canTransitionReturn(current, p.Status) {
+		err = fmt.Errorf("%w: %s -> %s", ErrReturnTransition, current, p.Status)
+		return err
+	}
+
+	switch p.Status {
+	case models.ReturnReceived:
+		if _, err = tx.ExecContext(ctx,
+			`UPDATE products SET stock_quantity = COALESCE(stock_quantity, 0) + $1 WHERE id=$2`,
+			quantity, productID); err != nil {
+			return err
+		}
+	case models.ReturnRefunded:
+		amount := p.RefundAmount
+		if amount == nil {
+			var price float64
+			if err = tx.QueryRowContext(ctx,
+				`SELECT price_per_unit FROM order_items WHERE id=$1`, orderItemID).Scan(&price); err != nil {
+				return err
+			}
+			v := price * float64(quantity)
+			amount = &v
+		}
+		if _, err = tx.ExecContext(ctx,
+			`UPDATE returns SET refund_amount=$1 WHERE id=$2`, *amount, returnID); err != nil {
+			return err
+		}
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE returns SET status=$1, admin_comment=COALESCE($2, admin_comment), updated_at=NOW() WHERE id=$3`,
+		p.Status, nullableString(p.Comment), returnID); err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO return_status_history (return_id, status, comment, changed_by) VALUES ($1,$2,$3,$4)`,
+		returnID, p.Status, nullableString(p.Comment), adminID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+const returnSelect = `
+	SELECT r.id, r.order_id, r.order_item_id, r.user_id, r.product_id, COALESCE(p.name, ''),
+	       r.quantity, r.reason, r.status, r.refund_amount, r.admin_comment, r.created_at, r.updated_at
+	FROM returns r
+	LEFT JOIN products p ON p.id = r.product_id`
+
+func scanReturn(sc interface{ Scan(...interface{}) error }) (*models.ReturnRequest, error) {
+	var rr models.ReturnRequest
+	var refund sql.NullFloat64
+	var comment sql.NullString
+	if err := sc.Scan(&rr.ID, &rr.OrderID, &rr.OrderItemID, &rr.UserID, &rr.ProductID, &rr.ProductName,
+		&rr.Quantity, &rr.Reason, &rr.Status, &refund, &comment, &rr.CreatedAt, &rr.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if refund.Valid {
+		v := refund.Float64
+		rr.RefundAmount = &v
+	}
+	if comment.Valid {
+		rr.AdminComment = comment.String
+	}
+	return &rr, nil
+}
+
+// GetReturns возвращает заявки; userID/status — необязательные фильтры.
+func GetReturns(ctx context.Context, userID *int, status string) ([]models.ReturnRequest, error) {
+	conds := []string{}
+	args := []interface{}{}
+	if userID != nil {
+		args = append(args, *userID)
+		conds = append(conds, fmt.Sprintf("r.user_id=$%d", len(args)))
+	}
+	if status != "" {
+		args = append(args, status)
+		conds = append(conds, fmt.Sprintf("r.status=$%d", len(args)))
+	}
+	q := returnSelect
+	if len(conds) > 0 {
+		q += " WHERE " + strings.Join(conds, " AND ")
+	}
+	q += " ORDER BY r.id DESC"
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.ReturnRequest{}
+	for rows.Next() {
+		rr, err := scanReturn(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *rr)
+	}
+	return out, rows.Err()
+}
+
+// GetReturnByID возвращает заявку вместе с историей статусов (nil, nil если не найдена).
+func GetReturnByID(ctx context.Context, id int) (*models.ReturnRequest, error) {
+	rr, err := scanReturn(db.DB.QueryRowContext(ctx, returnSelect+" WHERE r.id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT id, return_id, status, comment, changed_by, created_at
+		FROM return_status_history WHERE return_id=$1 ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h models.ReturnStatusHistory
+		var comment sql.NullString
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&h.ID, &h.ReturnID, &h.Status, &comment, &changedBy, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		if comment.Valid {
+			h.Comment = comment.String
+		}
+		if changedBy.Valid {
+			v := int(changedBy.Int64)
+			h.ChangedBy = &v
+		}
+		rr.History = append(rr.History, h)
+	}
+	return rr, rows.Err()
+}
@@ -2,16 +2,495 @@ package repository
 
 import (
 	"context"
+	"database/sql"
+	"errors"
+	"fmt"
 	"time"
+
 	"x86trade_backend/internal/db"
 	"x86trade_backend/internal/models"
+	"x86trade_backend/internal/storage"
+
+	"github.com/lib/pq"
+)
+
+var (
+	// ErrReviewExists — у пользователя уже есть действующий (не отклонённый) отзыв на этот товар
+	// (его можно отредактировать).
+	ErrReviewExists = errors.New("review already exists")
+	// ErrOwnReviewVote — голосовать за полезность своего отзыва нельзя.
+	ErrOwnReviewVote = errors.New("cannot vote for own review")
+	// ErrTooManyReviewImages — превышен лимит фотографий к отзыву.
+	ErrTooManyReviewImages = errors.New("too many review images")
 )
 
+// Сортировки отзывов на странице товара (?sort=).
+const (
+	ReviewSortNewest     = "newest"
+	ReviewSortHelpful    = "helpful"
+	ReviewSortRatingDesc = "rating_desc"
+	ReviewSortRatingAsc  = "rating_asc"
+)
+
+var reviewSorts = map[string]string{
+	ReviewSortNewest:     "r.created_at DESC, r.id DESC",
+	ReviewSortHelpful:    "r.helpful_count DESC, r.created_at DESC, r.id DESC",
+	ReviewSortRatingDesc: "r.rating DESC, r.created_at DESC, r.id DESC",
+	ReviewSortRatingAsc:  "r.rating ASC, r.created_at DESC, r.id DESC",
+}
+
+// IsReviewSort проверяет, что s — известная сортировка отзывов.
+func IsReviewSort(s string) bool {
+	_, ok := reviewSorts[s]
+	return ok
+}
+
+// ReviewFilter — выборка опубликованных отзывов товара. Rating — только с этой оценкой.
+type ReviewFilter struct {
+	ProductID int
+	Rating    *int
+	Sort      string
+	Limit     int
+	Offset    int
+}
+
+// verifiedPurchaseExpr — есть ли у пользователя $1 доставленный заказ с товаром $2.
+const verifiedPurchaseExpr = `EXISTS (SELECT 1 FROM orders o
+		JOIN order_items oi ON oi.order_id = o.id
+		WHERE o.user_id = $1 AND oi.product_id = $2 AND o.status = 'delivered')`
+
+const reviewSelect = `
+	SELECT r.id, r.product_id, COALESCE(p.name, ''), r.user_id,
+	       u.first_name || ' ' || u.last_name, r.rating, r.comment, r.status, r.verified_purchase,
+	       r.helpful_count, r.unhelpful_count,
+	       r.moderation_note, r.moderated_by, r.moderated_at, r.created_at, r.updated_at
+	  FROM reviews r
+	  LEFT JOIN products p ON p.id = r.product_id
+	  LEFT JOIN users u ON u.id = r.user_id`
+
+func scanReview(sc interface{ Scan(...interface{}) error }) (*models.Review, error) {
+	var r models.Review
+	var userName, note sql.NullString
+	var moderatedBy sql.NullInt64
+	var moderatedAt, created, updated sql.NullTime
+	if err := sc.Scan(&r.ID, &r.ProductID, &r.ProductName, &r.UserID, &userName, &r.Rating, &r.Comment,
+		&r.Status, &r.VerifiedPurchase, &r.HelpfulCount, &r.UnhelpfulCount, &note, &moderatedBy, &moderatedAt, &created, &updated); err != nil {
+		return nil, err
+	}
+	if userName.Valid {
+		r.UserName = userName.String
+	} else {
+		r.UserName = "Аноним"
+	}
+	r.ModerationNote = note.String
+	if moderatedBy.Valid {
+		id := int(moderatedBy.Int64)
+		r.ModeratedBy = &id
+	}
+	if moderatedAt.Valid {
+		r.ModeratedAt = &moderatedAt.Time
+	}
+	if created.Valid {
+		r.CreatedAt = created.Time
+	}
+	if updated.Valid {
+		r.UpdatedAt = &updated.Time
+	}
+	return &r, nil
+}
+
+func queryReviews(ctx context.Context, q string, args ...interface{}) ([]models.Review, error) {
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.Review{}
+	for rows.Next() {
+		r, err := scanReview(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *r)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	rows.Close()
+	if err := attachReviewImages(ctx, out); err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// attachReviewImages загружает фотографии отзывов одним запросом.
+func attachReviewImages(ctx context.Context, reviews []models.Review) error {
+	if len(reviews) == 0 {
+		return nil
+	}
+	ids := make([]int, len(reviews))
+	byID := make(map[int]*models.Review, len(reviews))
+	for i := range reviews {
+		reviews[i].Images = []models.ReviewImage{}
+		ids[i] = reviews[i].ID
+		byID[reviews[i].ID] = &reviews[i]
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT id, review_id, storage_key, content_type, size_bytes, position
+		  FROM review_images WHERE review_id = ANY($1)
+		 ORDER BY review_id, position, id`, pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var img models.ReviewImage
+		if err := rows.Scan(&img.ID, &img.ReviewID, &img.Key, &img.ContentType, &img.Size, &img.Position); err != nil {
+			return err
+		}
+		img.URL = storage.PublicURL(img.Key)
+		if r := byID[img.ReviewID]; r != nil {
+			r.Images = append(r.Images, img)
+		}
+	}
+	return rows.Err()
+}
+
+// GetProductReviews — страница опубликованных (одобренных) отзывов о товаре.
+func GetProductReviews(ctx context.Context, f ReviewFilter) ([]models.Review, error) {
+	order, ok := reviewSorts[f.Sort]
+	if !ok {
+		order = reviewSorts[ReviewSortNewest]
+	}
+	return queryReviews(ctx, reviewSelect+`
+		WHERE r.product_id = $1 AND r.status = $2 AND ($3::int IS NULL OR r.rating = $3)
+		ORDER BY `+order+`
+		LIMIT $4 OFFSET $5`, f.ProductID, models.ReviewApproved, f.Rating, f.Limit, f.Offset)
+}
+
+// CountProductReviews — число опубликованных отзывов о товаре по тому же фильтру.
+func CountProductReviews(ctx context.Context, f ReviewFilter) (int, error) {
+	var n int
+	err := db.DB.QueryRowContext(ctx, `
+		SELECT COUNT(*) FROM reviews
+		 WHERE product_id = $1 AND status = $2 AND ($3::int IS NULL OR rating = $3)`,
+		f.ProductID, models.ReviewApproved, f.Rating).Scan(&n)
+	return n, err
+}
+
+// CreateReview сохраняет отзыв в статусе pending: в рейтинг он попадёт после одобрения.
+// Отметка «проверенная покупка» ставится, если у автора есть доставленный заказ с товаром.
 func CreateReview(ctx context.Context, review *models.Review) error {
-	_, err := db.DB.ExecContext(ctx, `
-		INSERT INTO reviews (product_id, user_id, rating, comment, created_at)
-		VALUES ($1, $2, $3, $4, $5)
-	`, review.ProductID, review.UserID, review.Rating, review.Comment, time.Now())
+	err := db.DB.QueryRowContext(ctx, `
+		INSERT INTO reviews (product_id, user_id, rating, comment, status, verified_purchase, created_at)
+		VALUES ($2, $1, $3, $4, $5, `+verifiedPurchaseExpr+`, $6)
+		RETURNING id, verified_purchase
+	`, review.UserID, review.ProductID, review.Rating, review.Comment, models.ReviewPending, time.Now()).
+		Scan(&review.ID, &review.VerifiedPurchase)
+	if isReviewExists(err) {
+		return ErrReviewExists
+	}
+	if err != nil {
+		return err
+	}
+	review.Status = models.ReviewPending
+	return nil
+}
+
+// reviewUniqueIndex — частичный уникальный индекс «один не отклонённый отзыв на товар от пользователя».
+const reviewUniqueIndex = "idx_reviews_user_product"
+
+// isReviewExists сообщает, что запись нарушила reviewUniqueIndex: отклонённый отзыв нельзя
+// вернуть в работу, пока у автора есть другой действующий отзыв на тот же товар.
+func isReviewExists(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == "23505" && pqErr.Constraint == reviewUniqueIndex
+}
+
+// GetReviewByID возвращает отзыв (nil, nil если не найден).
+func GetReviewByID(ctx context.Context, id int) (*models.Review, error) {
+	r, err := scanReview(db.DB.QueryRowContext(ctx, reviewSelect+` WHERE r.id = $1`, id))
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list := []models.Review{*r}
+	if err := attachReviewImages(ctx, list); err != nil {
+		return nil, err
+	}
+	return &list[0], nil
+}
+
+// GetUserReviews — все отзывы пользователя, в том числе на модерации и отклонённые.
+func GetUserReviews(ctx context.Context, userID int) ([]models.Review, error) {
+	return queryReviews(ctx, reviewSelect+` WHERE r.user_id = $1 ORDER BY r.created_at DESC, r.id DESC`, userID)
+}
+
+// UpdateReview — правка отзыва автором: отзыв снова уходит на модерацию, а если был
+// одобрен — пропадает из рейтинга до повторного решения. sql.ErrNoRows — отзыва нет
+// или он чужой; ErrReviewExists — правится отклонённый отзыв, а у автора уже есть другой.
+func UpdateReview(ctx context.Context, review *models.Review) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	err = tx.QueryRowContext(ctx, `
+		UPDATE reviews r
+		   SET rating = $3, comment = $4, status = $5, updated_at = NOW(),
+		       verified_purchase = `+verifiedPurchaseExpr+`,
+		       moderated_by = NULL, moderated_at = NULL, moderation_note = NULL
+		 WHERE r.id = $6 AND r.user_id = $1 AND r.product_id = $2
+		RETURNING r.verified_purchase`,
+		review.UserID, review.ProductID, review.Rating, review.Comment, models.ReviewPending, review.ID).
+		Scan(&review.VerifiedPurchase)
+	if isReviewExists(err) {
+		return ErrReviewExists
+	}
+	if err != nil {
+		return err
+	}
+	if err = refreshProductRating(ctx, tx, review.ProductID); err != nil {
+		return err
+	}
+	review.Status = models.ReviewPending
+	return tx.Commit()
+}
+
+// DeleteReview удаляет отзыв и пересчитывает рейтинг товара. userID > 0 — удалить можно
+// только свой отзыв, 0 — любой (администратор). sql.ErrNoRows — отзыв не найден.
+// Возвращает ключи фотографий отзыва: файлы удаляет вызывающий после успешного удаления.
+func DeleteReview(ctx context.Context, id, userID int) (imageKeys []string, err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if err = tx.QueryRowContext(ctx,
+		`SELECT COALESCE(array_agg(storage_key), '{}') FROM review_images WHERE review_id = $1`, id).
+		Scan(pq.Array(&imageKeys)); err != nil {
+		return nil, err
+	}
+
+	var productID int
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM reviews WHERE id = $1 AND ($2 = 0 OR user_id = $2) RETURNING product_id`, id, userID).
+		Scan(&productID)
+	if err != nil {
+		return nil, err
+	}
+	if err = refreshProductRating(ctx, tx, productID); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return imageKeys, nil
+}
+
+// GetReviews — очередь модерации: отзывы в статусе status (пусто — все), старые сверху.
+func GetReviews(ctx context.Context, status string, limit, offset int) ([]models.Review, error) {
+	return queryReviews(ctx, reviewSelect+`
+		WHERE ($1 = '' OR r.status = $1)
+		ORDER BY r.created_at ASC, r.id ASC
+		LIMIT $2 OFFSET $3`, status, limit, offset)
+}
+
+func CountReviews(ctx context.Context, status string) (int, error) {
+	var n int
+	err := db.DB.QueryRowContext(ctx, `SELECT COUNT(*) FROM reviews WHERE $1 = '' OR status = $1`, status).Scan(&n)
+	return n, err
+}
+
+// ModerateReview записывает решение модератора и пересчитывает рейтинг товара.
+// sql.ErrNoRows — отзыв не найден; ErrReviewExists — отклонённый отзыв нельзя вернуть,
+// потому что у автора уже есть другой действующий отзыв на товар.
+func ModerateReview(ctx context.Context, id, adminID int, p models.ReviewModerationPayload) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var productID int
+	err = tx.QueryRowContext(ctx, `
+		UPDATE reviews
+		   SET status = $1, moderation_note = $2, moderated_by = $3, moderated_at = NOW()
+		 WHERE id = $4
+		RETURNING product_id`, p.Status, nullableString(p.Note), adminID, id).Scan(&productID)
+	if isReviewExists(err) {
+		return ErrReviewExists
+	}
+	if err != nil {
+		return err
+	}
+	if err = refreshProductRating(ctx, tx, productID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// markVerifiedReviews ставит отметку «проверенная покупка» отзывам покупателя
+// на товары доставленного заказа.
+func markVerifiedReviews(ctx context.Context, q txQuerier, orderID int) error {
+	if _, err := q.ExecContext(ctx, `
+		UPDATE reviews r SET verified_purchase = TRUE
+		  FROM orders o
+		  JOIN order_items oi ON oi.order_id = o.id
+		 WHERE o.id = $1 AND r.user_id = o.user_id AND r.product_id = oi.product_id
+		   AND NOT r.verified_purchase`, orderID); err != nil {
+		return fmt.Errorf("mark verified reviews: %w", err)
+	}
+	return nil
+}
+
+// VoteReview записывает голос пользователя за полезность опубликованного отзыва (повторный
+// голос заменяет прежний) и возвращает отзыв с обновлёнными счётчиками.
+// sql.ErrNoRows — отзыв не найден или не опубликован.
+func VoteReview(ctx context.Context, reviewID, userID int, helpful bool) (*models.Review, error) {
+	return changeReviewVote(ctx, reviewID, userID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `
+			INSERT INTO review_votes (review_id, user_id, helpful) VALUES ($1, $2, $3)
+			ON CONFLICT (review_id, user_id) DO UPDATE SET helpful = EXCLUDED.helpful, created_at = NOW()`,
+			reviewID, userID, helpful)
+		return err
+	})
+}
+
+// DeleteReviewVote отзывает голос пользователя (отсутствие голоса ошибкой не считается).
+func DeleteReviewVote(ctx context.Context, reviewID, userID int) (*models.Review, error) {
+	return changeReviewVote(ctx, reviewID, userID, func(tx *sql.Tx) error {
+		_, err := tx.ExecContext(ctx, `DELETE FROM review_votes WHERE review_id = $1 AND user_id = $2`, reviewID, userID)
+		return err
+	})
+}
+
+// changeReviewVote меняет голос и пересчитывает счётчики отзыва в одной транзакции;
+// строка отзыва блокируется, чтобы параллельные голоса не потеряли пересчёт.
+func changeReviewVote(ctx context.Context, reviewID, userID int, change func(tx *sql.Tx) error) (_ *models.Review, err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var authorID int
+	if err = tx.QueryRowContext(ctx,
+		`SELECT user_id FROM reviews WHERE id = $1 AND status = $2 FOR UPDATE`, reviewID, models.ReviewApproved).
+		Scan(&authorID); err != nil {
+		return nil, err
+	}
+	if authorID == userID {
+		err = ErrOwnReviewVote
+		return nil, err
+	}
+	if err = change(tx); err != nil {
+		return nil, err
+	}
+	if _, err = tx.ExecContext(ctx, `
+		UPDATE reviews SET
+		    helpful_count   = (SELECT COUNT(*) FROM review_votes WHERE review_id = $1 AND helpful),
+		    unhelpful_count = (SELECT COUNT(*) FROM review_votes WHERE review_id = $1 AND NOT helpful)
+		 WHERE id = $1`, reviewID); err != nil {
+		return nil, err
+	}
+	if err = tx.Commit(); err != nil {
+		return nil, err
+	}
+	return GetReviewByID(ctx, reviewID)
+}
+
+// AddReviewImages прикрепляет фотографии к отзыву автора (файлы уже в хранилище).
+// Всего у отзыва может быть не больше maxImages фотографий (ErrTooManyReviewImages).
+// Опубликованный отзыв с новыми фотографиями снова уходит на модерацию.
+// sql.ErrNoRows — отзыв не найден или чужой.
+func AddReviewImages(ctx context.Context, reviewID, userID int, images []models.ReviewImage, maxImages int) (err error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var productID int
+	var status string
+	if err = tx.QueryRowContext(ctx,
+		`SELECT product_id, status FROM reviews WHERE id = $1 AND user_id = $2 FOR UPDATE`, reviewID, userID).
+		Scan(&productID, &status); err != nil {
+		return err
+	}
+
+	var count, lastPos int
+	if err = tx.QueryRowContext(ctx,
+		`SELECT COUNT(*), COALESCE(MAX(position), 0) FROM review_images WHERE review_id = $1`, reviewID).
+		Scan(&count, &lastPos); err != nil {
+		return err
+	}
+	if count+len(images) > maxImages {
+		err = ErrTooManyReviewImages
+		return err
+	}
+
+	for i := range images {
+		img := &images[i]
+		img.ReviewID = reviewID
+		img.Position = lastPos + i + 1
+		if err = tx.QueryRowContext(ctx, `
+			INSERT INTO review_images (review_id, storage_key, content_type, size_bytes, position)
+			VALUES ($1, $2, $3, $4, $5) RETURNING id`,
+			reviewID, img.Key, img.ContentType, img.Size, img.Position).Scan(&img.ID); err != nil {
+			return err
+		}
+		img.URL = storage.PublicURL(img.Key)
+	}
+
+	if status == models.ReviewApproved {
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE reviews SET status = $1, updated_at = NOW(),
+			       moderated_by = NULL, moderated_at = NULL, moderation_note = NULL
+			 WHERE id = $2`, models.ReviewPending, reviewID); err != nil {
+			return err
+		}
+		if err = refreshProductRating(ctx, tx, productID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
 
-	return err
+// DeleteReviewImage открепляет фотографию от отзыва автора и возвращает ключ файла
+// (файл удаляет вызывающий). sql.ErrNoRows — фотография не найдена или отзыв чужой.
+func DeleteReviewImage(ctx context.Context, reviewID, imageID, userID int) (string, error) {
+	var key string
+	err := db.DB.QueryRowContext(ctx, `
+		DELETE FROM review_images ri
+		 USING reviews r
+		 WHERE ri.id = $1 AND ri.review_id = $2 AND r.id = ri.review_id AND r.user_id = $3
+		RETURNING ri.storage_key`, imageID, reviewID, userID).Scan(&key)
+	return key, err
 }
@@ -0,0 +1,85 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"sort"
+	"strings"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+// Подсказки собираются одним запросом из трёх групп, каждая со своим LIMIT.
+// rank: 2 — название (или SKU) начинается с запроса, 1 — содержит его, 0 — похоже по триграммам.
+const searchSuggestQuery = `
+(SELECT 'product' AS kind, p.id, p.name, p.sku, p.image_path,
+        CASE WHEN lower(p.name) LIKE $2 OR lower(p.sku) LIKE $2 THEN 2
+             WHEN p.name ILIKE $3 THEN 1 ELSE 0 END
+        + GREATEST(word_similarity($1, p.name), word_similarity($1, COALESCE(p.sku, ''))) AS score
+   FROM products p
+  WHERE lower(p.name) LIKE $2 OR lower(p.sku) LIKE $2 OR p.name ILIKE $3 OR $1 <% p.name
+  ORDER BY score DESC, p.id DESC
+  LIMIT $4)
+UNION ALL
+(SELECT 'category', c.id, c.name, NULL, NULL,
+        CASE WHEN lower(c.name) LIKE $2 THEN 2 WHEN c.name ILIKE $3 THEN 1 ELSE 0 END
+        + word_similarity($1, c.name) AS score
+   FROM categories c
+  WHERE lower(c.name) LIKE $2 OR c.name ILIKE $3 OR $1 <% c.name
+  ORDER BY score DESC, c.id
+  LIMIT $4)
+UNION ALL
+(SELECT 'manufacturer', m.id, m.name, NULL, NULL,
+        CASE WHEN lower(m.name) LIKE $2 THEN 2 WHEN m.name ILIKE $3 THEN 1 ELSE 0 END
+        + word_similarity($1, m.name) AS score
+   FROM manufacturers m
+  WHERE lower(m.name) LIKE $2 OR m.name ILIKE $3 OR $1 <% m.name
+  ORDER BY score DESC, m.id
+  LIMIT $4)`
+
+// suggestTypeOrder — порядок групп при равной релевантности.
+var suggestTypeOrder = map[string]int{
+	models.SuggestCategory:     0,
+	models.SuggestManufacturer: 1,
+	models.SuggestProduct:      2,
+}
+
+// escapeLike экранирует спецсимволы LIKE (экранирующий символ по умолчанию — обратная косая черта).
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`).Replace(s)
+}
+
+// GetSearchSuggestions возвращает не более perGroup подсказок каждого типа,
+// общим списком по убыванию релевантности.
+func GetSearchSuggestions(ctx context.Context, q string, perGroup int) ([]models.SearchSuggestion, error) {
+	like := escapeLike(strings.ToLower(q))
+	rows, err := db.DB.QueryContext(ctx, searchSuggestQuery, q, like+"%", "%"+like+"%", perGroup)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SearchSuggestion{}
+	for rows.Next() {
+		var s models.SearchSuggestion
+		var sku, imagePath sql.NullString
+		if err := rows.Scan(&s.Type, &s.ID, &s.Name, &sku, &imagePath, &s.Score); err != nil {
+			return nil, err
+		}
+		s.SKU = sku.String
+		s.ImagePath = imagePath.String
+		out = append(out, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+
+	sort.SliceStable(out, func(i, j int) bool {
+		if out[i].Score != out[j].Score {
+			return out[i].Score > out[j].Score
+		}
+		return suggestTypeOrder[out[i].Type] < suggestTypeOrder[out[j].Type]
+	})
+	return out, nil
+}
@@ -0,0 +1,385 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+
+	"github.com/lib/pq"
+)
+
+var (
+	ErrShipmentNotAllowed   = errors.New("order cannot be shipped in current status")
+	ErrShipmentQuantity     = errors.New("shipment quantity exceeds unshipped quantity")
+	ErrNothingToShip        = errors.New("nothing left to ship")
+	ErrShipmentStatus       = errors.New("unknown shipment status")
+	ErrShipmentTransition   = errors.New("invalid shipment status transition")
+	ErrShipmentItemNotFound = errors.New("order item does not belong to order")
+)
+
+// shipmentStatusRank задаёт порядок статусов: откатываться назад нельзя.
+// failed — терминальный статус, в него можно перейти из любого, кроме delivered.
+var shipmentStatusRank = map[string]int{
+	models.ShipmentPending:        0,
+	models.ShipmentShipped:        1,
+	models.ShipmentInTransit:      2,
+	models.ShipmentOutForDelivery: 3,
+	models.ShipmentDelivered:      4,
+}
+
+// txQuerier — общий интерфейс *sql.DB и *sql.Tx для функций, вызываемых внутри транзакций.
+type txQuerier interface {
+	ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row
+}
+
+// unshippedQuantities возвращает неотгруженный остаток по каждой позиции заказа
+// (отправления в статусе failed не учитываются).
+func unshippedQuantities(ctx context.Context, q txQuerier, orderID int) (map[int]int, error) {
+	rows, err := q.QueryContext(ctx, `
+		SELECT oi.id, oi.quantity - COALESCE(SUM(si.quantity) FILTER (WHERE s.status <> $2), 0)
+		FROM order_items oi
+		LEFT JOIN shipment_items si ON si.order_item_id = oi.id
+		LEFT JOIN shipments s ON s.id = si.shipment_id
+		WHERE oi.order_id = $1
+		GROUP BY oi.id, oi.quantity
+	`, orderID, models.ShipmentFailed)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := map[int]int{}
+	for rows.Next() {
+		var id, remaining int
+		if err := rows.Scan(&id, &remaining); err != nil {
+			return nil, err
+		}
+		out[id] = remaining
+	}
+	return out, rows.Err()
+}
+
+// CreateShipment создаёт отправление по заказу. Возвращает sql.ErrNoRows, если заказа нет.
+func CreateShipment(ctx context.Context, orderID int, p *models.CreateShipmentPayload) (int, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var orderStatus string
+	if err = tx.QueryRowContext(ctx, `SELECT status FROM orders WHERE id=$1 FOR UPDATE`, orderID).Scan(&orderStatus); err != nil {
+		return 0, err
+	}
+	if orderStatus == "cancelled" || orderStatus == "delivered" {
+		err = ErrShipmentNotAllowed
+		return 0, err
+	}
+
+	remaining, err := unshippedQuantities(ctx, tx, orderID)
+	if err != nil {
+		return 0, err
+	}
+
+	items := p.Items
+	if len(items) == 0 {
+		for id, qty := range remaining {
+			if qty > 0 {
+				items = append(items, models.ShipmentItem{OrderItemID: id, Quantity: qty})
+			}
+		}
+		if len(items) == 0 {
+			err = ErrNothingToShip
+			return 0, err
+		}
+	}
+	for _, it := range items {
+		left, ok := remaining[it.OrderItemID]
+		if !ok {
+			err = fmt.Errorf("%w: %d", ErrShipmentItemNotFound, it.OrderItemID)
+			return 0, err
+		}
+		if it.Quantity <= 0 || it.Quantity > left {
+			err = fmt.Errorf("%w: order item %d", ErrShipmentQuantity, it.OrderItemID)
+			return 0, err
+		}
+		remaining[it.OrderItemID] = left - it.Quantity
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO shipments (order_id, carrier, tracking_number, status)
+		VALUES ($1,$2,$3,$4) RETURNING id
+	`, orderID, p.Carrier, p.TrackingNumber, models.ShipmentPending).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	for _, it := range items {
+		if _, err = tx.ExecContext(ctx,
+			`INSERT INTO shipment_items (shipment_id, order_item_id, quantity) VALUES ($1,$2,$3)`,
+			id, it.OrderItemID, it.Quantity); err != nil {
+			return 0, err
+		}
+	}
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO shipment_events (shipment_id, status) VALUES ($1,$2)`, id, models.ShipmentPending); err != nil {
+		return 0, err
+	}
+
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateShipmentStatus двигает отправление по статусам, пишет событие в хронологию
+// и пересчитывает статус заказа и order_deliveries. sql.ErrNoRows — отправление не найдено.
+func UpdateShipmentStatus(ctx context.Context, shipmentID int, p *models.ShipmentStatusPayload) error {
+	if _, ok := shipmentStatusRank[p.Status]; !ok && p.Status != models.ShipmentFailed {
+		return fmt.Errorf("%w: %s", ErrShipmentStatus, p.Status)
+	}
+
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var orderID int
+	var current string
+	if err = tx.QueryRowContext(ctx,
+		`SELECT order_id, status FROM shipments WHERE id=$1 FOR UPDATE`, shipmentID).Scan(&orderID, &current); err != nil {
+		return err
+	}
+
+	if current == models.ShipmentFailed || current == models.ShipmentDelivered {
+		err = fmt.Errorf("%w: %s is final", ErrShipmentTransition, current)
+		return err
+	}
+	// повтор того же статуса допустим (например, новое место в in_transit)
+	if p.Status != models.ShipmentFailed && shipmentStatusRank[p.Status] < shipmentStatusRank[current] {
+		err = fmt.Errorf("%w: %s -> %s", ErrShipmentTransition, current, p.Status)
+		return err
+	}
+
+	inTransit := p.Status != models.ShipmentPending && p.Status != models.ShipmentFailed
+	delivered := p.Status == models.ShipmentDelivered
+	_, err = tx.ExecContext(ctx, `
+		UPDATE shipments SET
+			status = $1,
+			shipped_at = CASE WHEN shipped_at IS NULL AND $3 THEN NOW() ELSE shipped_at END,
+			delivered_at = CASE WHEN $4 THEN NOW() ELSE delivered_at END,
+			updated_at = NOW()
+		WHERE id = $2
+	`, p.Status, shipmentID, inTransit, delivered)
+	if err != nil {
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO shipment_events (shipment_id, status, location, comment) VALUES ($1,$2,$3,$4)`,
+		shipmentID, p.Status, nullableString(p.Location), nullableString(p.Comment)); err != nil {
+		return err
+	}
+
+	if err = rollupOrderShipmentStatus(ctx, tx, orderID); err != nil {
+		return err
+	}
+
+	return tx.Commit()
+}
+
+// rollupOrderShipmentStatus выводит статус заказа из отправлений:
+// всё доставлено — delivered, всё отгружено — shipped, часть отгружена — partially_shipped.
+// Если в пути ничего нет (например, единственное отправление ушло в failed), заказ,
+// ранее помеченный отгруженным, возвращается в processing, а доставка — в pending.
+// Доставка получает свой статус: pending, in_transit или delivered.
+func rollupOrderShipmentStatus(ctx context.Context, q txQuerier, orderID int) error {
+	rows, err := q.QueryContext(ctx, `
+		SELECT oi.quantity,
+		       COALESCE(SUM(si.quantity) FILTER (WHERE s.status IN ($2,$3,$4,$5)), 0),
+		       COALESCE(SUM(si.quantity) FILTER (WHERE s.status = $5), 0)
+		FROM order_items oi
+		LEFT JOIN shipment_items si ON si.order_item_id = oi.id
+		LEFT JOIN shipments s ON s.id = si.shipment_id
+		WHERE oi.order_id = $1
+		GROUP BY oi.id, oi.quantity
+	`, orderID, models.ShipmentShipped, models.ShipmentInTransit, models.ShipmentOutForDelivery, models.ShipmentDelivered)
+	if err != nil {
+		return err
+	}
+	allShipped, allDelivered, anyShipped := true, true, false
+	for rows.Next() {
+		var ordered, shipped, delivered int
+		if err := rows.Scan(&ordered, &shipped, &delivered); err != nil {
+			rows.Close()
+			return err
+		}
+		if shipped < ordered {
+			allShipped = false
+		}
+		if delivered < ordered {
+			allDelivered = false
+		}
+		if shipped > 0 {
+			anyShipped = true
+		}
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return err
+	}
+
+	if !anyShipped {
+		if _, err := q.ExecContext(ctx, `
+			UPDATE orders SET status='processing', updated_at=NOW()
+			WHERE id=$1 AND status IN ('shipped', 'partially_shipped')`, orderID); err != nil {
+			return err
+		}
+		return setDeliveryStatus(ctx, q, orderID, models.DeliveryPending, models.DeliveryInTransit)
+	}
+
+	// статус заказа и статус доставки — разные наборы значений
+	var status, deliveryStatus string
+	switch {
+	case allDelivered:
+		status, deliveryStatus = "delivered", models.DeliveryDelivered
+	case allShipped:
+		status, deliveryStatus = "shipped", models.DeliveryInTransit
+	default:
+		status, deliveryStatus = "partially_shipped", models.DeliveryInTransit
+	}
+
+	if _, err := q.ExecContext(ctx,
+		`UPDATE orders SET status=$1, updated_at=NOW() WHERE id=$2 AND status <> 'cancelled'`, status, orderID); err != nil {
+		return err
+	}
+	if err := setDeliveryStatus(ctx, q, orderID, deliveryStatus); err != nil {
+		return err
+	}
+	if allDelivered {
+		return markVerifiedReviews(ctx, q, orderID)
+	}
+	return nil
+}
+
+// setDeliveryStatus переводит доставку заказа в status. Доставка отменённого заказа не трогается.
+// from — если задан, обновляется только доставка в одном из этих статусов (пустой from
+// передаётся как NULL — без ограничения).
+func setDeliveryStatus(ctx context.Context, q txQuerier, orderID int, status string, from ...string) error {
+	_, err := q.ExecContext(ctx, `
+		UPDATE order_deliveries od SET status = $1
+		  FROM orders o
+		 WHERE od.order_id = $2 AND o.id = od.order_id AND o.status <> 'cancelled'
+		   AND ($3::text[] IS NULL OR od.status = ANY($3))`, status, orderID, pq.Array(from))
+	return err
+}
+
+// GetOrderShipments возвращает отправления заказа с позициями и хронологией событий.
+func GetOrderShipments(ctx context.Context, orderID int) ([]models.Shipment, error) {
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT id, order_id, carrier, tracking_number, status, shipped_at, delivered_at, created_at, updated_at
+		FROM shipments WHERE order_id=$1 ORDER BY id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	shipments := []models.Shipment{}
+	index := map[int]int{}
+	for rows.Next() {
+		var s models.Shipment
+		var shipped, delivered sql.NullTime
+		if err := rows.Scan(&s.ID, &s.OrderID, &s.Carrier, &s.TrackingNumber, &s.Status,
+			&shipped, &delivered, &s.CreatedAt, &s.UpdatedAt); err != nil {
+			rows.Close()
+			return nil, err
+		}
+		if shipped.Valid {
+			s.ShippedAt = &shipped.Time
+		}
+		if delivered.Valid {
+			s.DeliveredAt = &delivered.Time
+		}
+		s.Items = []models.ShipmentItem{}
+		s.Events = []models.ShipmentEvent{}
+		index[s.ID] = len(shipments)
+		shipments = append(shipments, s)
+	}
+	rows.Close()
+	if err := rows.Err(); err != nil {
+		return nil, err
+	}
+	if len(shipments) == 0 {
+		return shipments, nil
+	}
+
+	itemRows, err := db.DB.QueryContext(ctx, `
+		SELECT si.shipment_id, si.order_item_id, oi.product_id, si.quantity
+		FROM shipment_items si
+		JOIN shipments s ON s.id = si.shipment_id
+		JOIN order_items oi ON oi.id = si.order_item_id
+		WHERE s.order_id = $1
+		ORDER BY si.order_item_id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	for itemRows.Next() {
+		var shipmentID int
+		var it models.ShipmentItem
+		if err := itemRows.Scan(&shipmentID, &it.OrderItemID, &it.ProductID, &it.Quantity); err != nil {
+			itemRows.Close()
+			return nil, err
+		}
+		if i, ok := index[shipmentID]; ok {
+			shipments[i].Items = append(shipments[i].Items, it)
+		}
+	}
+	itemRows.Close()
+	if err := itemRows.Err(); err != nil {
+		return nil, err
+	}
+
+	eventRows, err := db.DB.QueryContext(ctx, `
+		SELECT e.shipment_id, e.id, e.status, e.location, e.comment, e.created_at
+		FROM shipment_events e
+		JOIN shipments s ON s.id = e.shipment_id
+		WHERE s.order_id = $1
+		ORDER BY e.created_at, e.id
+	`, orderID)
+	if err != nil {
+		return nil, err
+	}
+	defer eventRows.Close()
+	for eventRows.Next() {
+		var shipmentID int
+		var e models.ShipmentEvent
+		var location, comment sql.NullString
+		if err := eventRows.Scan(&shipmentID, &e.ID, &e.Status, &location, &comment, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		if location.Valid {
+			e.Location = location.String
+		}
+		if comment.Valid {
+			e.Comment = comment.String
+		}
+		if i, ok := index[shipmentID]; ok {
+			shipments[i].Events = append(shipments[i].Events, e)
+		}
+	}
+	return shipments, eventRows.Err()
+}
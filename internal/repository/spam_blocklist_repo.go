@@ -0,0 +1,49 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+// GetSpamBlocklist возвращает весь стоп-лист антиспама.
+func GetSpamBlocklist(ctx context.Context) ([]models.SpamBlocklistEntry, error) {
+	rows, err := db.DB.QueryContext(ctx, `SELECT id, kind, value, created_at FROM spam_blocklist ORDER BY kind, id`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	out := []models.SpamBlocklistEntry{}
+	for rows.Next() {
+		var e models.SpamBlocklistEntry
+		if err := rows.Scan(&e.ID, &e.Kind, &e.Value, &e.CreatedAt); err != nil {
+			return nil, err
+		}
+		out = append(out, e)
+	}
+	return out, rows.Err()
+}
+
+// CreateSpamBlocklistEntry добавляет запись; повтор (без учёта регистра) — ошибка уникальности.
+func CreateSpamBlocklistEntry(ctx context.Context, e *models.SpamBlocklistEntry) (int, error) {
+	var id int
+	err := db.DB.QueryRowContext(ctx,
+		`INSERT INTO spam_blocklist (kind, value) VALUES ($1, $2) RETURNING id, created_at`,
+		e.Kind, e.Value).Scan(&id, &e.CreatedAt)
+	return id, err
+}
+
+// DeleteSpamBlocklistEntry удаляет запись по id; sql.ErrNoRows — записи нет.
+func DeleteSpamBlocklistEntry(ctx context.Context, id int) error {
+	res, err := db.DB.ExecContext(ctx, `DELETE FROM spam_blocklist WHERE id = $1`, id)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		return sql.ErrNoRows
+	}
+	return nil
+}
@@ -0,0 +1,163 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+const userAddressSelect = `SELECT id, user_id, label, address, recipient_name, recipient_phone, is_default, created_at, updated_at
+	FROM user_addresses`
+
+func scanUserAddress(sc interface{ Scan(...interface{}) error }) (*models.UserAddress, error) {
+	var a models.UserAddress
+	var label sql.NullString
+	if err := sc.Scan(&a.ID, &a.UserID, &label, &a.Address, &a.RecipientName, &a.RecipientPhone,
+		&a.IsDefault, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	if label.Valid {
+		a.Label = label.String
+	}
+	return &a, nil
+}
+
+// GetUserAddresses возвращает адреса пользователя, адрес по умолчанию — первым.
+func GetUserAddresses(ctx context.Context, userID int) ([]models.UserAddress, error) {
+	rows, err := db.DB.QueryContext(ctx, userAddressSelect+` WHERE user_id=$1 ORDER BY is_default DESC, id`, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.UserAddress{}
+	for rows.Next() {
+		a, err := scanUserAddress(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// GetUserAddress возвращает адрес пользователя по id (nil, nil если не найден или чужой).
+func GetUserAddress(ctx context.Context, userID, id int) (*models.UserAddress, error) {
+	a, err := scanUserAddress(db.DB.QueryRowContext(ctx, userAddressSelect+` WHERE id=$1 AND user_id=$2`, id, userID))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	return a, err
+}
+
+// CreateUserAddress добавляет адрес. Первый адрес пользователя всегда становится адресом по умолчанию.
+func CreateUserAddress(ctx context.Context, userID int, p *models.UserAddressPayload) (int, error) {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	isDefault := p.IsDefault
+	if !isDefault {
+		var count int
+		if err = tx.QueryRowContext(ctx, `SELECT COUNT(*) FROM user_addresses WHERE user_id=$1`, userID).Scan(&count); err != nil {
+			return 0, err
+		}
+		isDefault = count == 0
+	}
+	if isDefault {
+		if _, err = tx.ExecContext(ctx, `UPDATE user_addresses SET is_default=FALSE WHERE user_id=$1 AND is_default`, userID); err != nil {
+			return 0, err
+		}
+	}
+
+	var id int
+	err = tx.QueryRowContext(ctx, `
+		INSERT INTO user_addresses (user_id, label, address, recipient_name, recipient_phone, is_default)
+		VALUES ($1,$2,$3,$4,$5,$6) RETURNING id
+	`, userID, nullableString(p.Label), p.Address, p.RecipientName, p.RecipientPhone, isDefault).Scan(&id)
+	if err != nil {
+		return 0, err
+	}
+	if err = tx.Commit(); err != nil {
+		return 0, err
+	}
+	return id, nil
+}
+
+// UpdateUserAddress обновляет адрес пользователя. Возвращает sql.ErrNoRows, если адрес не найден.
+// Снять флаг по умолчанию можно только назначив другой адрес основным.
+func UpdateUserAddress(ctx context.Context, userID, id int, p *models.UserAddressPayload) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	if p.IsDefault {
+		if _, err = tx.ExecContext(ctx,
+			`UPDATE user_addresses SET is_default=FALSE WHERE user_id=$1 AND is_default AND id<>$2`, userID, id); err != nil {
+			return err
+		}
+	}
+
+	res, err := tx.ExecContext(ctx, `
+		UPDATE user_addresses SET
+			label = $1,
+			address = $2,
+			recipient_name = $3,
+			recipient_phone = $4,
+			is_default = is_default OR $5,
+			updated_at = NOW()
+		WHERE id = $6 AND user_id = $7
+	`, nullableString(p.Label), p.Address, p.RecipientName, p.RecipientPhone, p.IsDefault, id, userID)
+	if err != nil {
+		return err
+	}
+	if n, _ := res.RowsAffected(); n == 0 {
+		err = sql.ErrNoRows
+		return err
+	}
+	return tx.Commit()
+}
+
+// DeleteUserAddress удаляет адрес; если он был основным, основным становится самый старый из оставшихся.
+// Заказы не затрагиваются: данные адреса копируются в order_deliveries при оформлении.
+func DeleteUserAddress(ctx context.Context, userID, id int) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var wasDefault bool
+	err = tx.QueryRowContext(ctx,
+		`DELETE FROM user_addresses WHERE id=$1 AND user_id=$2 RETURNING is_default`, id, userID).Scan(&wasDefault)
+	if err != nil {
+		return err
+	}
+	if wasDefault {
+		if _, err = tx.ExecContext(ctx, `
+			UPDATE user_addresses SET is_default=TRUE
+			WHERE id = (SELECT id FROM user_addresses WHERE user_id=$1 ORDER BY id LIMIT 1)
+		`, userID); err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
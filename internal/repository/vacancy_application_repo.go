@@ -0,0 +1,187 @@
+package repository
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+
+	"x86trade_backend/internal/db"
+	"x86trade_backend/internal/models"
+)
+
+var ErrApplicationTransition = errors.New("invalid application status transition")
+
+// applicationTransitions — допустимые переходы статусов отклика; rejected и hired — финальные.
+var applicationTransitions = map[string][]string{
+	models.ApplicationNew:       {models.ApplicationScreening, models.ApplicationInterview, models.ApplicationRejected},
+	models.ApplicationScreening: {models.ApplicationInterview, models.ApplicationRejected},
+	models.ApplicationInterview: {models.ApplicationHired, models.ApplicationRejected},
+}
+
+func canTransitionApplication(from, to string) bool {
+	for _, s := range applicationTransitions[from] {
+		if s == to {
+			return true
+		}
+	}
+	return false
+}
+
+// IsApplicationStatus сообщает, что s — известный статус отклика.
+func IsApplicationStatus(s string) bool {
+	switch s {
+	case models.ApplicationNew, models.ApplicationScreening, models.ApplicationInterview,
+		models.ApplicationRejected, models.ApplicationHired:
+		return true
+	}
+	return false
+}
+
+// CreateVacancyApplication сохраняет отклик (файл резюме к этому моменту уже в хранилище).
+func CreateVacancyApplication(ctx context.Context, a *models.VacancyApplication) (int, error) {
+	var id int
+	err := db.DB.QueryRowContext(ctx, `
+		INSERT INTO vacancy_applications
+			(vacancy_id, full_name, email, phone, cover_letter, cv_key, cv_filename, cv_content_type, cv_size)
+		VALUES ($1,$2,$3,$4,$5,$6,$7,$8,$9)
+		RETURNING id
+	`, a.VacancyID, a.FullName, a.Email, nullableString(a.Phone), nullableString(a.CoverLetter),
+		a.CVKey, a.CVFilename, a.CVContentType, a.CVSize).Scan(&id)
+	return id, err
+}
+
+const applicationSelect = `
+	SELECT a.id, a.vacancy_id, COALESCE(v.title, ''), a.full_name, a.email, a.phone, a.cover_letter,
+	       a.cv_key, a.cv_filename, a.cv_content_type, a.cv_size, a.status, a.hr_comment, a.created_at, a.updated_at
+	FROM vacancy_applications a
+	LEFT JOIN vacancies v ON v.id = a.vacancy_id`
+
+func scanApplication(sc interface{ Scan(...interface{}) error }) (*models.VacancyApplication, error) {
+	var a models.VacancyApplication
+	var phone, letter, comment sql.NullString
+	if err := sc.Scan(&a.ID, &a.VacancyID, &a.VacancyTitle, &a.FullName, &a.Email, &phone, &letter,
+		&a.CVKey, &a.CVFilename, &a.CVContentType, &a.CVSize, &a.Status, &comment, &a.CreatedAt, &a.UpdatedAt); err != nil {
+		return nil, err
+	}
+	a.Phone = phone.String
+	a.CoverLetter = letter.String
+	a.HRComment = comment.String
+	return &a, nil
+}
+
+// GetVacancyApplications возвращает отклики на вакансию, новые первыми; status — необязательный фильтр.
+func GetVacancyApplications(ctx context.Context, vacancyID int, status string) ([]models.VacancyApplication, error) {
+	args := []interface{}{vacancyID}
+	q := applicationSelect + " WHERE a.vacancy_id=$1"
+	if status != "" {
+		args = append(args, status)
+		q += fmt.Sprintf(" AND a.status=$%d", len(args))
+	}
+	q += " ORDER BY a.created_at DESC, a.id DESC"
+
+	rows, err := db.DB.QueryContext(ctx, q, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	out := []models.VacancyApplication{}
+	for rows.Next() {
+		a, err := scanApplication(rows)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, *a)
+	}
+	return out, rows.Err()
+}
+
+// GetVacancyApplicationByID возвращает отклик с историей статусов (nil, nil если не найден).
+func GetVacancyApplicationByID(ctx context.Context, id int) (*models.VacancyApplication, error) {
+	a, err := scanApplication(db.DB.QueryRowContext(ctx, applicationSelect+" WHERE a.id=$1", id))
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	rows, err := db.DB.QueryContext(ctx, `
+		SELECT id, status, comment, changed_by, created_at
+		FROM vacancy_application_status_history WHERE application_id=$1 ORDER BY created_at, id
+	`, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	for rows.Next() {
+		var h models.VacancyApplicationStatusChange
+		var comment sql.NullString
+		var changedBy sql.NullInt64
+		if err := rows.Scan(&h.ID, &h.Status, &comment, &changedBy, &h.CreatedAt); err != nil {
+			return nil, err
+		}
+		h.Comment = comment.String
+		if changedBy.Valid {
+			v := int(changedBy.Int64)
+			h.ChangedBy = &v
+		}
+		a.History = append(a.History, h)
+	}
+	return a, rows.Err()
+}
+
+// TransitionVacancyApplication меняет статус отклика (действие сотрудника adminID) и пишет историю.
+// sql.ErrNoRows — отклика нет; ErrApplicationTransition — переход не разрешён.
+func TransitionVacancyApplication(ctx context.Context, id, adminID int, p *models.VacancyApplicationStatusPayload) error {
+	tx, err := db.DB.BeginTx(ctx, nil)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err != nil {
+			_ = tx.Rollback()
+		}
+	}()
+
+	var current string
+	err = tx.QueryRowContext(ctx, `SELECT status FROM vacancy_applications WHERE id=$1 FOR UPDATE`, id).Scan(&current)
+	if err != nil {
+		return err
+	}
+	if !canTransitionApplication(current, p.Status) {
+		err = fmt.Errorf("%w: %s -> %s", ErrApplicationTransition, current, p.Status)
+		return err
+	}
+
+	if _, err = tx.ExecContext(ctx,
+		`UPDATE vacancy_applications SET status=$1, hr_comment=COALESCE($2, hr_comment), updated_at=NOW() WHERE id=$3`,
+		p.Status, nullableString(p.Comment), id); err != nil {
+		return err
+	}
+	if _, err = tx.ExecContext(ctx,
+		`INSERT INTO vacancy_application_status_history (application_id, status, comment, changed_by) VALUES ($1,$2,$3,$4)`,
+		id, p.Status, nullableString(p.Comment), adminID); err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// GetVacancyCVKeys возвращает ключи файлов резюме всех откликов на вакансию —
+// чтобы удалить файлы вместе с вакансией (строки удаляются каскадом).
+func GetVacancyCVKeys(ctx context.Context, vacancyID int) ([]string, error) {
+	rows, err := db.DB.QueryContext(ctx, `SELECT cv_key FROM vacancy_applications WHERE vacancy_id=$1`, vacancyID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+	var keys []string
+	for rows.Next() {
+		var k string
+		if err := rows.Scan(&k); err != nil {
+			return nil, err
+		}
+		keys = append(keys, k)
+	}
+	return keys, rows.Err()
+}
@@ -0,0 +1,50 @@
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header — заголовок, в котором ID запроса принимается от прокси/клиента и возвращается в ответе.
+const Header = "X-Request-ID"
+
+// maxLen — предельная длина ID, принятого извне.
+const maxLen = 128
+
+type ctxKey struct{}
+
+// Valid сообщает, можно ли принять ID от прокси/клиента: 1..128 символов из [A-Za-z0-9._-].
+// ID попадает в заголовки ответа, логи и тела ошибок, поэтому всё остальное отбрасывается.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLen {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		c := id[i]
+		if !('a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || '0' <= c && c <= '9' || c == '.' || c == '_' || c == '-') {
+			return false
+		}
+	}
+	return true
+}
+
+// New генерирует случайный ID запроса (16 байт в hex).
+func New() string {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return ""
+	}
+	return hex.EncodeToString(b)
+}
+
+// WithContext кладёт ID запроса в контекст.
+func WithContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, ctxKey{}, id)
+}
+
+// FromContext возвращает ID запроса или пустую строку.
+func FromContext(ctx context.Context) string {
+	id, _ := ctx.Value(ctxKey{}).(string)
+	return id
+}
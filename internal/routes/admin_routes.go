@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/handlers/admin_handlers"
 
 	"github.com/go-chi/chi/v5"
@@ -10,68 +11,110 @@ import (
 // Вызывается внутри защищённой группы, где уже подключён middleware AdminOnly.
 func RegisterAdminRoutes(r chi.Router) {
 	// users CRUD (admin)
-	r.Get("/api/admin/users", admin_handlers.AdminGetUsers)
-	r.Get("/api/admin/users/{id}", admin_handlers.AdminGetUserByID)
-	r.Post("/api/admin/users", admin_handlers.AdminCreateUser)
-	r.Put("/api/admin/users/{id}", admin_handlers.AdminUpdateUser)
-	r.Delete("/api/admin/users/{id}", admin_handlers.AdminDeleteUser)
+	r.Get("/api/admin/users", apperr.Handler(admin_handlers.AdminGetUsers))
+	r.Get("/api/admin/users/{id}", apperr.Handler(admin_handlers.AdminGetUserByID))
+	r.Post("/api/admin/users", apperr.Handler(admin_handlers.AdminCreateUser))
+	r.Put("/api/admin/users/{id}", apperr.Handler(admin_handlers.AdminUpdateUser))
+	r.Delete("/api/admin/users/{id}", apperr.Handler(admin_handlers.AdminDeleteUser))
 
 	// products CRUD (admin)
-	r.Get("/api/admin/products", admin_handlers.AdminGetProducts)
-	r.Get("/api/admin/products/{id}", admin_handlers.AdminGetProductByID)
-	r.Post("/api/admin/products", admin_handlers.AdminCreateProduct)
-	r.Put("/api/admin/products/{id}", admin_handlers.AdminUpdateProduct)
-	r.Delete("/api/admin/products/{id}", admin_handlers.AdminDeleteProduct)
+	r.Get("/api/admin/products", apperr.Handler(admin_handlers.AdminGetProducts))
+	r.Get("/api/admin/products/{id}", apperr.Handler(admin_handlers.AdminGetProductByID))
+	r.Post("/api/admin/products", apperr.Handler(admin_handlers.AdminCreateProduct))
+	r.Put("/api/admin/products/{id}", apperr.Handler(admin_handlers.AdminUpdateProduct))
+	r.Delete("/api/admin/products/{id}", apperr.Handler(admin_handlers.AdminDeleteProduct))
+
+	// product images (admin): загрузка с превью, порядок, главное изображение
+	r.Get("/api/admin/products/{id}/images", apperr.Handler(admin_handlers.AdminGetProductImages))
+	r.Post("/api/admin/products/{id}/images", apperr.Handler(admin_handlers.AdminUploadProductImages))
+	r.Put("/api/admin/products/{id}/images/order", apperr.Handler(admin_handlers.AdminReorderProductImages))
+	r.Put("/api/admin/products/{id}/images/{imageID}/primary", apperr.Handler(admin_handlers.AdminSetPrimaryProductImage))
+	r.Delete("/api/admin/products/{id}/images/{imageID}", apperr.Handler(admin_handlers.AdminDeleteProductImage))
 
 	// categories CRUD (admin)
-	r.Get("/api/admin/categories", admin_handlers.AdminGetCategories)
-	r.Post("/api/admin/categories", admin_handlers.AdminCreateCategory)
-	r.Put("/api/admin/categories/{id}", admin_handlers.AdminUpdateCategory)
-	r.Delete("/api/admin/categories/{id}", admin_handlers.AdminDeleteCategory)
+	r.Get("/api/admin/categories", apperr.Handler(admin_handlers.AdminGetCategories))
+	r.Post("/api/admin/categories", apperr.Handler(admin_handlers.AdminCreateCategory))
+	r.Put("/api/admin/categories/{id}", apperr.Handler(admin_handlers.AdminUpdateCategory))
+	r.Delete("/api/admin/categories/{id}", apperr.Handler(admin_handlers.AdminDeleteCategory))
 
 	// manufacturers CRUD (admin)
-	r.Get("/api/admin/manufacturers", admin_handlers.AdminGetManufacturers)
-	r.Post("/api/admin/manufacturers", admin_handlers.AdminCreateManufacturer)
-	r.Put("/api/admin/manufacturers/{id}", admin_handlers.AdminUpdateManufacturer)
-	r.Delete("/api/admin/manufacturers/{id}", admin_handlers.AdminDeleteManufacturer)
+	r.Get("/api/admin/manufacturers", apperr.Handler(admin_handlers.AdminGetManufacturers))
+	r.Post("/api/admin/manufacturers", apperr.Handler(admin_handlers.AdminCreateManufacturer))
+	r.Put("/api/admin/manufacturers/{id}", apperr.Handler(admin_handlers.AdminUpdateManufacturer))
+	r.Delete("/api/admin/manufacturers/{id}", apperr.Handler(admin_handlers.AdminDeleteManufacturer))
 
 	// delivery_methods CRUD (admin)
-	r.Get("/api/admin/delivery_methods", admin_handlers.AdminGetDeliveryMethods)
-	r.Post("/api/admin/delivery_methods", admin_handlers.AdminCreateDeliveryMethod)
-	r.Put("/api/admin/delivery_methods/{id}", admin_handlers.AdminUpdateDeliveryMethod)
-	r.Delete("/api/admin/delivery_methods/{id}", admin_handlers.AdminDeleteDeliveryMethod)
+	r.Get("/api/admin/delivery_methods", apperr.Handler(admin_handlers.AdminGetDeliveryMethods))
+	r.Post("/api/admin/delivery_methods", apperr.Handler(admin_handlers.AdminCreateDeliveryMethod))
+	r.Put("/api/admin/delivery_methods/{id}", apperr.Handler(admin_handlers.AdminUpdateDeliveryMethod))
+	r.Delete("/api/admin/delivery_methods/{id}", apperr.Handler(admin_handlers.AdminDeleteDeliveryMethod))
 
 	// payment methods CRUD (admin)
-	r.Get("/api/admin/payment_methods", admin_handlers.AdminGetPaymentMethods)
-	r.Post("/api/admin/payment_methods", admin_handlers.AdminCreatePaymentMethod)
-	r.Put("/api/admin/payment_methods/{id}", admin_handlers.AdminUpdatePaymentMethod)
-	r.Delete("/api/admin/payment_methods/{id}", admin_handlers.AdminDeletePaymentMethod)
+	r.Get("/api/admin/payment_methods", apperr.Handler(admin_handlers.AdminGetPaymentMethods))
+	r.Post("/api/admin/payment_methods", apperr.Handler(admin_handlers.AdminCreatePaymentMethod))
+	r.Put("/api/admin/payment_methods/{id}", apperr.Handler(admin_handlers.AdminUpdatePaymentMethod))
+	r.Delete("/api/admin/payment_methods/{id}", apperr.Handler(admin_handlers.AdminDeletePaymentMethod))
 
 	// vacancies CRUD (admin)
-	r.Get("/api/admin/vacancies", admin_handlers.AdminGetVacancies)
-	r.Get("/api/admin/vacancies/{id}", admin_handlers.AdminGetVacancyByID)
-	r.Post("/api/admin/vacancies", admin_handlers.AdminCreateVacancy)
-	r.Put("/api/admin/vacancies/{id}", admin_handlers.AdminUpdateVacancy)
-	r.Delete("/api/admin/vacancies/{id}", admin_handlers.AdminDeleteVacancy)
+	r.Get("/api/admin/vacancies", apperr.Handler(admin_handlers.AdminGetVacancies))
+	r.Get("/api/admin/vacancies/{id}", apperr.Handler(admin_handlers.AdminGetVacancyByID))
+	r.Post("/api/admin/vacancies", apperr.Handler(admin_handlers.AdminCreateVacancy))
+	r.Put("/api/admin/vacancies/{id}", apperr.Handler(admin_handlers.AdminUpdateVacancy))
+	r.Delete("/api/admin/vacancies/{id}", apperr.Handler(admin_handlers.AdminDeleteVacancy))
+
+	// vacancy applications (HR)
+	r.Get("/api/admin/vacancies/{id}/applications", apperr.Handler(admin_handlers.AdminGetVacancyApplications))
+	r.Get("/api/admin/vacancy_applications/{id}", apperr.Handler(admin_handlers.AdminGetVacancyApplicationByID))
+	r.Put("/api/admin/vacancy_applications/{id}/status", apperr.Handler(admin_handlers.AdminUpdateVacancyApplicationStatus))
+	r.Get("/api/admin/vacancy_applications/{id}/cv", apperr.Handler(admin_handlers.AdminDownloadVacancyApplicationCV))
 
 	// characteristic types
-	r.Get("/api/admin/characteristic_types", admin_handlers.AdminGetCharacteristicTypes)
-	r.Post("/api/admin/characteristic_types", admin_handlers.AdminCreateCharacteristicType)
-	r.Put("/api/admin/characteristic_types/{id}", admin_handlers.AdminUpdateCharacteristicType)
-	r.Delete("/api/admin/characteristic_types/{id}", admin_handlers.AdminDeleteCharacteristicType)
+	r.Get("/api/admin/characteristic_types", apperr.Handler(admin_handlers.AdminGetCharacteristicTypes))
+	r.Get("/api/admin/characteristic_types/migration_report", apperr.Handler(admin_handlers.AdminCharacteristicMigrationReport))
+	r.Post("/api/admin/characteristic_types/{id}/normalize", apperr.Handler(admin_handlers.AdminNormalizeCharacteristicValues))
+	r.Post("/api/admin/characteristic_types", apperr.Handler(admin_handlers.AdminCreateCharacteristicType))
+	r.Put("/api/admin/characteristic_types/{id}", apperr.Handler(admin_handlers.AdminUpdateCharacteristicType))
+	r.Delete("/api/admin/characteristic_types/{id}", apperr.Handler(admin_handlers.AdminDeleteCharacteristicType))
 
 	// product characteristics
-	r.Get("/api/admin/product_characteristics", admin_handlers.AdminListProductCharacteristics)
-	r.Get("/api/admin/products/{product_id}/characteristics", admin_handlers.AdminGetProductCharacteristics)
-	r.Post("/api/admin/product_characteristics", admin_handlers.AdminCreateProductCharacteristic)
-	r.Put("/api/admin/product_characteristics/{id}", admin_handlers.AdminUpdateProductCharacteristic)
-	r.Delete("/api/admin/product_characteristics/{id}", admin_handlers.AdminDeleteProductCharacteristic)
+	r.Get("/api/admin/product_characteristics", apperr.Handler(admin_handlers.AdminListProductCharacteristics))
+	r.Get("/api/admin/products/{product_id}/characteristics", apperr.Handler(admin_handlers.AdminGetProductCharacteristics))
+	r.Post("/api/admin/product_characteristics", apperr.Handler(admin_handlers.AdminCreateProductCharacteristic))
+	r.Put("/api/admin/product_characteristics/{id}", apperr.Handler(admin_handlers.AdminUpdateProductCharacteristic))
+	r.Delete("/api/admin/product_characteristics/{id}", apperr.Handler(admin_handlers.AdminDeleteProductCharacteristic))
 
 	// orders CRUD (admin)
-	r.Get("/api/admin/orders", admin_handlers.AdminGetOrders)
-	r.Put("/api/admin/orders/{id}/status", admin_handlers.AdminUpdateOrderStatus)
-	r.Put("/api/admin/orders/{id}", admin_handlers.AdminUpdateOrder)
+	r.Get("/api/admin/orders", apperr.Handler(admin_handlers.AdminGetOrders))
+	r.Put("/api/admin/orders/{id}/status", apperr.Handler(admin_handlers.AdminUpdateOrderStatus))
+	r.Put("/api/admin/orders/{id}", apperr.Handler(admin_handlers.AdminUpdateOrder))
+
+	// shipments (admin)
+	r.Get("/api/admin/orders/{id}/shipments", apperr.Handler(admin_handlers.AdminGetOrderShipments))
+	r.Post("/api/admin/orders/{id}/shipments", apperr.Handler(admin_handlers.AdminCreateShipment))
+	r.Put("/api/admin/shipments/{id}/status", apperr.Handler(admin_handlers.AdminUpdateShipmentStatus))
+
+	// returns (RMA)
+	r.Get("/api/admin/returns", apperr.Handler(admin_handlers.AdminGetReturns))
+	r.Get("/api/admin/returns/{id}", apperr.Handler(admin_handlers.AdminGetReturnByID))
+	r.Put("/api/admin/returns/{id}/status", apperr.Handler(admin_handlers.AdminUpdateReturnStatus))
+
+	// contact messages inbox
+	r.Get("/api/admin/contact_messages", apperr.Handler(admin_handlers.AdminGetContactMessages))
+	r.Get("/api/admin/contact_messages/{id}", apperr.Handler(admin_handlers.AdminGetContactMessageByID))
+	r.Put("/api/admin/contact_messages/{id}/processed", apperr.Handler(admin_handlers.AdminSetContactMessageProcessed))
+	r.Put("/api/admin/contact_messages/{id}/assign", apperr.Handler(admin_handlers.AdminAssignContactMessage))
+	r.Post("/api/admin/contact_messages/{id}/reply", apperr.Handler(admin_handlers.AdminReplyContactMessage))
+
+	// reviews moderation
+	r.Get("/api/admin/reviews", apperr.Handler(admin_handlers.AdminGetReviews))
+	r.Put("/api/admin/reviews/{id}/status", apperr.Handler(admin_handlers.AdminModerateReview))
+	r.Delete("/api/admin/reviews/{id}", apperr.Handler(admin_handlers.AdminDeleteReview))
+
+	// contact form anti-spam blocklist
+	r.Get("/api/admin/spam_blocklist", apperr.Handler(admin_handlers.AdminGetSpamBlocklist))
+	r.Post("/api/admin/spam_blocklist", apperr.Handler(admin_handlers.AdminCreateSpamBlocklistEntry))
+	r.Delete("/api/admin/spam_blocklist/{id}", apperr.Handler(admin_handlers.AdminDeleteSpamBlocklistEntry))
 
 	// replace-all (bulk) for product
-	r.Put("/api/admin/products/{product_id}/characteristics", admin_handlers.AdminReplaceProductCharacteristics)
+	r.Put("/api/admin/products/{product_id}/characteristics", apperr.Handler(admin_handlers.AdminReplaceProductCharacteristics))
 }
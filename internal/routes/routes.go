@@ -1,6 +1,7 @@
 package routes
 
 import (
+	"x86trade_backend/internal/apperr"
 	"x86trade_backend/internal/handlers"
 	"x86trade_backend/internal/middleware"
 
@@ -11,45 +12,83 @@ import (
 // CORS и logging теперь применяются извне (в main).
 func SetupRoutes(r chi.Router) {
 	// Публичные роуты
-	r.Post("/api/auth/register", handlers.RegisterHandler)
-	r.Post("/api/auth/login", handlers.LoginHandler)
-	r.Post("/api/auth/refresh", handlers.RefreshHandler)
-	r.Post("/api/auth/logout", handlers.LogoutHandler)
+	// Лимиты частоты (middleware.RateLimit) — по IP клиента, политики задаются в конфиге
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(middleware.LimitAuth, middleware.KeyByIP))
+		r.Post("/api/auth/register", apperr.Handler(handlers.RegisterHandler))
+		r.Post("/api/auth/login", apperr.Handler(handlers.LoginHandler))
+		r.Post("/api/auth/refresh", apperr.Handler(handlers.RefreshHandler))
+	})
+	r.Post("/api/auth/logout", apperr.Handler(handlers.LogoutHandler))
+
+	r.Get("/api/contact/form_token", apperr.Handler(handlers.GetContactFormTokenHandler))
+	r.With(middleware.RateLimit(middleware.LimitContact, middleware.KeyByIP)).
+		Post("/api/contact", apperr.Handler(handlers.CreateContactMessageHandler))
+	r.Get("/api/vacancies", apperr.Handler(handlers.GetVacanciesHandler))
+	r.With(middleware.RateLimit(middleware.LimitApplications, middleware.KeyByIP)).
+		Post("/api/vacancies/{id}/applications", apperr.Handler(handlers.CreateVacancyApplicationHandler))
+
+	r.Group(func(r chi.Router) {
+		r.Use(middleware.RateLimit(middleware.LimitCatalog, middleware.KeyByIP))
+		r.Get("/api/products", apperr.Handler(handlers.GetProductsHandler))
+		r.Get("/api/products/{id}", apperr.Handler(handlers.GetProductHandler))
+		r.Get("/api/products/{id}/details", apperr.Handler(handlers.GetProductDetailsHandler))
+		r.Get("/api/products/{id}/reviews", apperr.Handler(handlers.GetProductReviewsHandler))
+	})
 
-	r.Post("/api/contact", handlers.CreateContactMessageHandler)
-	r.Get("/api/vacancies", handlers.GetVacanciesHandler)
+	r.With(middleware.RateLimit(middleware.LimitSuggest, middleware.KeyByIP)).
+		Get("/api/search/suggest", apperr.Handler(handlers.SearchSuggestHandler))
 
-	r.Get("/api/products", handlers.GetProductsHandler)
-	r.Get("/api/products/{id}", handlers.GetProductHandler)
-	r.Get("/api/products/{id}/details", handlers.GetProductDetailsHandler)
+	r.Get("/api/files/*", apperr.Handler(handlers.GetPublicFileHandler))
 
-	r.Get("/api/categories", handlers.GetCategoriesHandler)
-	r.Get("/api/delivery_methods", handlers.GetDeliveryMethodsHandler)
-	r.Get("/api/payment_methods", handlers.GetPaymentMethodsHandler)
+	r.Get("/api/categories", apperr.Handler(handlers.GetCategoriesHandler))
+	r.Get("/api/delivery_methods", apperr.Handler(handlers.GetDeliveryMethodsHandler))
+	r.Get("/api/payment_methods", apperr.Handler(handlers.GetPaymentMethodsHandler))
 
 	// Защищенные роуты (требуют аутентификации)
 	r.Group(func(r chi.Router) {
 		// Auth middleware — у вас уже реализовано в handlers.AuthMiddleware
 		r.Use(middleware.AuthMiddleware)
+		r.Use(middleware.RateLimit(middleware.LimitUser, middleware.KeyByUser))
 
 		// Cart
-		r.Get("/api/cart", handlers.GetCartHandler)
-		r.Post("/api/cart", handlers.AddToCartHandler)
-		r.Put("/api/cart", handlers.UpdateCartHandler)
-		r.Delete("/api/cart", handlers.ClearCartHandler)
-		r.Delete("/api/cart/{productID}", handlers.RemoveFromCartHandler)
+		r.Get("/api/cart", apperr.Handler(handlers.GetCartHandler))
+		r.Post("/api/cart", apperr.Handler(handlers.AddToCartHandler))
+		r.Put("/api/cart", apperr.Handler(handlers.UpdateCartHandler))
+		r.Delete("/api/cart", apperr.Handler(handlers.ClearCartHandler))
+		r.Delete("/api/cart/{productID}", apperr.Handler(handlers.RemoveFromCartHandler))
 
 		// Orders (user)
-		r.Get("/api/orders", handlers.GetOrdersHandler)
-		r.Get("/api/orders/{id}", handlers.GetOrderDetailsHandler)
-		r.Post("/api/orders", handlers.CreateOrderHandler)
-		r.Put("/api/orders/{orderID}/cancel", handlers.CancelOrderHandler)
+		r.Get("/api/orders", apperr.Handler(handlers.GetOrdersHandler))
+		r.Get("/api/orders/{id}", apperr.Handler(handlers.GetOrderDetailsHandler))
+		r.Post("/api/orders", apperr.Handler(handlers.CreateOrderHandler))
+		r.Put("/api/orders/{orderID}/cancel", apperr.Handler(handlers.CancelOrderHandler))
+		r.Post("/api/orders/{id}/reorder", apperr.Handler(handlers.ReorderHandler))
+
+		// Returns (RMA)
+		r.Post("/api/orders/{id}/returns", apperr.Handler(handlers.CreateReturnHandler))
+		r.Get("/api/returns", apperr.Handler(handlers.GetMyReturnsHandler))
+		r.Get("/api/returns/{id}", apperr.Handler(handlers.GetMyReturnHandler))
 
 		// Profile
-		r.Get("/api/auth/me", handlers.MeHandler)
-		r.Put("/api/auth/me", handlers.UpdateMeHandler)
+		r.Get("/api/auth/me", apperr.Handler(handlers.MeHandler))
+		r.Put("/api/auth/me", apperr.Handler(handlers.UpdateMeHandler))
+
+		// Address book
+		r.Get("/api/auth/me/addresses", apperr.Handler(handlers.GetMyAddressesHandler))
+		r.Post("/api/auth/me/addresses", apperr.Handler(handlers.CreateMyAddressHandler))
+		r.Put("/api/auth/me/addresses/{id}", apperr.Handler(handlers.UpdateMyAddressHandler))
+		r.Delete("/api/auth/me/addresses/{id}", apperr.Handler(handlers.DeleteMyAddressHandler))
 
-		r.Post("/api/reviews", handlers.CreateReviewHandler)
+		// Reviews (один на товар; правка и удаление — только автором)
+		r.Post("/api/reviews", apperr.Handler(handlers.CreateReviewHandler))
+		r.Get("/api/reviews/mine", apperr.Handler(handlers.GetMyReviewsHandler))
+		r.Put("/api/reviews/{id}", apperr.Handler(handlers.UpdateMyReviewHandler))
+		r.Delete("/api/reviews/{id}", apperr.Handler(handlers.DeleteMyReviewHandler))
+		r.Put("/api/reviews/{id}/vote", apperr.Handler(handlers.VoteReviewHandler))
+		r.Delete("/api/reviews/{id}/vote", apperr.Handler(handlers.DeleteReviewVoteHandler))
+		r.Post("/api/reviews/{id}/images", apperr.Handler(handlers.UploadReviewImagesHandler))
+		r.Delete("/api/reviews/{id}/images/{imageID}", apperr.Handler(handlers.DeleteReviewImageHandler))
 
 		// Админские роуты — регистрируем в отдельном модуле
 		r.Group(func(r chi.Router) {
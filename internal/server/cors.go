@@ -11,7 +11,8 @@ func ApplyCORS(r chi.Router, origins []string, allowCred bool) {
 	r.Use(cors.Handler(cors.Options{
 		AllowedOrigins:   origins,
 		AllowedMethods:   []string{"GET", "POST", "PUT", "DELETE", "OPTIONS"},
-		AllowedHeaders:   []string{"Content-Type", "Authorization"},
+		AllowedHeaders:   []string{"Content-Type", "Authorization", "X-Request-ID", "traceparent"},
+		ExposedHeaders:   []string{"X-Request-ID", "RateLimit-Limit", "RateLimit-Remaining", "RateLimit-Reset", "RateLimit-Policy", "Retry-After", "X-Search-Mode"},
 		AllowCredentials: allowCred,
 		MaxAge:           300,
 	}))
@@ -0,0 +1,62 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"x86trade_backend/internal/config"
+)
+
+// readHeaderTimeout — на заголовки медленному клиенту хватит и этого; остальное настраивается.
+const readHeaderTimeout = 5 * time.Second
+
+// NewHTTPServer создаёт http.Server с таймаутами: без них медленный клиент может держать
+// соединение и горутину сколько угодно.
+func NewHTTPServer(addr string, h http.Handler, cfg config.HTTP) *http.Server {
+	return &http.Server{
+		Addr:              addr,
+		Handler:           h,
+		ReadHeaderTimeout: min(readHeaderTimeout, cfg.ReadTimeout),
+		ReadTimeout:       cfg.ReadTimeout,
+		WriteTimeout:      cfg.WriteTimeout,
+		IdleTimeout:       cfg.IdleTimeout,
+	}
+}
+
+// Run обслуживает запросы, пока не отменён ctx (SIGTERM/SIGINT), затем вызывает onShutdown
+// (например, перевести /readyz в 503) и ждёт завершения активных запросов не дольше
+// drain (SHUTDOWN_TIMEOUT). Новые соединения после начала остановки не принимаются.
+func Run(ctx context.Context, srv *http.Server, drain time.Duration, onShutdown func()) error {
+	errCh := make(chan error, 1)
+	go func() {
+		errCh <- srv.ListenAndServe()
+	}()
+
+	select {
+	case err := <-errCh:
+		// не смогли занять порт и т.п.
+		return err
+	case <-ctx.Done():
+	}
+
+	slog.Info("shutdown signal received, draining connections", "timeout", drain.String())
+	if onShutdown != nil {
+		onShutdown()
+	}
+
+	shutdownCtx, cancel := context.WithTimeout(context.Background(), drain)
+	defer cancel()
+	if err := srv.Shutdown(shutdownCtx); err != nil {
+		// дедлайн вышел — закрываем оставшиеся соединения принудительно
+		_ = srv.Close()
+		return err
+	}
+	if err := <-errCh; err != nil && !errors.Is(err, http.ErrServerClosed) {
+		return err
+	}
+	slog.Info("server stopped gracefully")
+	return nil
+}
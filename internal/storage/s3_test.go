@@ -0,0 +1,137 @@
+package storage
+
+import (
+	"context"
+	"errors"
+	"io"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/minio/minio-go/v7"
+)
+
+// testS3 подключается к MinIO (или другому S3-совместимому хранилищу) из переменных
+// S3_TEST_ENDPOINT, S3_TEST_ACCESS_KEY, S3_TEST_SECRET_KEY и S3_TEST_BUCKET. Без них тест
+// пропускается. Локально:
+//
+//	docker run -p 9000:9000 minio/minio server /data
+//	S3_TEST_ENDPOINT=http://localhost:9000 S3_TEST_ACCESS_KEY=minioadmin \
+//	S3_TEST_SECRET_KEY=minioadmin S3_TEST_BUCKET=x86trade-test go test ./internal/storage/
+func testS3(t *testing.T) *S3 {
+	t.Helper()
+	endpoint := os.Getenv("S3_TEST_ENDPOINT")
+	if endpoint == "" {
+		t.Skip("S3_TEST_ENDPOINT is not set")
+	}
+	s, err := NewS3(S3Config{
+		Endpoint:  endpoint,
+		Bucket:    os.Getenv("S3_TEST_BUCKET"),
+		AccessKey: os.Getenv("S3_TEST_ACCESS_KEY"),
+		SecretKey: os.Getenv("S3_TEST_SECRET_KEY"),
+		PathStyle: true,
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	ctx := context.Background()
+	exists, err := s.client.BucketExists(ctx, s.bucket)
+	if err != nil {
+		t.Fatalf("BucketExists: %v", err)
+	}
+	if !exists {
+		if err := s.client.MakeBucket(ctx, s.bucket, minio.MakeBucketOptions{}); err != nil {
+			t.Fatalf("MakeBucket: %v", err)
+		}
+	}
+	return s
+}
+
+func TestS3PutOpenDelete(t *testing.T) {
+	s := testS3(t)
+	ctx := context.Background()
+	key := NewKey("products", ".jpg")
+
+	if err := s.Put(ctx, key, strings.NewReader("jpeg data"), 9, "image/jpeg"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	info, err := s.client.StatObject(ctx, s.bucket, key, minio.StatObjectOptions{})
+	if err != nil || info.ContentType != "image/jpeg" || info.Size != 9 {
+		t.Fatalf("StatObject = %+v, %v", info, err)
+	}
+
+	rc, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	data, err := io.ReadAll(rc)
+	rc.Close()
+	if err != nil || string(data) != "jpeg data" {
+		t.Fatalf("Open read = %q, %v", data, err)
+	}
+
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete: %v", err)
+	}
+	if _, err := s.Open(ctx, key); !errors.Is(err, ErrNotFound) {
+		t.Fatalf("Open after Delete: err = %v, want ErrNotFound", err)
+	}
+	// удаление отсутствующего объекта — не ошибка
+	if err := s.Delete(ctx, key); err != nil {
+		t.Fatalf("Delete missing: %v", err)
+	}
+}
+
+func TestS3UnknownSizePut(t *testing.T) {
+	s := testS3(t)
+	ctx := context.Background()
+	key := NewKey("reviews", ".png")
+	defer s.Delete(ctx, key)
+
+	if err := s.Put(ctx, key, strings.NewReader("png data"), -1, "image/png"); err != nil {
+		t.Fatalf("Put: %v", err)
+	}
+	rc, err := s.Open(ctx, key)
+	if err != nil {
+		t.Fatalf("Open: %v", err)
+	}
+	defer rc.Close()
+	if data, _ := io.ReadAll(rc); string(data) != "png data" {
+		t.Fatalf("Open read = %q", data)
+	}
+}
+
+func TestNewS3Config(t *testing.T) {
+	tests := []struct {
+		name string
+		cfg  S3Config
+		ok   bool
+	}{
+		{"valid", S3Config{Endpoint: "http://localhost:9000", Bucket: "b", AccessKey: "k", SecretKey: "s"}, true},
+		{"not a URL", S3Config{Endpoint: "localhost:9000", Bucket: "b", AccessKey: "k", SecretKey: "s"}, false},
+		{"endpoint path", S3Config{Endpoint: "http://localhost:9000/s3", Bucket: "b", AccessKey: "k", SecretKey: "s"}, false},
+		{"no bucket", S3Config{Endpoint: "http://localhost:9000", AccessKey: "k", SecretKey: "s"}, false},
+		{"no credentials", S3Config{Endpoint: "http://localhost:9000", Bucket: "b"}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := NewS3(tt.cfg)
+			if (err == nil) != tt.ok {
+				t.Fatalf("NewS3: err = %v, want ok = %v", err, tt.ok)
+			}
+		})
+	}
+}
+
+func TestS3InvalidKey(t *testing.T) {
+	s, err := NewS3(S3Config{Endpoint: "http://127.0.0.1:1", Bucket: "b", AccessKey: "k", SecretKey: "s", PathStyle: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	for _, key := range []string{"", "/abs", "a/../b", "a\\b"} {
+		if _, err := s.Open(context.Background(), key); !errors.Is(err, ErrInvalidKey) {
+			t.Errorf("Open(%q): err = %v, want ErrInvalidKey", key, err)
+		}
+	}
+}
@@ -0,0 +1,79 @@
+// Package storage — хранилище загруженных файлов (резюме, изображения). Файлы адресуются
+// ключом вида "cv/2026/10/<random>.pdf"; в БД хранится только ключ, а не путь на диске,
+// поэтому бэкенд хранилища можно сменить без миграции данных.
+package storage
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"io"
+	"path"
+	"strings"
+	"time"
+)
+
+// Files — хранилище приложения; задаётся один раз при старте (как db.DB).
+var Files Storage
+
+// ErrNotFound — объекта с таким ключом нет.
+var ErrNotFound = errors.New("storage: object not found")
+
+// ErrInvalidKey — ключ пустой, абсолютный или выходит за пределы хранилища ("..").
+var ErrInvalidKey = errors.New("storage: invalid key")
+
+// Storage — хранилище объектов.
+type Storage interface {
+	// Put сохраняет объект целиком; существующий объект с тем же ключом перезаписывается.
+	Put(ctx context.Context, key string, r io.Reader, size int64, contentType string) error
+	// Open открывает объект на чтение; ErrNotFound, если его нет.
+	Open(ctx context.Context, key string) (io.ReadCloser, error)
+	// Delete удаляет объект; отсутствие объекта ошибкой не считается.
+	Delete(ctx context.Context, key string) error
+}
+
+// PublicBaseURL — префикс публичных ссылок на файлы (по умолчанию их раздаёт сам API).
+var PublicBaseURL = "/api/files/"
+
+// publicPrefixes — каталоги, файлы из которых можно отдавать без авторизации.
+// Резюме (cv/) и прочие служебные файлы сюда не входят.
+var publicPrefixes = []string{"reviews/", "products/"}
+
+// PublicURL — ссылка на публичный файл.
+func PublicURL(key string) string {
+	return PublicBaseURL + key
+}
+
+// IsPublic сообщает, можно ли отдавать объект по публичной ссылке.
+func IsPublic(key string) bool {
+	if checkKey(key) != nil {
+		return false
+	}
+	for _, p := range publicPrefixes {
+		if strings.HasPrefix(key, p) {
+			return true
+		}
+	}
+	return false
+}
+
+// NewKey генерирует уникальный ключ в каталоге prefix с расширением ext (".pdf").
+func NewKey(prefix, ext string) string {
+	b := make([]byte, 16)
+	_, _ = rand.Read(b)
+	return path.Join(prefix, time.Now().UTC().Format("2006/01"), hex.EncodeToString(b)+ext)
+}
+
+// checkKey отсекает ключи, которые могут указать за пределы хранилища.
+func checkKey(key string) error {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "\\") || path.Clean(key) != key {
+		return ErrInvalidKey
+	}
+	for _, part := range strings.Split(key, "/") {
+		if part == ".." || part == "." {
+			return ErrInvalidKey
+		}
+	}
+	return nil
+}
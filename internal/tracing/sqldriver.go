@@ -0,0 +1,140 @@
+package tracing
+
+import (
+	"context"
+	"database/sql/driver"
+	"errors"
+	"io"
+	"strings"
+	"unicode"
+
+	"github.com/XSAM/otelsql"
+	"go.opentelemetry.io/otel/attribute"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// maxStatementLen — сколько символов SQL сохраняем в атрибуте db.query.text.
+const maxStatementLen = 2000
+
+// WrapDriver оборачивает database/sql-драйвер через otelsql: каждый запрос, выполненный
+// с контекстом, в котором есть спан, получает дочерний спан "db SELECT" и т.п. В атрибут
+// db.query.text попадает очищенный SQL (литералы заменены на ?), сырой текст запроса не пишется.
+// Запросы без родительского спана (миграции, фоновые задачи) не трассируются.
+func WrapDriver(d driver.Driver) driver.Driver {
+	return otelsql.WrapDriver(d,
+		otelsql.WithAttributes(semconv.DBSystemNamePostgreSQL),
+		otelsql.WithSpanNameFormatter(func(_ context.Context, method otelsql.Method, query string) string {
+			if query == "" {
+				return string(method)
+			}
+			return "db " + sqlOperation(query)
+		}),
+		otelsql.WithAttributesGetter(func(_ context.Context, _ otelsql.Method, query string, _ []driver.NamedValue) []attribute.KeyValue {
+			if query == "" {
+				return nil
+			}
+			return []attribute.KeyValue{
+				semconv.DBOperationName(sqlOperation(query)),
+				semconv.DBQueryText(SanitizeSQL(query)),
+			}
+		}),
+		otelsql.WithSpanOptions(otelsql.SpanOptions{
+			DisableQuery:         true,
+			DisableErrSkip:       true,
+			OmitConnResetSession: true,
+			OmitConnPrepare:      true,
+			OmitRows:             true,
+			// отмена контекста (клиент ушёл) и конец данных ошибкой запроса не считаются
+			RecordError: func(err error) bool {
+				return !errors.Is(err, context.Canceled) && !errors.Is(err, io.EOF)
+			},
+			SpanFilter: func(ctx context.Context, _ otelsql.Method, _ string, _ []driver.NamedValue) bool {
+				return trace.SpanContextFromContext(ctx).IsValid()
+			},
+		}),
+	)
+}
+
+// sqlOperation возвращает первое ключевое слово запроса (SELECT, INSERT, WITH, ...).
+func sqlOperation(query string) string {
+	q := strings.TrimLeftFunc(query, func(r rune) bool { return unicode.IsSpace(r) || r == '(' })
+	end := strings.IndexFunc(q, func(r rune) bool { return !unicode.IsLetter(r) })
+	if end < 0 {
+		end = len(q)
+	}
+	if end == 0 {
+		return "QUERY"
+	}
+	return strings.ToUpper(q[:end])
+}
+
+// SanitizeSQL убирает из запроса всё, что может содержать данные: строковые и числовые
+// литералы заменяются на ?, комментарии удаляются, пробелы схлопываются. Плейсхолдеры $N,
+// идентификаторы и ключевые слова остаются как есть.
+func SanitizeSQL(query string) string {
+	var b strings.Builder
+	b.Grow(len(query))
+	rs := []rune(query)
+	space := false
+
+	isIdent := func(r rune) bool { return unicode.IsLetter(r) || unicode.IsDigit(r) || r == '_' || r == '$' }
+
+	for i := 0; i < len(rs); i++ {
+		r := rs[i]
+		switch {
+		case unicode.IsSpace(r):
+			space = true
+			continue
+		case r == '-' && i+1 < len(rs) && rs[i+1] == '-':
+			for i < len(rs) && rs[i] != '\n' {
+				i++
+			}
+			space = true
+			continue
+		case r == '/' && i+1 < len(rs) && rs[i+1] == '*':
+			i += 2
+			for i+1 < len(rs) && !(rs[i] == '*' && rs[i+1] == '/') {
+				i++
+			}
+			i++
+			space = true
+			continue
+		}
+
+		if space && b.Len() > 0 {
+			b.WriteByte(' ')
+		}
+		space = false
+
+		switch {
+		case r == '\'':
+			// строковый литерал, '' внутри — экранированная кавычка
+			i++
+			for i < len(rs) {
+				if rs[i] == '\'' {
+					if i+1 < len(rs) && rs[i+1] == '\'' {
+						i += 2
+						continue
+					}
+					break
+				}
+				i++
+			}
+			b.WriteByte('?')
+		case unicode.IsDigit(r) && (i == 0 || !isIdent(rs[i-1])):
+			for i+1 < len(rs) && (unicode.IsDigit(rs[i+1]) || rs[i+1] == '.') {
+				i++
+			}
+			b.WriteByte('?')
+		default:
+			b.WriteRune(r)
+		}
+	}
+
+	out := b.String()
+	if len(out) > maxStatementLen {
+		out = strings.ToValidUTF8(out[:maxStatementLen], "") + "..."
+	}
+	return out
+}
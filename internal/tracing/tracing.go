@@ -0,0 +1,80 @@
+// Package tracing настраивает OpenTelemetry: TracerProvider с экспортом в OTLP/HTTP или stdout,
+// пропагацию W3C Trace Context и обёртку database/sql-драйвера (WrapDriver).
+//
+// Пока Setup не вызван (или OTEL_TRACES_EXPORTER=none), глобальный провайдер otel — no-op,
+// и спаны ничего не стоят.
+package tracing
+
+import (
+	"context"
+	"fmt"
+	"os"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	"go.opentelemetry.io/otel/propagation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.37.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// instrumentationName — имя трейсера для спанов, которые приложение открывает само.
+const instrumentationName = "x86trade_backend"
+
+// Config — настройки трассировки (заполняются из config.Tracing).
+type Config struct {
+	Exporter     string            // otlp | stdout | none
+	ServiceName  string            // service.name в ресурсе
+	OTLPEndpoint string            // полный URL, например http://localhost:4318/v1/traces
+	OTLPHeaders  map[string]string // дополнительные заголовки (авторизация коллектора и т.п.)
+	SampleRatio  float64           // доля новых трасс, 0..1
+}
+
+// Setup включает трассировку по конфигу и регистрирует глобальные TracerProvider и пропагатор.
+// Возвращаемая функция дожидается отправки накопленных спанов — её нужно вызвать при остановке сервиса.
+func Setup(cfg Config) (shutdown func(context.Context) error, err error) {
+	noop := func(context.Context) error { return nil }
+
+	var exp sdktrace.SpanExporter
+	switch cfg.Exporter {
+	case "none", "":
+		return noop, nil
+	case "stdout", "console":
+		exp, err = stdouttrace.New(stdouttrace.WithWriter(os.Stdout))
+	case "otlp":
+		exp, err = otlptracehttp.New(context.Background(),
+			otlptracehttp.WithEndpointURL(cfg.OTLPEndpoint),
+			otlptracehttp.WithHeaders(cfg.OTLPHeaders),
+		)
+	default:
+		return noop, fmt.Errorf("tracing: unknown exporter %q", cfg.Exporter)
+	}
+	if err != nil {
+		return noop, fmt.Errorf("tracing: %s exporter: %w", cfg.Exporter, err)
+	}
+
+	res, err := resource.Merge(resource.Default(),
+		resource.NewSchemaless(semconv.ServiceName(cfg.ServiceName)))
+	if err != nil {
+		return noop, fmt.Errorf("tracing: resource: %w", err)
+	}
+
+	tp := sdktrace.NewTracerProvider(
+		sdktrace.WithBatcher(exp),
+		sdktrace.WithResource(res),
+		// решение о семплировании принимает тот, кто начал трассу; для новых трасс — доля SampleRatio
+		sdktrace.WithSampler(sdktrace.ParentBased(sdktrace.TraceIDRatioBased(cfg.SampleRatio))),
+	)
+	otel.SetTracerProvider(tp)
+	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(
+		propagation.TraceContext{}, propagation.Baggage{},
+	))
+	return tp.Shutdown, nil
+}
+
+// Tracer возвращает трейсер приложения из глобального провайдера.
+func Tracer() trace.Tracer {
+	return otel.Tracer(instrumentationName)
+}
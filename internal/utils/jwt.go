@@ -2,22 +2,30 @@ package utils
 
 import (
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret []byte
+var (
+	jwtSecret     []byte
+	accessMinutes = 15
+	refreshDays   = 7
+)
 
-func init() {
-	s := os.Getenv("JWT_SECRET")
-	if s == "" {
-		s = "verysecretkey" // default for dev; override in .env
-	}
-	jwtSecret = []byte(s)
+// ConfigureJWT задаёт секрет и сроки жизни токенов (вызывается из main по загруженному конфигу).
+func ConfigureJWT(secret string, accessMin, refreshD int) {
+	jwtSecret = []byte(secret)
+	accessMinutes = accessMin
+	refreshDays = refreshD
 }
 
+// AccessTokenMinutes — срок жизни access-токена в минутах.
+func AccessTokenMinutes() int { return accessMinutes }
+
+// RefreshTokenDays — срок жизни refresh-токена в днях.
+func RefreshTokenDays() int { return refreshDays }
+
 type Claims struct {
 	UserID int `json:"user_id"`
 	jwt.RegisteredClaims
@@ -0,0 +1,65 @@
+package validation
+
+import (
+	"net/mail"
+	"regexp"
+	"strings"
+)
+
+// Стабильные коды ошибок полей — на них опирается фронтенд, менять нельзя.
+const (
+	CodeRequired      = "required"
+	CodeNotFound      = "not_found"
+	CodeInvalidFormat = "invalid_format"
+	CodeTooLong       = "too_long"
+	CodeNotAllowed    = "not_allowed"
+)
+
+// FieldError — ошибка одного поля запроса.
+type FieldError struct {
+	Field   string `json:"field"`
+	Code    string `json:"code"`
+	Message string `json:"message"`
+}
+
+// Errors накапливает ошибки полей; пустой Errors означает, что запрос валиден.
+type Errors []FieldError
+
+func (e *Errors) Add(field, code, message string) {
+	*e = append(*e, FieldError{Field: field, Code: code, Message: message})
+}
+
+func (e Errors) HasErrors() bool {
+	return len(e) > 0
+}
+
+func (e Errors) Error() string {
+	parts := make([]string, 0, len(e))
+	for _, fe := range e {
+		parts = append(parts, fe.Field+": "+fe.Code)
+	}
+	return "validation failed: " + strings.Join(parts, ", ")
+}
+
+// phoneRe — допускаем +, цифры, пробелы, скобки и дефисы; количество цифр проверяется отдельно.
+var phoneRe = regexp.MustCompile(`^\+?[0-9 ()\-]+$`)
+
+// IsValidPhone проверяет телефон: 10–15 цифр (E.164) в допустимом написании.
+func IsValidPhone(s string) bool {
+	if !phoneRe.MatchString(s) {
+		return false
+	}
+	digits := 0
+	for _, c := range s {
+		if c >= '0' && c <= '9' {
+			digits++
+		}
+	}
+	return digits >= 10 && digits <= 15
+}
+
+// IsValidEmail проверяет, что строка — один голый адрес e-mail (без имени и угловых скобок).
+func IsValidEmail(s string) bool {
+	a, err := mail.ParseAddress(s)
+	return err == nil && a.Address == s && strings.Contains(s[strings.LastIndexByte(s, '@'):], ".")
+}